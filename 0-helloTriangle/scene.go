@@ -0,0 +1,106 @@
+package hellotriangle
+
+import (
+	"github.com/Grindlemire/gl/engine"
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// width and height of the window we are creating
+const (
+	winWidth  = 500
+	winHeight = 500
+)
+
+var triangle = []float32{
+	0, 0.5, 0, // top
+	-0.5, -0.5, 0, // left
+	0.5, -0.5, 0, // right
+}
+
+// vertex shader program that just passes through the posistion. Null terminate it C
+var vertexShaderSource = `
+	#version 410
+	in vec3 vp;
+	void main() {
+		gl_Position = vec4(vp, 1.0);
+	}
+` + "\x00"
+
+// fragment shader program that just sets the color of the fragment to white. Null terminate it for C
+var fragmentShaderSource = `
+	#version 410
+	out vec4 frag_color;
+	void main(){
+		frag_color = vec4(1, 1, 1, 1);
+	}
+` + "\x00"
+
+func init() {
+	engine.Register(engine.Scene{
+		Name:   "helloTriangle",
+		Title:  "Hello Triangle",
+		Width:  winWidth,
+		Height: winHeight,
+		Setup:  setup,
+	})
+}
+
+// setup sets the hooks that run the hello triangle
+func setup(a *engine.App) {
+	var program, vao, vbo uint32
+
+	a.Init = func(*engine.App) (err error) {
+		program, err = engine.NewProgram(vertexShaderSource, fragmentShaderSource)
+		if err != nil {
+			return err
+		}
+
+		vao, vbo = makeVAO(triangle)
+		return nil
+	}
+	a.Render = func(alpha float64) {
+		draw(vao, program)
+	}
+	a.Shutdown = func() {
+		gl.DeleteVertexArrays(1, &vao)
+		gl.DeleteBuffers(1, &vbo)
+		engine.DeleteProgram(program)
+	}
+}
+
+// draw draws each frame
+func draw(vao uint32, program uint32) {
+	// clear previous frame
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+	// use our program
+	gl.UseProgram(program)
+
+	// bind to the vao so when we call draw it knows which to draw
+	gl.BindVertexArray(vao)
+	// draw our array. Tell it to draw triangles, start at the first vertex and draw three vertices
+	countVertices := int32(len(triangle) / 3)
+	gl.DrawArrays(gl.TRIANGLES, 0, countVertices)
+}
+
+// makeVAO takes a list of vertices and makes a vertex array object from them. It hands back the
+// vertex buffer object too so it can be deleted when we are done with it
+func makeVAO(vertices []float32) (vao, vbo uint32) {
+	// create vertex buffer object
+	// generate the buffer with this memory (we only want 1 of them)
+	gl.GenBuffers(1, &vbo)
+	// bind the buffer to our variable and say it is an array buffer
+	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
+	// place the data  in the buffer and tell it how long the array is
+	gl.BufferData(gl.ARRAY_BUFFER, 4*len(vertices), gl.Ptr(vertices), gl.STATIC_DRAW)
+
+	// generate the vertex array (we only want 1 array)
+	gl.GenVertexArrays(1, &vao)
+	// bind the vertex array to our variable
+	gl.BindVertexArray(vao)
+	// tell the vao about our data. args(index to start at, the number of floats per point, the type, do we normalize, how much space before the next point, global offset)
+	gl.VertexAttribPointer(0, 3, gl.FLOAT, false, 0, nil)
+	// enable the vao
+	gl.EnableVertexAttribArray(0)
+
+	return vao, vbo
+}
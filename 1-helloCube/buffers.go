@@ -1,8 +1,7 @@
-package main
+package hellocube
 
 import (
-	"fmt"
-
+	"github.com/Grindlemire/gl/engine"
 	"github.com/go-gl/gl/v4.1-core/gl"
 )
 
@@ -14,12 +13,18 @@ type VertexBufferObject struct {
 
 // NewVBO creates a vertex buffer object and copies the vertices into it
 func NewVBO(vertices []float32) (vbo VertexBufferObject) {
-	gl.GenBuffers(1, &vbo.addr)
-	gl.BindBuffer(gl.ARRAY_BUFFER, vbo.addr)
-	gl.BufferData(gl.ARRAY_BUFFER, 4*len(vertices), gl.Ptr(vertices), gl.STATIC_DRAW)
+	vbo.addr = engine.GenBuffer()
+	engine.State.BindBuffer(gl.ARRAY_BUFFER, vbo.addr)
+	engine.Backend.BufferData(gl.ARRAY_BUFFER, 4*len(vertices), vertices, gl.STATIC_DRAW)
+	engine.CheckError("NewVBO")
 	return vbo
 }
 
+// Delete deletes the vbo
+func (vbo VertexBufferObject) Delete() {
+	engine.DeleteBuffer(vbo.addr)
+}
+
 // VertexArrayObject wraps the openGL VAO. It points to the data loaded in with the vbo
 type VertexArrayObject struct {
 	addr uint32
@@ -27,20 +32,26 @@ type VertexArrayObject struct {
 
 // NewVAO creates a vertex array object
 func NewVAO() (vao VertexArrayObject) {
-	gl.GenVertexArrays(1, &vao.addr)
-	gl.BindVertexArray(vao.addr)
+	vao.addr = engine.GenVertexArray()
+	engine.State.BindVertexArray(vao.addr)
 	return vao
 }
 
+// Delete deletes the vao. The buffers it points to have to be deleted separately
+func (vao VertexArrayObject) Delete() {
+	engine.DeleteVertexArray(vao.addr)
+}
+
 // MapAttribute maps data to a specific attribute from the VAO
 // Take the data in the VAO (it points to the data loaded into the VBO) and map it to some
 // input passed to the shaders. This takes a pointer to the program, the name of the input in GLSL,
 // the offset into the data you set, the number of elements in the data you set, and the stride (how
 // many floats between instances of this data)
 func (vao VertexArrayObject) MapAttribute(program uint32, name string, offset int, size, stride int32) {
-	attributeAddress := uint32(gl.GetAttribLocation(program, gl.Str(fmt.Sprintf("%s\x00", name))))
-	gl.VertexAttribPointer(attributeAddress, size, gl.FLOAT, false, stride*4, gl.PtrOffset(offset))
-	gl.EnableVertexAttribArray(attributeAddress)
+	attributeAddress := uint32(engine.Backend.GetAttribLocation(program, name))
+	engine.Backend.VertexAttribPointer(attributeAddress, size, gl.FLOAT, false, stride*4, offset)
+	engine.Backend.EnableVertexAttribArray(attributeAddress)
+	engine.CheckError("MapAttribute")
 }
 
 // ElementBufferObject wraps the openGL EBO. It is an efficient way of specifying your triangles
@@ -51,8 +62,14 @@ type ElementBufferObject struct {
 
 // NewEBO creates a new element buffer object
 func NewEBO(elements []uint32) (ebo ElementBufferObject) {
-	gl.GenBuffers(1, &ebo.addr)
-	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, ebo.addr)
-	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, 4*len(elements), gl.Ptr(elements), gl.STATIC_DRAW)
+	ebo.addr = engine.GenBuffer()
+	engine.State.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, ebo.addr)
+	engine.Backend.BufferData(gl.ELEMENT_ARRAY_BUFFER, 4*len(elements), elements, gl.STATIC_DRAW)
+	engine.CheckError("NewEBO")
 	return ebo
 }
+
+// Delete deletes the ebo
+func (ebo ElementBufferObject) Delete() {
+	engine.DeleteBuffer(ebo.addr)
+}
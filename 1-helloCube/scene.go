@@ -0,0 +1,119 @@
+package hellocube
+
+import (
+	"github.com/Grindlemire/gl/engine"
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// width and height of the window we are creating
+const (
+	winWidth  = 500
+	winHeight = 500
+)
+
+func init() {
+	engine.Register(engine.Scene{
+		Name:   "helloCube",
+		Title:  "Hello Cube",
+		Width:  winWidth,
+		Height: winHeight,
+		Setup:  setup,
+	})
+}
+
+// setup sets the hooks that run the hello cube. A small cube is attached to the big one so it is
+// carried around as the big one spins while it spins on its own
+func setup(a *engine.App) {
+	var (
+		program    uint32
+		models     *engine.ModelUniforms
+		projection *Projection
+		vao        VertexArrayObject
+		vbo        VertexBufferObject
+		ebo        ElementBufferObject
+	)
+
+	// spin the cube at a fixed rate no matter how fast we are rendering. We keep the previous
+	// angle around so rendering can blend between the last two updates
+	angle, previousAngle := 0.0, 0.0
+
+	// both cubes draw the same vertices, only their world matrices differ
+	drawCube := func() {
+		engine.State.BindVertexArray(vao.addr) // the cache skips this when the vao is already bound
+		engine.DrawElements(gl.TRIANGLES, 6*6, gl.UNSIGNED_INT, 0)
+	}
+	cube := engine.NewNode("cube")
+	cube.Draw = drawCube
+	moon := engine.NewNode("moon")
+	moon.Draw = drawCube
+	moon.SetPosition(mgl32.Vec3{1.6, 0, 0})
+	moon.SetScale(mgl32.Vec3{0.25, 0.25, 0.25})
+	_ = cube.Attach(moon)
+
+	a.Init = func(*engine.App) (err error) {
+		program, err = engine.NewProgram(vertexShaderSrc, fragShaderSrc)
+		if err != nil {
+			return err
+		}
+
+		// create our transformations
+		models = engine.NewModelUniforms(program)
+		_ = NewView(program, "view", mgl32.Vec3{3, 3, 3}, mgl32.Vec3{0, 0, 0}, mgl32.Vec3{0, 1, 0})
+		projection = NewProjection(program, "projection")
+
+		// load our data into our buffers
+		vao = NewVAO()
+		vbo = NewVBO(cubeVertices)
+		ebo = NewEBO(cubeElements)
+
+		// name everything so debug messages and tools like RenderDoc can tell us what they mean
+		engine.Label(gl.PROGRAM, program, "cube")
+		engine.Label(gl.VERTEX_ARRAY, vao.addr, "cube")
+		engine.Label(gl.BUFFER, vbo.addr, "cube vertices")
+		engine.Label(gl.BUFFER, ebo.addr, "cube elements")
+
+		// map our data into the shader
+		vao.MapAttribute(program, "vert", 0, 3, 0)
+
+		// enable depth of field and general constants
+		engine.State.Enable(gl.DEPTH_TEST)
+		engine.State.DepthFunc(gl.LESS)
+		gl.ClearColor(0.0, 0.0, 0.0, 0.0)
+
+		// draw a wireframe instead of filling
+		engine.State.PolygonMode(gl.LINE)
+		return nil
+	}
+
+	a.Update = func(dt float64) {
+		previousAngle = angle
+		angle += dt
+	}
+
+	a.Render = func(alpha float64) {
+		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+
+		// calculate the angle between the last two updates
+		renderAngle := float32(previousAngle + (angle-previousAngle)*alpha)
+		cube.SetRotation(mgl32.QuatRotate(renderAngle, mgl32.Vec3{0, 1, 0}))
+		moon.SetRotation(mgl32.QuatRotate(3*renderAngle, mgl32.Vec3{1, 0, 0}))
+
+		// render. Each cube's world matrix is sent to the model uniform before it is drawn
+		engine.State.UseProgram(program)
+		cube.Render(models)
+	}
+
+	a.Resize = func(width, height int) {
+		engine.State.UseProgram(program)
+		projection.UpdateAspect(width, height)
+		projection.UpdateUniform()
+	}
+
+	a.Shutdown = func() {
+		vao.Delete()
+		vbo.Delete()
+		ebo.Delete()
+		engine.DeleteProgram(program)
+	}
+}
@@ -1,4 +1,4 @@
-package main
+package hellocube
 
 var vertexShaderSrc = `
 	#version 410
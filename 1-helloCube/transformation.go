@@ -1,9 +1,7 @@
-package main
+package hellocube
 
 import (
-	"fmt"
-
-	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/Grindlemire/gl/engine"
 	"github.com/go-gl/mathgl/mgl32"
 )
 
@@ -21,7 +19,8 @@ type Transformation struct {
 // This is called when the transformation matrix has changed and we want to push that change
 // to the shader
 func (t *Transformation) UpdateUniform() {
-	gl.UniformMatrix4fv(t.addr, 1, false, &t.matrix[0])
+	engine.Backend.UniformMatrix4fv(t.addr, 1, false, &t.matrix[0])
+	engine.CheckError("UpdateUniform")
 }
 
 // UpdateMatrix updates the matrix to the new matrix
@@ -51,9 +50,9 @@ func NewProjection(program uint32, name string) (projection *Projection) {
 	// create the transformation matrix
 	matrix := mgl32.Perspective(mgl32.DegToRad(45.0), float32(winWidth)/winHeight, 0.1, 10.0)
 	// get the location in memory where we need to place it
-	addr := gl.GetUniformLocation(program, gl.Str(fmt.Sprintf("%s\x00", name)))
+	addr := engine.Backend.GetUniformLocation(program, name)
 	// load the data into the memory location
-	gl.UniformMatrix4fv(addr, 1, false, &matrix[0])
+	engine.Backend.UniformMatrix4fv(addr, 1, false, &matrix[0])
 
 	projection = &Projection{
 		Transformation{
@@ -65,6 +64,15 @@ func NewProjection(program uint32, name string) (projection *Projection) {
 	return projection
 }
 
+// UpdateAspect rebuilds the projection matrix for a window with a new width and height
+func (p *Projection) UpdateAspect(width, height int) {
+	// a minimized window has no height so keep the old aspect ratio
+	if height == 0 {
+		return
+	}
+	p.matrix = mgl32.Perspective(mgl32.DegToRad(45.0), float32(width)/float32(height), 0.1, 10.0)
+}
+
 // View manages the view transformation matrix (it converts world coordinates to camera coordinates)
 // This remaps everything in the world with respect to some camera somewhere
 type View struct {
@@ -77,8 +85,8 @@ type View struct {
 func NewView(program uint32, name string, looking, located, up mgl32.Vec3) (view *View) {
 	// create the view transformation matrix with
 	matrix := mgl32.LookAtV(looking, located, up)
-	addr := gl.GetUniformLocation(program, gl.Str(fmt.Sprintf("%s\x00", name)))
-	gl.UniformMatrix4fv(addr, 1, false, &matrix[0])
+	addr := engine.Backend.GetUniformLocation(program, name)
+	engine.Backend.UniformMatrix4fv(addr, 1, false, &matrix[0])
 
 	view = &View{
 		Transformation{
@@ -101,8 +109,8 @@ type Model struct {
 func NewModel(program uint32, name string) (model *Model) {
 	// transform from world coordinates
 	matrix := mgl32.Ident4()
-	addr := gl.GetUniformLocation(program, gl.Str(fmt.Sprintf("%s\x00", name)))
-	gl.UniformMatrix4fv(addr, 1, false, &matrix[0])
+	addr := engine.Backend.GetUniformLocation(program, name)
+	engine.Backend.UniformMatrix4fv(addr, 1, false, &matrix[0])
 
 	model = &Model{
 		Transformation{
@@ -0,0 +1,191 @@
+package instancing
+
+import (
+	"log"
+	"math/rand"
+
+	"github.com/Grindlemire/gl/engine"
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// width and height of the window we are creating
+const (
+	winWidth  = 960
+	winHeight = 540
+)
+
+// the cubes sit in a grid this many across, deep and high, this far apart
+const (
+	gridWidth   = 100
+	gridDepth   = 100
+	gridHeight  = 10
+	gridSpacing = 2.5
+)
+
+// eye is where the camera sits looking down over the grid
+var eye = mgl32.Vec3{0, 110, 230}
+
+func init() {
+	engine.Register(engine.Scene{
+		Name:   "instancing",
+		Title:  "Instancing",
+		Width:  winWidth,
+		Height: winHeight,
+		Setup:  setup,
+	})
+}
+
+// spinner is where one cube sits in the grid and how it spins
+type spinner struct {
+	position mgl32.Vec3
+	axis     mgl32.Vec3
+	speed    float32
+}
+
+// setup sets the hooks that run the instancing demo. Every cube in the grid spins on its own axis
+// and they are all drawn with a single instanced draw call. Space pauses the spinning and S logs
+// how many draw calls and triangles the last frame took
+func setup(a *engine.App) {
+	var (
+		program    uint32
+		projection mgl32.Mat4
+		frame      *engine.FrameUniforms
+		cube       *engine.MeshBuffers
+		buffer     *engine.InstanceBuffer
+		spinners   []spinner
+		instances  []engine.Instance
+		paused     bool
+	)
+
+	// spin at a fixed rate no matter how fast we are rendering. We keep the previous angle
+	// around so rendering can blend between the last two updates
+	angle, previousAngle := 0.0, 0.0
+
+	a.Init = func(*engine.App) (err error) {
+		program, err = engine.NewProgram(vertexShaderSrc, fragShaderSrc)
+		if err != nil {
+			return err
+		}
+		engine.Label(gl.PROGRAM, program, "instancing")
+
+		frame, err = engine.NewFrameUniforms()
+		if err != nil {
+			return err
+		}
+		err = frame.Bind(program)
+		if err != nil {
+			return err
+		}
+
+		// the same random axes every run so the grid always looks the same
+		random := rand.New(rand.NewSource(1))
+		spinners = make([]spinner, 0, gridWidth*gridDepth*gridHeight)
+		instances = make([]engine.Instance, gridWidth*gridDepth*gridHeight)
+		for y := 0; y < gridHeight; y++ {
+			for z := 0; z < gridDepth; z++ {
+				for x := 0; x < gridWidth; x++ {
+					axis := mgl32.Vec3{random.Float32() - 0.5, random.Float32() - 0.5, random.Float32() - 0.5}
+					if axis.Len() < 0.01 {
+						axis = mgl32.Vec3{0, 1, 0}
+					}
+					spinners = append(spinners, spinner{
+						position: mgl32.Vec3{
+							(float32(x) - gridWidth/2) * gridSpacing,
+							float32(y) * gridSpacing,
+							(float32(z) - gridDepth/2) * gridSpacing,
+						},
+						axis:  axis.Normalize(),
+						speed: 0.5 + 2*random.Float32(),
+					})
+					// color the grid from one corner to the other
+					instances[len(spinners)-1].Color = mgl32.Vec4{
+						float32(x) / gridWidth,
+						0.3 + 0.7*float32(y)/gridHeight,
+						float32(z) / gridDepth,
+						1,
+					}
+				}
+			}
+		}
+
+		cube = engine.NewMeshBuffers(engine.NewCubeMesh())
+		engine.Label(gl.VERTEX_ARRAY, cube.VAO, "cube")
+		buffer = engine.NewInstanceBuffer(len(instances))
+		buffer.Attach(cube.VAO)
+
+		a.Window.SetKeyCallback(func(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+			if action != glfw.Press {
+				return
+			}
+			switch key {
+			case glfw.KeyEscape:
+				w.SetShouldClose(true)
+			case glfw.KeySpace:
+				paused = !paused
+			case glfw.KeyS:
+				s := engine.Draws.LastFrame
+				log.Printf("%d cubes in %d draw calls with %d triangles", buffer.Count, s.Calls, s.Triangles)
+			}
+		})
+
+		// enable depth of field and general constants
+		engine.State.Enable(gl.DEPTH_TEST)
+		engine.State.DepthFunc(gl.LESS)
+		engine.State.Enable(gl.CULL_FACE)
+		gl.ClearColor(0.05, 0.05, 0.08, 1.0)
+		return nil
+	}
+
+	a.Update = func(dt float64) {
+		previousAngle = angle
+		if !paused {
+			angle += dt
+		}
+	}
+
+	a.Render = func(alpha float64) {
+		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+
+		// calculate the angle between the last two updates and only upload the cubes again when
+		// they have turned
+		renderAngle := float32(previousAngle + (angle-previousAngle)*alpha)
+		if buffer.Count == 0 || angle != previousAngle {
+			for i, s := range spinners {
+				translate := mgl32.Translate3D(s.position.X(), s.position.Y(), s.position.Z())
+				instances[i].Model = translate.Mul4(mgl32.HomogRotate3D(renderAngle*s.speed, s.axis))
+			}
+			buffer.Update(instances)
+		}
+
+		err := frame.SetCamera(mgl32.LookAtV(eye, mgl32.Vec3{0, 0, 0}, mgl32.Vec3{0, 1, 0}), projection, eye)
+		if err != nil {
+			log.Printf("unable to set the camera: %v", err)
+			return
+		}
+		engine.State.UseProgram(program)
+		cube.DrawInstanced(buffer.Count)
+	}
+
+	a.Resize = func(width, height int) {
+		// a minimized window has no height so keep the old aspect ratio
+		if height == 0 {
+			return
+		}
+		projection = mgl32.Perspective(mgl32.DegToRad(45.0), float32(width)/float32(height), 0.1, 1000.0)
+	}
+
+	a.Shutdown = func() {
+		if frame != nil {
+			frame.Delete()
+		}
+		if cube != nil {
+			cube.Delete()
+		}
+		if buffer != nil {
+			buffer.Delete()
+		}
+		engine.DeleteProgram(program)
+	}
+}
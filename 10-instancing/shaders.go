@@ -0,0 +1,43 @@
+package instancing
+
+import (
+	"github.com/Grindlemire/gl/engine"
+)
+
+// the vertex inputs are where engine.MeshBuffers puts them and the instance inputs are where
+// engine.InstanceBuffer puts them
+var vertexShaderSrc = `
+	#version 410
+` + engine.CameraGLSL + `
+	layout(location = 0) in vec3 vert;
+	layout(location = 2) in vec3 vertNormal;
+	layout(location = 4) in mat4 instanceModel;
+	layout(location = 8) in vec4 instanceColor;
+
+	out vec3 fragNormal;
+	out vec4 fragColor;
+
+	void main() {
+		// the cubes are only rotated and moved so the model matrix works for normals too
+		fragNormal = mat3(instanceModel) * vertNormal;
+		fragColor = instanceColor;
+		gl_Position = projection * view * instanceModel * vec4(vert, 1.0);
+	}
+` + "\x00"
+
+var fragShaderSrc = `
+	#version 410
+
+	const vec3 lightDirection = normalize(vec3(-0.4, -1.0, -0.6));
+	const float ambient = 0.25;
+
+	in vec3 fragNormal;
+	in vec4 fragColor;
+
+	out vec4 outputColor;
+
+	void main() {
+		float diffuse = max(dot(normalize(fragNormal), -lightDirection), 0.0);
+		outputColor = vec4(fragColor.rgb * (ambient + diffuse), fragColor.a);
+	}
+` + "\x00"
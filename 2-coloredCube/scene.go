@@ -0,0 +1,109 @@
+package coloredcube
+
+import (
+	"github.com/Grindlemire/gl/engine"
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// width and height of the window we are creating
+const (
+	winWidth  = 500
+	winHeight = 500
+)
+
+func init() {
+	engine.Register(engine.Scene{
+		Name:   "coloredCube",
+		Title:  "Colored Cube",
+		Width:  winWidth,
+		Height: winHeight,
+		Setup:  setup,
+	})
+}
+
+// setup sets the hooks that run the colored cube
+func setup(a *engine.App) {
+	var (
+		program    uint32
+		model      *Model
+		projection *Projection
+		vao        VertexArrayObject
+		vbo        VertexBufferObject
+		ebo        ElementBufferObject
+	)
+
+	// spin the cube at a fixed rate no matter how fast we are rendering. We keep the previous
+	// angle around so rendering can blend between the last two updates
+	angle, previousAngle := 0.0, 0.0
+
+	a.Init = func(*engine.App) (err error) {
+		program, err = engine.NewProgram(vertexShaderSrc, fragShaderSrc)
+		if err != nil {
+			return err
+		}
+
+		// create our transformations
+		model = NewModel(program, "model")
+		_ = NewView(program, "view", mgl32.Vec3{3, 3, 3}, mgl32.Vec3{0, 0, 0}, mgl32.Vec3{0, 1, 0})
+		projection = NewProjection(program, "projection")
+
+		// load our data into our buffers
+		vao = NewVAO()
+		vbo = NewVBO(cubeVertices)
+		ebo = NewEBO(cubeElements)
+
+		// name everything so debug messages and tools like RenderDoc can tell us what they mean
+		engine.Label(gl.PROGRAM, program, "cube")
+		engine.Label(gl.VERTEX_ARRAY, vao.addr, "cube")
+		engine.Label(gl.BUFFER, vbo.addr, "cube vertices")
+		engine.Label(gl.BUFFER, ebo.addr, "cube elements")
+
+		// map our data into the shader
+		vao.MapAttribute(program, "vert", 0, 3, 6)
+		vao.MapAttribute(program, "color", 3, 3, 6)
+
+		// enable depth of field and general constants
+		engine.State.Enable(gl.DEPTH_TEST)
+		engine.State.DepthFunc(gl.LESS)
+		gl.ClearColor(0.0, 0.0, 0.0, 0.0)
+
+		// draw a wireframe instead of filling
+		engine.State.PolygonMode(gl.FILL)
+		return nil
+	}
+
+	a.Update = func(dt float64) {
+		previousAngle = angle
+		angle += dt
+	}
+
+	a.Render = func(alpha float64) {
+		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+
+		// calculate the angle between the last two updates
+		renderAngle := previousAngle + (angle-previousAngle)*alpha
+		model.UpdateMatrix(mgl32.HomogRotate3D(float32(renderAngle), mgl32.Vec3{0, 1, 0}))
+
+		// render
+		engine.State.UseProgram(program)
+		// This sends the updated model transformation to the shaders so we get rotation
+		model.UpdateUniform()
+
+		engine.State.BindVertexArray(vao.addr) // the cache skips this when the vao is already bound
+		engine.DrawElements(gl.TRIANGLES, 6*6, gl.UNSIGNED_INT, 0)
+	}
+
+	a.Resize = func(width, height int) {
+		engine.State.UseProgram(program)
+		projection.UpdateAspect(width, height)
+		projection.UpdateUniform()
+	}
+
+	a.Shutdown = func() {
+		vao.Delete()
+		vbo.Delete()
+		ebo.Delete()
+		engine.DeleteProgram(program)
+	}
+}
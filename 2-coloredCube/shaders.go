@@ -1,4 +1,4 @@
-package main
+package coloredcube
 
 var vertexShaderSrc = `
 	#version 410
@@ -0,0 +1,129 @@
+package texturedcube
+
+import (
+	"log"
+
+	"github.com/Grindlemire/gl/assets"
+	"github.com/Grindlemire/gl/engine"
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// width and height of the window we are creating
+const (
+	winWidth  = 500
+	winHeight = 500
+)
+
+func init() {
+	engine.Register(engine.Scene{
+		Name:   "texturedCube",
+		Title:  "Textured Cube",
+		Width:  winWidth,
+		Height: winHeight,
+		Setup:  setup,
+	})
+}
+
+// setup sets the hooks that run the textured cube
+func setup(a *engine.App) {
+	var (
+		program    uint32
+		model      *Model
+		projection *Projection
+		vao        VertexArrayObject
+		vbo        VertexBufferObject
+		loader     *engine.Loader
+		texture    *engine.Texture
+	)
+
+	// spin the cube at a fixed rate no matter how fast we are rendering. We keep the previous
+	// angle around so rendering can blend between the last two updates
+	angle, previousAngle := 0.0, 0.0
+
+	a.Init = func(*engine.App) (err error) {
+		program, err = engine.NewProgram(vertexShaderSrc, fragShaderSrc)
+		if err != nil {
+			return err
+		}
+
+		// create our transformations
+		model = NewModel(program, "model")
+		_ = NewView(program, "view", mgl32.Vec3{5, 5, 5}, mgl32.Vec3{0, 0, 0}, mgl32.Vec3{0, 1, 0})
+		projection = NewProjection(program, "projection")
+
+		// load our data into our buffers
+		vao = NewVAO()
+		vbo = NewVBO(cubeVertices)
+
+		// name everything so debug messages and tools like RenderDoc can tell us what they mean
+		engine.Label(gl.PROGRAM, program, "cube")
+		engine.Label(gl.VERTEX_ARRAY, vao.addr, "cube")
+		engine.Label(gl.BUFFER, vbo.addr, "cube vertices")
+
+		// load our texture in the background so we don't hold up the first frames decoding it.
+		// The cube draws black until it is ready
+		gl.Uniform1i(gl.GetUniformLocation(program, gl.Str("texSampler\x00")), 0)
+		loader = engine.NewLoader(1)
+		loader.LoadTexture(assets.FS, "wall.jpg", func(t *engine.Texture, err error) {
+			if err != nil {
+				log.Printf("Error loading texture: %v", err)
+				return
+			}
+			texture = t
+		})
+
+		// map our data into the shader
+		vao.MapAttribute(program, "vert", 0, 3, 5)
+		vao.MapAttribute(program, "vertTexCoord", 3, 2, 5)
+
+		// enable depth of field and general constants
+		engine.State.Enable(gl.DEPTH_TEST)
+		engine.State.DepthFunc(gl.LESS)
+		gl.ClearColor(0.0, 0.0, 0.0, 0.0)
+
+		// draw a wireframe instead of filling
+		engine.State.PolygonMode(gl.FILL)
+		return nil
+	}
+
+	a.Update = func(dt float64) {
+		previousAngle = angle
+		angle += dt
+	}
+
+	a.Render = func(alpha float64) {
+		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+		if texture != nil {
+			texture.Bind(gl.TEXTURE0)
+		}
+
+		// calculate the angle between the last two updates
+		renderAngle := previousAngle + (angle-previousAngle)*alpha
+		model.UpdateMatrix(mgl32.HomogRotate3D(float32(renderAngle), mgl32.Vec3{0, 1, 0}))
+
+		// render
+		engine.State.UseProgram(program)
+		// This sends the updated model transformation to the shaders so we get rotation
+		model.UpdateUniform()
+
+		engine.State.BindVertexArray(vao.addr) // the cache skips this when the vao is already bound
+		engine.DrawArrays(gl.TRIANGLES, 0, 6*6)
+	}
+
+	a.Resize = func(width, height int) {
+		engine.State.UseProgram(program)
+		projection.UpdateAspect(width, height)
+		projection.UpdateUniform()
+	}
+
+	a.Shutdown = func() {
+		loader.Close()
+		if texture != nil {
+			texture.Delete()
+		}
+		vao.Delete()
+		vbo.Delete()
+		engine.DeleteProgram(program)
+	}
+}
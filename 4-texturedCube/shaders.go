@@ -1,4 +1,4 @@
-package main
+package texturedcube
 
 var vertexShaderSrc = `
 	#version 410
@@ -1,4 +1,4 @@
-package main
+package inputcapturing
 
 import (
 	"math"
@@ -10,12 +10,15 @@ import (
 
 // Camera manages the location of the camera
 type Camera struct {
-	position mgl32.Vec3
-	front    mgl32.Vec3
-	up       mgl32.Vec3
+	position         mgl32.Vec3
+	previousPosition mgl32.Vec3
+	front            mgl32.Vec3
+	up               mgl32.Vec3
 
-	yaw   float64
-	pitch float64
+	yaw           float64
+	pitch         float64
+	previousYaw   float64
+	previousPitch float64
 
 	xoffset  float64
 	yoffset  float64
@@ -26,19 +29,22 @@ type Camera struct {
 	view *View
 
 	keys map[string]*Key
+	axes map[string]*Axis
 
 	cameraSpeed float32
+	lookSpeed   float64
 }
 
 // NewCamera creates a new camera to manage the view matrix
 func NewCamera(program uint32, name string, position, front, up mgl32.Vec3) (c *Camera) {
 	c = &Camera{
-		position: position,
-		up:       up,
-		front:    front,
+		position:         position,
+		previousPosition: position,
+		up:               up,
+		front:            front,
 
 		cameraSpeed: 2.5,
-		yaw:         -90,
+		lookSpeed:   120,
 		firstPos:    true,
 
 		view: NewView(program, name, position, position.Add(front), up),
@@ -61,8 +67,32 @@ func NewCamera(program uint32, name string, position, front, up mgl32.Vec3) (c *
 				Pressed: false,
 			},
 		},
+
+		// sticks report up as -1 so invert the y axes to move forward and look up
+		axes: map[string]*Axis{
+			"forward": &Axis{
+				GamepadAxis: AxisLeftY,
+				Invert:      true,
+			},
+			"strafe": &Axis{
+				GamepadAxis: AxisLeftX,
+			},
+			"yaw": &Axis{
+				GamepadAxis: AxisRightX,
+			},
+			"pitch": &Axis{
+				GamepadAxis: AxisRightY,
+				Invert:      true,
+			},
+		},
 	}
 
+	// start the yaw and pitch off pointing the way front does
+	front = front.Normalize()
+	c.yaw = mgl64.RadToDeg(math.Atan2(float64(front.Z()), float64(front.X())))
+	c.pitch = mgl64.RadToDeg(math.Asin(float64(front.Y())))
+	c.previousYaw, c.previousPitch = c.yaw, c.pitch
+
 	return c
 }
 
@@ -82,7 +112,13 @@ func (c *Camera) ProcessMouseMove(xpos, ypos float64) {
 
 	c.yaw += c.xoffset
 	c.pitch += c.yoffset
+	c.updateFront()
 
+	return
+}
+
+// updateFront points the camera in the direction of the current yaw and pitch
+func (c *Camera) updateFront() {
 	if c.pitch > 89.0 {
 		c.pitch = 89.0
 	}
@@ -91,12 +127,20 @@ func (c *Camera) ProcessMouseMove(xpos, ypos float64) {
 		c.pitch = -89.0
 	}
 
-	x := float32(math.Cos(mgl64.DegToRad(c.yaw)) * math.Cos(mgl64.DegToRad(c.pitch)))
-	y := float32(math.Sin(mgl64.DegToRad(c.pitch)))
-	z := float32(math.Sin(mgl64.DegToRad(c.yaw)) * math.Cos(mgl64.DegToRad(c.pitch)))
-	c.front = mgl32.Vec3{x, y, z}.Normalize()
+	c.front = frontFor(c.yaw, c.pitch)
+}
 
-	return
+// frontFor returns the direction a camera with the yaw and pitch in degrees looks in
+func frontFor(yaw, pitch float64) mgl32.Vec3 {
+	x := float32(math.Cos(mgl64.DegToRad(yaw)) * math.Cos(mgl64.DegToRad(pitch)))
+	y := float32(math.Sin(mgl64.DegToRad(pitch)))
+	z := float32(math.Sin(mgl64.DegToRad(yaw)) * math.Cos(mgl64.DegToRad(pitch)))
+	return mgl32.Vec3{x, y, z}.Normalize()
+}
+
+// ProcessScroll speeds up or slows down the camera based on the scroll wheel
+func (c *Camera) ProcessScroll(yoffset float64) {
+	c.cameraSpeed *= float32(math.Pow(1.1, yoffset))
 }
 
 // ProcessKeyPress processes a key press for the camera
@@ -116,8 +160,20 @@ func (c *Camera) ProcessKeyPress(key glfw.Key, action glfw.Action, mods glfw.Mod
 	}
 }
 
-// Update updates the camera based on the key press state
+// ProcessGamepad processes the current state of a gamepad for the camera
+func (c *Camera) ProcessGamepad(state GamepadState) {
+	for _, axis := range c.axes {
+		axis.Value = state.Axes[axis.GamepadAxis]
+		if axis.Invert {
+			axis.Value = -axis.Value
+		}
+	}
+}
+
+// Update updates the camera based on the key press and gamepad state
 func (c *Camera) Update(deltaTime float32) {
+	c.previousPosition = c.position
+	c.previousYaw, c.previousPitch = c.yaw, c.pitch
 
 	if c.keys["w"].Pressed {
 		c.position = c.position.Add(c.front.Mul(c.cameraSpeed * deltaTime))
@@ -132,6 +188,22 @@ func (c *Camera) Update(deltaTime float32) {
 		c.position = c.position.Add(c.front.Cross(c.up).Mul(c.cameraSpeed * deltaTime))
 	}
 
-	c.view.UpdateCameraLocation(c.position, c.position.Add(c.front), c.up)
+	// the sticks move and turn the camera proportionally to how far they are pushed
+	c.position = c.position.Add(c.front.Mul(c.cameraSpeed * deltaTime * c.axes["forward"].Value))
+	c.position = c.position.Add(c.front.Cross(c.up).Mul(c.cameraSpeed * deltaTime * c.axes["strafe"].Value))
+	if c.axes["yaw"].Value != 0 || c.axes["pitch"].Value != 0 {
+		c.yaw += c.lookSpeed * float64(deltaTime*c.axes["yaw"].Value)
+		c.pitch += c.lookSpeed * float64(deltaTime*c.axes["pitch"].Value)
+		c.updateFront()
+	}
+}
+
+// UpdateView points the view matrix at where the camera is and sends it to the shader.
+// alpha blends between where the camera was and where it looked before and after the last update
+func (c *Camera) UpdateView(alpha float32) {
+	position := c.previousPosition.Add(c.position.Sub(c.previousPosition).Mul(alpha))
+	yaw := c.previousYaw + (c.yaw-c.previousYaw)*float64(alpha)
+	pitch := c.previousPitch + (c.pitch-c.previousPitch)*float64(alpha)
+	c.view.UpdateCameraLocation(position, position.Add(frontFor(yaw, pitch)), c.up)
 	c.view.UpdateUniform()
 }
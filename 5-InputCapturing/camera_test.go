@@ -0,0 +1,61 @@
+package inputcapturing
+
+import (
+	"testing"
+
+	"github.com/Grindlemire/gl/engine"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// useFakeGL swaps a FakeGL in as the engine's backend for the test
+func useFakeGL(t *testing.T) {
+	t.Helper()
+	backend := engine.Backend
+	t.Cleanup(func() { engine.Backend = backend })
+	engine.Backend = engine.NewFakeGL()
+}
+
+func TestNewCameraYawPitchFollowFront(t *testing.T) {
+	useFakeGL(t)
+
+	c := NewCamera(1, "view", mgl32.Vec3{0, 0, 5}, mgl32.Vec3{0, 0, -1}, mgl32.Vec3{0, 1, 0})
+	if !nearly(c.yaw, -90) || !nearly(c.pitch, 0) {
+		t.Errorf("looking down -z gave yaw %v and pitch %v, want -90 and 0", c.yaw, c.pitch)
+	}
+	c = NewCamera(1, "view", mgl32.Vec3{}, mgl32.Vec3{1, 1, 0}, mgl32.Vec3{0, 1, 0})
+	if !nearly(c.yaw, 0) || !nearly(c.pitch, 45) {
+		t.Errorf("looking up along +x gave yaw %v and pitch %v, want 0 and 45", c.yaw, c.pitch)
+	}
+}
+
+func TestUpdateViewInterpolatesLooking(t *testing.T) {
+	useFakeGL(t)
+
+	position, up := mgl32.Vec3{0, 0, 5}, mgl32.Vec3{0, 1, 0}
+	c := NewCamera(1, "view", position, mgl32.Vec3{0, 0, -1}, up)
+
+	// turn right 60 degrees and up 30 in one update
+	c.axes["yaw"].Value = 1
+	c.axes["pitch"].Value = 0.5
+	c.Update(0.5)
+	if !nearly(c.yaw, -30) || !nearly(c.pitch, 30) {
+		t.Fatalf("yaw and pitch are %v and %v after the update, want -30 and 30", c.yaw, c.pitch)
+	}
+
+	// halfway between updates the camera looks halfway round
+	for _, step := range []struct {
+		alpha      float32
+		yaw, pitch float64
+	}{{0, -90, 0}, {0.5, -60, 15}, {1, -30, 30}} {
+		c.UpdateView(step.alpha)
+		want := mgl32.LookAtV(position, position.Add(frontFor(step.yaw, step.pitch)), up)
+		if !c.view.matrix.ApproxEqualThreshold(want, 1e-5) {
+			t.Errorf("alpha %v: view is %v, want it looking at yaw %v and pitch %v", step.alpha, c.view.matrix, step.yaw, step.pitch)
+		}
+	}
+}
+
+// nearly reports whether two angles are the same within float error
+func nearly(a, b float64) bool {
+	return a-b < 1e-4 && b-a < 1e-4
+}
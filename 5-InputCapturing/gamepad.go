@@ -0,0 +1,248 @@
+package inputcapturing
+
+import (
+	"log"
+	"math"
+	"runtime"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+// GamepadAxis is an axis in the standard gamepad layout (an xbox style controller)
+type GamepadAxis int
+
+// The axes of the standard gamepad layout. Sticks go from -1 to 1 and triggers go from 0 to 1
+const (
+	AxisLeftX GamepadAxis = iota
+	AxisLeftY
+	AxisRightX
+	AxisRightY
+	AxisLeftTrigger
+	AxisRightTrigger
+	axisCount
+)
+
+// GamepadButton is a button in the standard gamepad layout
+type GamepadButton int
+
+// The buttons of the standard gamepad layout
+const (
+	ButtonA GamepadButton = iota
+	ButtonB
+	ButtonX
+	ButtonY
+	ButtonLeftBumper
+	ButtonRightBumper
+	ButtonBack
+	ButtonStart
+	ButtonGuide
+	ButtonLeftThumb
+	ButtonRightThumb
+	ButtonDpadUp
+	ButtonDpadRight
+	ButtonDpadDown
+	ButtonDpadLeft
+	buttonCount
+)
+
+// GamepadMapping maps the standard gamepad layout onto the raw joystick axes and buttons glfw reports.
+// glfw 3.2 has no gamepad database so the raw indices depend on the platform driver. An index of -1
+// means the joystick doesn't have that input
+type GamepadMapping struct {
+	Axes    [axisCount]int
+	Buttons [buttonCount]int
+}
+
+// StandardMapping is the layout an xinput controller reports on windows and mac
+var StandardMapping = GamepadMapping{
+	Axes:    [axisCount]int{0, 1, 2, 3, 4, 5},
+	Buttons: [buttonCount]int{0, 1, 2, 3, 4, 5, 6, 7, -1, 8, 9, 10, 11, 12, 13},
+}
+
+// LinuxMapping is the layout the xpad driver reports for an xbox controller on linux.
+// It puts the triggers in between the sticks, has a guide button, and reports the dpad as a hat
+// (extra axes) instead of buttons
+var LinuxMapping = GamepadMapping{
+	Axes:    [axisCount]int{0, 1, 3, 4, 2, 5},
+	Buttons: [buttonCount]int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, -1, -1, -1, -1},
+}
+
+// GamepadState is a snapshot of a gamepad's inputs after mapping and dead zone filtering
+type GamepadState struct {
+	Axes    [axisCount]float32
+	Buttons [buttonCount]bool
+}
+
+// Gamepad is a joystick that we read through a GamepadMapping
+type Gamepad struct {
+	Joystick glfw.Joystick
+	Name     string
+	Mapping  GamepadMapping
+
+	// DeadZone is how far a stick has to be pushed before it registers at all
+	DeadZone float32
+	// TriggerDeadZone is how far a trigger has to be pulled before it registers at all
+	TriggerDeadZone float32
+
+	State GamepadState
+}
+
+// NewGamepad creates a gamepad for the joystick with the platform's default mapping
+func NewGamepad(joy glfw.Joystick) (g *Gamepad) {
+	mapping := StandardMapping
+	if runtime.GOOS == "linux" {
+		mapping = LinuxMapping
+	}
+
+	g = &Gamepad{
+		Joystick:        joy,
+		Name:            glfw.GetJoystickName(joy),
+		Mapping:         mapping,
+		DeadZone:        0.2,
+		TriggerDeadZone: 0.1,
+	}
+	return g
+}
+
+// Poll reads the joystick and updates the state of the gamepad
+func (g *Gamepad) Poll() {
+	g.read(glfw.GetJoystickAxes(g.Joystick), glfw.GetJoystickButtons(g.Joystick))
+}
+
+// read updates the state of the gamepad from the raw axes and buttons of the joystick
+func (g *Gamepad) read(axes []float32, buttons []byte) {
+	var raw [axisCount]float32
+	for axis, index := range g.Mapping.Axes {
+		if index >= 0 && index < len(axes) {
+			raw[axis] = axes[index]
+		}
+	}
+
+	// glfw reports triggers from -1 (released) to 1 (pulled) so rescale them to 0 to 1
+	// before applying the dead zone
+	for _, axis := range []GamepadAxis{AxisLeftTrigger, AxisRightTrigger} {
+		raw[axis] = applyDeadZone((raw[axis]+1)/2, g.TriggerDeadZone)
+	}
+
+	g.State.Axes[AxisLeftX], g.State.Axes[AxisLeftY] = applyRadialDeadZone(raw[AxisLeftX], raw[AxisLeftY], g.DeadZone)
+	g.State.Axes[AxisRightX], g.State.Axes[AxisRightY] = applyRadialDeadZone(raw[AxisRightX], raw[AxisRightY], g.DeadZone)
+	g.State.Axes[AxisLeftTrigger] = raw[AxisLeftTrigger]
+	g.State.Axes[AxisRightTrigger] = raw[AxisRightTrigger]
+
+	for button, index := range g.Mapping.Buttons {
+		g.State.Buttons[button] = index >= 0 && index < len(buttons) && glfw.Action(buttons[index]) == glfw.Press
+	}
+}
+
+// applyRadialDeadZone zeroes a stick inside the dead zone and rescales the rest of its range
+// so it still starts at 0 and ends at 1. Doing it on the stick as a whole instead of each axis
+// keeps diagonals from snapping to the axes
+func applyRadialDeadZone(x, y, deadZone float32) (float32, float32) {
+	magnitude := float32(math.Hypot(float64(x), float64(y)))
+	if magnitude <= deadZone {
+		return 0, 0
+	}
+
+	scaled := applyDeadZone(magnitude, deadZone)
+	return x / magnitude * scaled, y / magnitude * scaled
+}
+
+// applyDeadZone zeroes a 0 to 1 value inside the dead zone and rescales the rest of its range
+func applyDeadZone(value, deadZone float32) float32 {
+	if value <= deadZone {
+		return 0
+	}
+	if value > 1 {
+		return 1
+	}
+	return (value - deadZone) / (1 - deadZone)
+}
+
+// Gamepads tracks the connected gamepads and which one of them is being followed
+type Gamepads struct {
+	pads map[glfw.Joystick]*Gamepad
+	// active is the joystick being followed, it is only valid while it is in pads
+	active glfw.Joystick
+
+	// OnConnect is called when a gamepad is plugged in
+	OnConnect func(g *Gamepad)
+	// OnDisconnect is called when a gamepad is unplugged. active is set when it was the one being
+	// followed, the others can come and go without changing anything
+	OnDisconnect func(g *Gamepad, active bool)
+}
+
+// NewGamepads picks up any joysticks that are already plugged in and listens for new ones
+func NewGamepads() (g *Gamepads) {
+	g = &Gamepads{
+		pads: map[glfw.Joystick]*Gamepad{},
+	}
+
+	for joy := glfw.Joystick1; joy <= glfw.JoystickLast; joy++ {
+		if glfw.JoystickPresent(joy) {
+			g.connect(joy)
+		}
+	}
+
+	glfw.SetJoystickCallback(g.HandleJoystick)
+	return g
+}
+
+// HandleJoystick is the callback that is called anytime a joystick is connected or disconnected
+func (g *Gamepads) HandleJoystick(joy, event int) {
+	switch glfw.MonitorEvent(event) {
+	case glfw.Connected:
+		g.connect(glfw.Joystick(joy))
+	case glfw.Disconnected:
+		g.disconnect(glfw.Joystick(joy))
+	}
+}
+
+func (g *Gamepads) connect(joy glfw.Joystick) {
+	pad := NewGamepad(joy)
+	if g.Active() == nil {
+		g.active = joy
+	}
+	g.pads[joy] = pad
+	log.Printf("Gamepad %d connected: %s", joy, pad.Name)
+
+	if g.OnConnect != nil {
+		g.OnConnect(pad)
+	}
+}
+
+func (g *Gamepads) disconnect(joy glfw.Joystick) {
+	pad, ok := g.pads[joy]
+	if !ok {
+		return
+	}
+	delete(g.pads, joy)
+	log.Printf("Gamepad %d disconnected: %s", joy, pad.Name)
+
+	// follow the lowest numbered gamepad that is left when the active one goes
+	active := joy == g.active
+	if active {
+		for next := glfw.Joystick1; next <= glfw.JoystickLast; next++ {
+			if _, ok := g.pads[next]; ok {
+				g.active = next
+				break
+			}
+		}
+	}
+
+	if g.OnDisconnect != nil {
+		g.OnDisconnect(pad, active)
+	}
+}
+
+// Poll reads every connected gamepad
+func (g *Gamepads) Poll() {
+	for _, pad := range g.pads {
+		pad.Poll()
+	}
+}
+
+// Active returns the gamepad being followed or nil if there isn't one. It is the first one plugged
+// in until it is unplugged
+func (g *Gamepads) Active() *Gamepad {
+	return g.pads[g.active]
+}
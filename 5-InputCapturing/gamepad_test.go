@@ -0,0 +1,141 @@
+package inputcapturing
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+func TestRadialDeadZoneZeroesInside(t *testing.T) {
+	for _, stick := range [][2]float32{{0, 0}, {0.1, 0}, {0, -0.19}, {0.14, 0.14}, {-0.1, 0.1}} {
+		x, y := applyRadialDeadZone(stick[0], stick[1], 0.2)
+		if x != 0 || y != 0 {
+			t.Errorf("stick %v inside the dead zone gave (%v, %v), want (0, 0)", stick, x, y)
+		}
+	}
+}
+
+func TestRadialDeadZoneContinuousAtEdge(t *testing.T) {
+	const deadZone = 0.2
+	for _, angle := range []float64{0, math.Pi / 4, 2, math.Pi, 5} {
+		dx, dy := float32(math.Cos(angle)), float32(math.Sin(angle))
+
+		// just past the edge the stick should barely register
+		x, y := applyRadialDeadZone(dx*(deadZone+1e-4), dy*(deadZone+1e-4), deadZone)
+		if magnitude := math.Hypot(float64(x), float64(y)); magnitude > 1e-3 {
+			t.Errorf("angle %v just past the dead zone has magnitude %v, want about 0", angle, magnitude)
+		}
+
+		// pushed all the way it should reach 1 in the same direction
+		x, y = applyRadialDeadZone(dx, dy, deadZone)
+		if magnitude := math.Hypot(float64(x), float64(y)); math.Abs(magnitude-1) > 1e-5 {
+			t.Errorf("angle %v pushed all the way has magnitude %v, want 1", angle, magnitude)
+		}
+		if math.Abs(float64(x*dy-y*dx)) > 1e-5 {
+			t.Errorf("angle %v changed direction to (%v, %v)", angle, x, y)
+		}
+	}
+}
+
+func TestRadialDeadZoneRescalesLinearly(t *testing.T) {
+	x, y := applyRadialDeadZone(0.6, 0, 0.2)
+	if math.Abs(float64(x-0.5)) > 1e-6 || y != 0 {
+		t.Errorf("got (%v, %v), want (0.5, 0)", x, y)
+	}
+}
+
+func TestDeadZone(t *testing.T) {
+	tests := []struct {
+		value, want float32
+	}{
+		{0, 0},
+		{0.1, 0},
+		{0.55, 0.5},
+		{1, 1},
+		{1.5, 1},
+	}
+	for _, test := range tests {
+		if got := applyDeadZone(test.value, 0.1); math.Abs(float64(got-test.want)) > 1e-6 {
+			t.Errorf("applyDeadZone(%v, 0.1) = %v, want %v", test.value, got, test.want)
+		}
+	}
+}
+
+func TestReadRemapsTriggers(t *testing.T) {
+	g := &Gamepad{Mapping: StandardMapping, DeadZone: 0.2, TriggerDeadZone: 0.1}
+
+	// glfw reports released triggers as -1 and pulled ones as 1
+	g.read([]float32{0, 0, 0, 0, -1, 1}, nil)
+	if got := g.State.Axes[AxisLeftTrigger]; got != 0 {
+		t.Errorf("released trigger is %v, want 0", got)
+	}
+	if got := g.State.Axes[AxisRightTrigger]; got != 1 {
+		t.Errorf("pulled trigger is %v, want 1", got)
+	}
+
+	// halfway is 0.5 before the dead zone is taken out
+	g.read([]float32{0, 0, 0, 0, 0, 0}, nil)
+	want := (0.5 - g.TriggerDeadZone) / (1 - g.TriggerDeadZone)
+	if got := g.State.Axes[AxisLeftTrigger]; math.Abs(float64(got-want)) > 1e-6 {
+		t.Errorf("half pulled trigger is %v, want %v", got, want)
+	}
+}
+
+func TestReadFollowsMapping(t *testing.T) {
+	g := &Gamepad{Mapping: LinuxMapping, DeadZone: 0.2, TriggerDeadZone: 0.1}
+
+	// xpad puts the left trigger at 2 and the right stick at 3 and 4
+	g.read([]float32{0, 0, 1, 0.9, 0, 1}, nil)
+	if got := g.State.Axes[AxisLeftTrigger]; got != 1 {
+		t.Errorf("left trigger is %v, want 1", got)
+	}
+	if got := g.State.Axes[AxisRightX]; got <= 0 {
+		t.Errorf("right stick x is %v, want it pushed", got)
+	}
+	if got := g.State.Axes[AxisRightY]; got != 0 {
+		t.Errorf("right stick y is %v, want 0", got)
+	}
+
+	// a joystick without the buttons has none of them pressed
+	for button, pressed := range g.State.Buttons {
+		if pressed {
+			t.Errorf("button %d is pressed", button)
+		}
+	}
+}
+
+func TestDisconnectOnlyActiveResets(t *testing.T) {
+	first := &Gamepad{Joystick: glfw.Joystick1}
+	second := &Gamepad{Joystick: glfw.Joystick3}
+	g := &Gamepads{
+		pads:   map[glfw.Joystick]*Gamepad{glfw.Joystick1: first, glfw.Joystick3: second},
+		active: glfw.Joystick1,
+	}
+
+	var disconnected []bool
+	g.OnDisconnect = func(pad *Gamepad, active bool) {
+		disconnected = append(disconnected, active)
+	}
+
+	g.disconnect(glfw.Joystick3)
+	if g.Active() != first {
+		t.Fatalf("active gamepad changed when another one was unplugged")
+	}
+	g.pads[glfw.Joystick3] = second
+	g.disconnect(glfw.Joystick1)
+	if g.Active() != second {
+		t.Fatalf("active gamepad didn't move to the one that is left")
+	}
+	g.disconnect(glfw.Joystick3)
+	if g.Active() != nil {
+		t.Fatalf("there is still an active gamepad with none plugged in")
+	}
+
+	want := []bool{false, true, true}
+	for i := range want {
+		if i >= len(disconnected) || disconnected[i] != want[i] {
+			t.Fatalf("disconnects reported active %v, want %v", disconnected, want)
+		}
+	}
+}
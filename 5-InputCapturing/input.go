@@ -1,6 +1,8 @@
-package main
+package inputcapturing
 
 import (
+	"log"
+
 	"github.com/go-gl/glfw/v3.2/glfw"
 )
 
@@ -10,6 +12,13 @@ type Key struct {
 	Pressed bool
 }
 
+// Axis manages a gamepad axis bound to some camera control and how far it is currently pushed
+type Axis struct {
+	GamepadAxis
+	Invert bool
+	Value  float32
+}
+
 // Keys is the global state of the captured keys we are listening for
 var Keys = map[string]*Key{
 	"w": &Key{
@@ -44,6 +53,7 @@ var (
 
 // HandleKeyPress is the callback that is called anytime the program detects a key action
 func HandleKeyPress(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+	record(InputEvent{Type: KeyEvent, Key: key, Scancode: scancode, Action: action, Mods: mods})
 	if key == glfw.KeyEscape {
 		w.SetShouldClose(true)
 	}
@@ -52,5 +62,23 @@ func HandleKeyPress(w *glfw.Window, key glfw.Key, scancode int, action glfw.Acti
 
 // HandleCursorMove handles the global state of the cursor
 func HandleCursorMove(w *glfw.Window, xpos float64, ypos float64) {
+	record(InputEvent{Type: CursorEvent, X: xpos, Y: ypos})
 	camera.ProcessMouseMove(xpos, ypos)
 }
+
+// HandleScroll is the callback that is called anytime the mouse wheel or trackpad scrolls
+func HandleScroll(w *glfw.Window, xoff float64, yoff float64) {
+	record(InputEvent{Type: ScrollEvent, X: xoff, Y: yoff})
+	camera.ProcessScroll(yoff)
+}
+
+// record logs the event if we are recording the session. Events coming from a replay aren't
+// recorded again, only the input after it hands control back
+func record(e InputEvent) {
+	if recorder == nil || player != nil {
+		return
+	}
+	if err := recorder.Record(e); err != nil {
+		log.Printf("Error recording input: %v", err)
+	}
+}
@@ -0,0 +1,178 @@
+package inputcapturing
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/pkg/errors"
+)
+
+// EventType is the kind of input an InputEvent holds
+type EventType string
+
+// The kinds of input we record
+const (
+	KeyEvent     EventType = "key"
+	CursorEvent  EventType = "cursor"
+	ScrollEvent  EventType = "scroll"
+	GamepadEvent EventType = "gamepad"
+	EndEvent     EventType = "end"
+)
+
+// InputEvent is a single recorded input. Step is the fixed time step the event was handled before
+// and Time is the simulation time of that step. Only the fields for the event's type are set
+type InputEvent struct {
+	Step int       `json:"step"`
+	Time float64   `json:"time"`
+	Type EventType `json:"type"`
+
+	Key      glfw.Key         `json:"key,omitempty"`
+	Scancode int              `json:"scancode,omitempty"`
+	Action   glfw.Action      `json:"action,omitempty"`
+	Mods     glfw.ModifierKey `json:"mods,omitempty"`
+
+	X float64 `json:"x,omitempty"`
+	Y float64 `json:"y,omitempty"`
+
+	Gamepad *GamepadState `json:"gamepad,omitempty"`
+}
+
+// Recorder logs input events to a file, one json event per line, so a session can be replayed later
+type Recorder struct {
+	file     *os.File
+	writer   *bufio.Writer
+	encoder  *json.Encoder
+	timeStep float64
+	step     int
+	gamepad  GamepadState
+}
+
+// NewRecorder creates a recorder that writes to the file. timeStep is the fixed time step the
+// simulation advances by each time Step is called
+func NewRecorder(file string, timeStep float64) (r *Recorder, err error) {
+	f, err := os.Create(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create recording file")
+	}
+
+	w := bufio.NewWriter(f)
+	r = &Recorder{
+		file:     f,
+		writer:   w,
+		encoder:  json.NewEncoder(w),
+		timeStep: timeStep,
+	}
+	return r, nil
+}
+
+// Record stamps the event with the current step and writes it out
+func (r *Recorder) Record(e InputEvent) error {
+	e.Step = r.step
+	e.Time = float64(r.step) * r.timeStep
+	return errors.Wrap(r.encoder.Encode(e), "unable to write input event")
+}
+
+// RecordGamepad records the state of the gamepad if it changed since the last time it was recorded
+func (r *Recorder) RecordGamepad(state GamepadState) error {
+	if state == r.gamepad {
+		return nil
+	}
+	r.gamepad = state
+	return r.Record(InputEvent{Type: GamepadEvent, Gamepad: &state})
+}
+
+// Step advances the recorder to the next time step
+func (r *Recorder) Step() {
+	r.step++
+}
+
+// Close marks the end of the session, flushes the recording and closes the file
+func (r *Recorder) Close() error {
+	if err := r.Record(InputEvent{Type: EndEvent}); err != nil {
+		r.file.Close()
+		return err
+	}
+	if err := r.writer.Flush(); err != nil {
+		r.file.Close()
+		return errors.Wrap(err, "unable to flush recording")
+	}
+	return errors.Wrap(r.file.Close(), "unable to close recording file")
+}
+
+// Player replays a recording by feeding its events back through the input callbacks one fixed
+// time step at a time. It doesn't look at the clock so a replay does exactly the same thing
+// every time no matter how fast it runs
+type Player struct {
+	events []InputEvent
+	next   int
+	step   int
+	end    int
+
+	// dispatch handles each event as it is played back
+	dispatch func(w *glfw.Window, e InputEvent)
+}
+
+// NewPlayer loads a recording from a file
+func NewPlayer(file string) (p *Player, err error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to open recording file")
+	}
+	defer f.Close()
+
+	p = &Player{dispatch: dispatch}
+	decoder := json.NewDecoder(f)
+	for {
+		var e InputEvent
+		err = decoder.Decode(&e)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to decode input event %d", len(p.events))
+		}
+		p.events = append(p.events, e)
+	}
+
+	// the recording ends at the step it was closed on. If it wasn't closed cleanly then stop
+	// right after the last event
+	if len(p.events) > 0 {
+		last := p.events[len(p.events)-1]
+		p.end = last.Step
+		if last.Type != EndEvent {
+			p.end++
+		}
+	}
+
+	return p, nil
+}
+
+// Step dispatches every event recorded for the current time step and advances to the next one
+func (p *Player) Step(w *glfw.Window) {
+	for ; p.next < len(p.events) && p.events[p.next].Step <= p.step; p.next++ {
+		p.dispatch(w, p.events[p.next])
+	}
+	p.step++
+}
+
+// dispatch sends a recorded event through the input callback it came from
+func dispatch(w *glfw.Window, e InputEvent) {
+	switch e.Type {
+	case KeyEvent:
+		HandleKeyPress(w, e.Key, e.Scancode, e.Action, e.Mods)
+	case CursorEvent:
+		HandleCursorMove(w, e.X, e.Y)
+	case ScrollEvent:
+		HandleScroll(w, e.X, e.Y)
+	case GamepadEvent:
+		camera.ProcessGamepad(*e.Gamepad)
+	}
+}
+
+// Done returns whether the whole recording has been played
+func (p *Player) Done() bool {
+	return p.step >= p.end
+}
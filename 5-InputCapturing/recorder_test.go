@@ -0,0 +1,148 @@
+package inputcapturing
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+// played is an event and the step the player dispatched it on
+type played struct {
+	step  int
+	event InputEvent
+}
+
+// replay plays the whole recording and returns what was dispatched on each step
+func replay(t *testing.T, file string) (events []played, steps int) {
+	t.Helper()
+	p, err := NewPlayer(file)
+	if err != nil {
+		t.Fatalf("unable to load the recording: %v", err)
+	}
+	p.dispatch = func(w *glfw.Window, e InputEvent) {
+		events = append(events, played{p.step, e})
+	}
+	for ; !p.Done(); steps++ {
+		if steps > 100 {
+			t.Fatalf("the player never finished")
+		}
+		p.Step(nil)
+	}
+	return events, steps
+}
+
+func TestRecorderRoundTrip(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "session.json")
+	r, err := NewRecorder(file, 0.01)
+	if err != nil {
+		t.Fatalf("unable to create the recorder: %v", err)
+	}
+
+	gamepad := GamepadState{}
+	gamepad.Axes[AxisLeftX] = 0.5
+	gamepad.Buttons[ButtonA] = true
+
+	key := InputEvent{Type: KeyEvent, Key: glfw.KeyW, Scancode: 17, Action: glfw.Press, Mods: glfw.ModShift}
+	cursor := InputEvent{Type: CursorEvent, X: 120.5, Y: 64}
+	scroll := InputEvent{Type: ScrollEvent, X: 0, Y: -1}
+
+	// step 0 has two events, step 1 none and step 2 one, then the gamepad on step 3. The end
+	// marker on step 4 stops the player rather than being dispatched
+	record := []func() error{
+		func() error { return r.Record(key) },
+		func() error { return r.Record(cursor) },
+		func() error { r.Step(); r.Step(); return r.Record(scroll) },
+		func() error { r.Step(); return r.RecordGamepad(gamepad) },
+		// the same state again isn't recorded
+		func() error { return r.RecordGamepad(gamepad) },
+		func() error { r.Step(); return r.Close() },
+	}
+	for i, f := range record {
+		if err := f(); err != nil {
+			t.Fatalf("recording %d failed: %v", i, err)
+		}
+	}
+
+	events, steps := replay(t, file)
+	want := []played{
+		{0, key},
+		{0, cursor},
+		{2, scroll},
+		{3, InputEvent{Type: GamepadEvent, Gamepad: &gamepad}},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("replayed %d events, want %d: %+v", len(events), len(want), events)
+	}
+	for i, w := range want {
+		got := events[i]
+		if got.step != w.step {
+			t.Errorf("event %d (%s) dispatched on step %d, want %d", i, got.event.Type, got.step, w.step)
+		}
+		if got.event.Step != w.step || got.event.Time != float64(w.step)*0.01 {
+			t.Errorf("event %d stamped step %d time %v, want step %d", i, got.event.Step, got.event.Time, w.step)
+		}
+		got.event.Step, got.event.Time = 0, 0
+		if !reflect.DeepEqual(got.event, w.event) {
+			t.Errorf("event %d is %+v, want %+v", i, got.event, w.event)
+		}
+	}
+	if steps != 4 {
+		t.Errorf("player finished after %d steps, want 4", steps)
+	}
+}
+
+func TestPlayerWithoutEnd(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "crashed.json")
+	r, err := NewRecorder(file, 0.01)
+	if err != nil {
+		t.Fatalf("unable to create the recorder: %v", err)
+	}
+	r.Step()
+	r.Step()
+	if err := r.Record(InputEvent{Type: CursorEvent, X: 1, Y: 2}); err != nil {
+		t.Fatalf("unable to record: %v", err)
+	}
+	// flush without writing the end event like a session that crashed
+	if err := r.writer.Flush(); err != nil {
+		t.Fatalf("unable to flush: %v", err)
+	}
+	r.file.Close()
+
+	events, steps := replay(t, file)
+	if len(events) != 1 || events[0].step != 2 {
+		t.Fatalf("replayed %+v, want the cursor on step 2", events)
+	}
+	if steps != 3 {
+		t.Errorf("player finished after %d steps, want 3", steps)
+	}
+}
+
+func TestReplayIsNotRecorded(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "session.json")
+	r, err := NewRecorder(file, 0.01)
+	if err != nil {
+		t.Fatalf("unable to create the recorder: %v", err)
+	}
+
+	saved := recorder
+	defer func() { recorder, player = saved, nil }()
+	recorder = r
+
+	player = &Player{}
+	record(InputEvent{Type: CursorEvent, X: 1, Y: 1})
+	player = nil
+	record(InputEvent{Type: CursorEvent, X: 2, Y: 2})
+	if err := r.Close(); err != nil {
+		t.Fatalf("unable to close the recorder: %v", err)
+	}
+
+	p, err := NewPlayer(file)
+	if err != nil {
+		t.Fatalf("unable to load the recording: %v", err)
+	}
+	if len(p.events) != 2 || p.events[0].X != 2 || p.events[1].Type != EndEvent {
+		t.Errorf("recorded %+v, want only the cursor move after the replay", p.events)
+	}
+}
@@ -0,0 +1,226 @@
+package inputcapturing
+
+import (
+	"flag"
+	"log"
+
+	"github.com/Grindlemire/gl/assets"
+	"github.com/Grindlemire/gl/engine"
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/pkg/errors"
+)
+
+// width and height of the window we are creating
+const (
+	winWidth  = 960
+	winHeight = 540
+)
+
+// timeStep is how far the camera moves forward in time each update. Updating in fixed steps
+// is what lets a recorded session replay exactly
+const timeStep = 1.0 / 120.0
+
+var (
+	camera   *Camera
+	gamepads *Gamepads
+	recorder *Recorder
+	player   *Player
+)
+
+// flags for recording and replaying a session. Running headless replays as fast as possible
+// and exits when the replay finishes
+var (
+	flags      = flag.NewFlagSet("inputCapturing", flag.ExitOnError)
+	recordFile = flags.String("record", "", "record the input events of this session to a file")
+	replayFile = flags.String("replay", "", "replay the input events from a recorded session instead of listening for input")
+)
+
+func init() {
+	engine.Register(engine.Scene{
+		Name:   "inputCapturing",
+		Title:  "Input Capturing",
+		Width:  winWidth,
+		Height: winHeight,
+		Flags:  flags,
+		Setup:  setup,
+	})
+}
+
+// setup sets the hooks that run the input capturing
+func setup(a *engine.App) {
+	var (
+		program    uint32
+		projection *Projection
+		vao        VertexArrayObject
+		vbo        VertexBufferObject
+		loader     *engine.Loader
+		texture    *engine.Texture
+	)
+
+	a.TimeStep = timeStep
+
+	a.Init = func(*engine.App) (err error) {
+		if *replayFile != "" {
+			player, err = NewPlayer(*replayFile)
+			if err != nil {
+				return errors.Wrap(err, "unable to load replay")
+			}
+
+			// a headless replay doesn't wait on the clock, it just runs one step a frame
+			if a.Config.Hidden {
+				a.Lockstep = true
+			}
+		}
+
+		if *recordFile != "" {
+			recorder, err = NewRecorder(*recordFile, timeStep)
+			if err != nil {
+				return errors.Wrap(err, "unable to start recording")
+			}
+		}
+
+		program, err = engine.NewProgram(vertexShaderSrc, fragShaderSrc)
+		if err != nil {
+			return err
+		}
+
+		// the replay is the only input so only listen to the window if we aren't replaying
+		if player == nil {
+			listen(a.Window)
+		}
+
+		// create our transformations
+		_ = NewModel(program, "model")
+		camera = NewCamera(program, "view", mgl32.Vec3{0, 0, 5}, mgl32.Vec3{0, 0, -1}, mgl32.Vec3{0, 1, 0})
+		projection = NewProjection(program, "projection")
+
+		// listen for gamepads
+		gamepads = NewGamepads()
+		gamepads.OnDisconnect = func(pad *Gamepad, active bool) {
+			// stop moving right away instead of drifting until the next update polls them again
+			if active && player == nil {
+				camera.ProcessGamepad(GamepadState{})
+			}
+		}
+
+		// load our data into our buffers
+		vao = NewVAO()
+		vbo = NewVBO(cubeVertices)
+
+		// name everything so debug messages and tools like RenderDoc can tell us what they mean
+		engine.Label(gl.PROGRAM, program, "cube")
+		engine.Label(gl.VERTEX_ARRAY, vao.addr, "cube")
+		engine.Label(gl.BUFFER, vbo.addr, "cube vertices")
+
+		// load our texture in the background so we don't hold up the first frames decoding it.
+		// The cube draws black until it is ready
+		gl.Uniform1i(gl.GetUniformLocation(program, gl.Str("texSampler\x00")), 0)
+		loader = engine.NewLoader(1)
+		loader.LoadTexture(assets.FS, "wall.jpg", func(t *engine.Texture, err error) {
+			if err != nil {
+				log.Printf("Error loading texture: %v", err)
+				return
+			}
+			texture = t
+		})
+
+		// map our data into the shader
+		vao.MapAttribute(program, "vert", 0, 3, 5)
+		vao.MapAttribute(program, "vertTexCoord", 3, 2, 5)
+
+		// enable depth of field and general constants
+		engine.State.Enable(gl.DEPTH_TEST)
+		engine.State.DepthFunc(gl.LESS)
+		gl.ClearColor(0.0, 0.0, 0.0, 0.0)
+
+		// draw a wireframe instead of filling
+		engine.State.PolygonMode(gl.FILL)
+		return nil
+	}
+
+	a.Update = func(dt float64) {
+		if player != nil {
+			player.Step(a.Window)
+		} else {
+			pollGamepads()
+		}
+
+		camera.Update(float32(dt))
+		if recorder != nil {
+			recorder.Step()
+		}
+
+		if player != nil && player.Done() {
+			log.Printf("Replay finished with the camera at %v looking at %v", camera.position, camera.front)
+			player = nil
+			if a.Config.Hidden {
+				a.Window.SetShouldClose(true)
+			}
+
+			// hand control back to the user
+			listen(a.Window)
+		}
+	}
+
+	a.Render = func(alpha float64) {
+		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+		if texture != nil {
+			texture.Bind(gl.TEXTURE0)
+		}
+
+		engine.State.UseProgram(program)
+		camera.UpdateView(float32(alpha))
+
+		engine.State.BindVertexArray(vao.addr) // the cache skips this when the vao is already bound
+		engine.DrawArrays(gl.TRIANGLES, 0, 6*6)
+	}
+
+	a.Resize = func(width, height int) {
+		engine.State.UseProgram(program)
+		projection.UpdateAspect(width, height)
+		projection.UpdateUniform()
+	}
+
+	a.Shutdown = func() {
+		loader.Close()
+		if texture != nil {
+			texture.Delete()
+		}
+		vao.Delete()
+		vbo.Delete()
+		engine.DeleteProgram(program)
+
+		if recorder != nil {
+			if err := recorder.Close(); err != nil {
+				log.Printf("Error saving recording: %v", err)
+			}
+		}
+	}
+}
+
+// listen sends the window's input events to our input handlers
+func listen(window *glfw.Window) {
+	window.SetKeyCallback(HandleKeyPress)
+	window.SetCursorPosCallback(HandleCursorMove)
+	window.SetScrollCallback(HandleScroll)
+}
+
+// pollGamepads reads the active gamepad and passes it along to the camera. If the gamepad
+// is unplugged the camera gets an idle state so it stops moving
+func pollGamepads() {
+	gamepads.Poll()
+
+	state := GamepadState{}
+	if pad := gamepads.Active(); pad != nil {
+		state = pad.State
+	}
+
+	if recorder != nil {
+		if err := recorder.RecordGamepad(state); err != nil {
+			log.Printf("Error recording gamepad: %v", err)
+		}
+	}
+	camera.ProcessGamepad(state)
+}
@@ -1,4 +1,4 @@
-package main
+package inputcapturing
 
 var vertexShaderSrc = `
 	#version 410
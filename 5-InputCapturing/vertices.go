@@ -1,4 +1,4 @@
-package main
+package inputcapturing
 
 // var cubeVertices = []float32{
 // 	// X,Y,Z,R,G,B,U,V
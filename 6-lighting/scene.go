@@ -0,0 +1,281 @@
+package lighting
+
+import (
+	"log"
+	"math"
+
+	"github.com/Grindlemire/gl/engine"
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// width and height of the window we are creating
+const (
+	winWidth  = 960
+	winHeight = 540
+)
+
+// shadowSize is how many texels across each shadow map is
+const shadowSize = 2048
+
+// eye is where the camera sits looking at the middle of the scene
+var eye = mgl32.Vec3{8, 6, 10}
+
+// object is a cube placed in the scene with its own material
+type object struct {
+	position mgl32.Vec3
+	scale    mgl32.Vec3
+	material engine.Material
+}
+
+// objects is everything we draw. The floor is a flattened cube
+var objects = []object{
+	{
+		position: mgl32.Vec3{0, -1.1, 0},
+		scale:    mgl32.Vec3{8, 0.1, 8},
+		material: engine.Material{Diffuse: mgl32.Vec3{0.6, 0.6, 0.6}, Specular: mgl32.Vec3{0.2, 0.2, 0.2}, Shininess: 8},
+	},
+	{
+		position: mgl32.Vec3{0, 0, 0},
+		scale:    mgl32.Vec3{1, 1, 1},
+		material: engine.Material{Diffuse: mgl32.Vec3{0.8, 0.3, 0.2}, Specular: mgl32.Vec3{1, 1, 1}, Shininess: 32},
+	},
+	{
+		position: mgl32.Vec3{-3.5, -0.5, 2},
+		scale:    mgl32.Vec3{0.5, 0.5, 0.5},
+		material: engine.Material{Diffuse: mgl32.Vec3{0.2, 0.6, 0.3}, Specular: mgl32.Vec3{0.5, 0.5, 0.5}, Shininess: 16},
+	},
+	{
+		position: mgl32.Vec3{3, 0.5, -2.5},
+		scale:    mgl32.Vec3{0.75, 1.5, 0.75},
+		material: engine.Material{Diffuse: mgl32.Vec3{0.2, 0.3, 0.8}, Specular: mgl32.Vec3{1, 1, 1}, Shininess: 64},
+	},
+}
+
+func init() {
+	engine.Register(engine.Scene{
+		Name:   "lighting",
+		Title:  "Lighting",
+		Width:  winWidth,
+		Height: winHeight,
+		Setup:  setup,
+	})
+}
+
+// setup sets the hooks that run the lighting demo. B switches between Blinn-Phong and Phong
+// highlights, 1-4 turn the lights on and off, M shows the shadow maps and - and = change the
+// shadow bias
+func setup(a *engine.App) {
+	var (
+		program       uint32
+		shadowProgram uint32
+		model         *Model
+		shadowModel   *Transformation
+		shadows       []*engine.ShadowMap
+		shadowView    *engine.ShadowDebugView
+		projection    mgl32.Mat4
+		frame         *engine.FrameUniforms
+		material      *engine.MaterialUniforms
+		vao           VertexArrayObject
+		vbo           VertexBufferObject
+	)
+
+	view := mgl32.LookAtV(eye, mgl32.Vec3{0, 0, 0}, mgl32.Vec3{0, 1, 0})
+
+	lights := []engine.Light{
+		engine.NewDirectionalLight(mgl32.Vec3{-0.3, -1, -0.5}, mgl32.Vec3{0.4, 0.4, 0.35}),
+		engine.NewPointLight(mgl32.Vec3{}, mgl32.Vec3{1, 0.4, 0.3}),
+		engine.NewPointLight(mgl32.Vec3{}, mgl32.Vec3{0.3, 0.5, 1}),
+		engine.NewSpotLight(mgl32.Vec3{0, 6, 0}, mgl32.Vec3{0, -1, 0}, mgl32.Vec3{1, 1, 0.9}, 15, 25),
+	}
+	enabled := []bool{true, true, true, true}
+	blinn := true
+	showShadows := false
+
+	// the point lights orbit the middle cube. We keep the previous time around so rendering can
+	// blend between the last two updates
+	t, previousT := 0.0, 0.0
+
+	a.Init = func(*engine.App) (err error) {
+		program, err = engine.NewProgram(vertexShaderSrc, fragShaderSrc)
+		if err != nil {
+			return err
+		}
+
+		// the camera and lights are shared by every program through uniform blocks
+		frame, err = engine.NewFrameUniforms()
+		if err != nil {
+			return err
+		}
+		err = frame.Bind(program)
+		if err != nil {
+			return err
+		}
+
+		// create our transformations
+		model = NewModel(program, "model", "normalMatrix")
+		material = engine.NewMaterialUniforms(program)
+
+		// the sun and the spot light cast shadows. The shadow program only needs the model matrix
+		shadowProgram, err = engine.NewShadowProgram()
+		if err != nil {
+			return err
+		}
+		shadowModel = &Transformation{
+			addr:   engine.Backend.GetUniformLocation(shadowProgram, "model"),
+			matrix: mgl32.Ident4(),
+		}
+		for _, i := range []int{0, 3} {
+			shadow, err := engine.NewShadowMap(shadowSize)
+			if err != nil {
+				return err
+			}
+			lights[i].Shadow = shadow
+			shadows = append(shadows, shadow)
+		}
+		shadowView, err = engine.NewShadowDebugView()
+		if err != nil {
+			return err
+		}
+
+		// load our data into our buffers
+		vao = NewVAO()
+		vbo = NewVBO(cubeVertices)
+
+		// name everything so debug messages and tools like RenderDoc can tell us what they mean
+		engine.Label(gl.PROGRAM, program, "lit")
+		engine.Label(gl.VERTEX_ARRAY, vao.addr, "cube")
+		engine.Label(gl.BUFFER, vbo.addr, "cube vertices")
+
+		// map our data into the shader
+		vao.MapAttribute(program, "vert", 0, 3, 6)
+		vao.MapAttribute(program, "vertNormal", 3, 3, 6)
+
+		a.Window.SetKeyCallback(func(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+			if action != glfw.Press {
+				return
+			}
+			switch key {
+			case glfw.KeyEscape:
+				w.SetShouldClose(true)
+			case glfw.KeyB:
+				blinn = !blinn
+				log.Printf("Blinn-Phong: %v", blinn)
+			case glfw.Key1, glfw.Key2, glfw.Key3, glfw.Key4:
+				i := int(key - glfw.Key1)
+				enabled[i] = !enabled[i]
+			case glfw.KeyM:
+				showShadows = !showShadows
+			case glfw.KeyMinus, glfw.KeyEqual:
+				for _, s := range shadows {
+					if key == glfw.KeyMinus {
+						s.Bias /= 2
+					} else {
+						s.Bias *= 2
+					}
+				}
+				log.Printf("shadow bias: %v", shadows[0].Bias)
+			}
+		})
+
+		// enable depth of field and general constants
+		engine.State.Enable(gl.DEPTH_TEST)
+		engine.State.DepthFunc(gl.LESS)
+		gl.ClearColor(0.02, 0.02, 0.05, 0.0)
+		return nil
+	}
+
+	a.Update = func(dt float64) {
+		previousT = t
+		t += dt
+	}
+
+	a.Render = func(alpha float64) {
+		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+
+		renderT := previousT + (t-previousT)*alpha
+		lights[1].Position = orbit(renderT, 4, 1.5)
+		lights[2].Position = orbit(renderT+math.Pi, 3, 0.5)
+
+		active := []engine.Light{}
+		for i, light := range lights {
+			if enabled[i] {
+				active = append(active, light)
+			}
+		}
+
+		// render the depth of the scene from every light that casts a shadow first
+		for _, light := range active {
+			if light.Shadow == nil {
+				continue
+			}
+			light.Shadow.Update(light, mgl32.Vec3{0, 0, 0}, 12)
+			light.Shadow.Begin(shadowProgram)
+			drawObjects(vao, shadowModel, nil)
+			light.Shadow.End()
+		}
+
+		// these only fail if the blocks are the wrong type which they never are
+		_ = frame.SetCamera(view, projection, eye)
+		_ = frame.SetLights(active, blinn)
+
+		engine.State.UseProgram(program)
+		drawObjects(vao, model, material)
+
+		if showShadows {
+			for i, s := range shadows {
+				shadowView.Draw(s, int32(10+i*210), 10, 200, 200)
+			}
+		}
+	}
+
+	a.Resize = func(width, height int) {
+		// a minimized window has no height so keep the old aspect ratio
+		if height == 0 {
+			return
+		}
+		projection = mgl32.Perspective(mgl32.DegToRad(45.0), float32(width)/float32(height), 0.1, 100.0)
+	}
+
+	a.Shutdown = func() {
+		if frame != nil {
+			frame.Delete()
+		}
+		for _, s := range shadows {
+			s.Delete()
+		}
+		if shadowView != nil {
+			shadowView.Delete()
+		}
+		vao.Delete()
+		vbo.Delete()
+		engine.DeleteProgram(shadowProgram)
+		engine.DeleteProgram(program)
+	}
+}
+
+// modelUniform is a model matrix uniform. The lit program also uploads the normal matrix with it
+type modelUniform interface {
+	UpdateMatrix(mgl32.Mat4)
+	UpdateUniform()
+}
+
+// drawObjects draws every object with the program in use. The shadow pass has no material
+func drawObjects(vao VertexArrayObject, model modelUniform, material *engine.MaterialUniforms) {
+	engine.State.BindVertexArray(vao.addr)
+	for _, o := range objects {
+		model.UpdateMatrix(mgl32.Translate3D(o.position.X(), o.position.Y(), o.position.Z()).Mul4(
+			mgl32.Scale3D(o.scale.X(), o.scale.Y(), o.scale.Z())))
+		model.UpdateUniform()
+		if material != nil {
+			material.Upload(o.material)
+		}
+		engine.DrawArrays(gl.TRIANGLES, 0, 6*6)
+	}
+}
+
+// orbit returns a point circling the middle of the scene at time t
+func orbit(t, radius, height float64) mgl32.Vec3 {
+	return mgl32.Vec3{float32(radius * math.Cos(t)), float32(height), float32(radius * math.Sin(t))}
+}
@@ -0,0 +1,39 @@
+package lighting
+
+import (
+	"github.com/Grindlemire/gl/engine"
+)
+
+var vertexShaderSrc = `
+	#version 410
+` + engine.CameraGLSL + `
+	uniform mat4 model;
+	uniform mat3 normalMatrix;
+
+	layout(location = 0) in vec3 vert;
+	layout(location = 1) in vec3 vertNormal;
+
+	out vec3 fragPosition;
+	out vec3 fragNormal;
+
+	void main() {
+		// lighting happens in world space so pass the world position and normal along
+		vec4 world = model * vec4(vert, 1.0);
+		fragPosition = world.xyz;
+		fragNormal = normalMatrix * vertNormal;
+		gl_Position = projection * view * world;
+	}
+` + "\x00"
+
+var fragShaderSrc = `
+	#version 410
+` + engine.CameraGLSL + engine.LightsGLSL + `
+	in vec3 fragPosition;
+	in vec3 fragNormal;
+
+	out vec4 outputColor;
+
+	void main() {
+		outputColor = vec4(shadeLights(fragNormal, fragPosition, material.diffuse), 1.0);
+	}
+` + "\x00"
@@ -0,0 +1,54 @@
+package lighting
+
+// cubeVertices is a cube made of 36 vertices (6 faces * 2 triangles * 3 vertices). Each corner is
+// repeated for every face it touches since each face needs its own normal for lighting
+var cubeVertices = []float32{
+	// X, Y, Z, NX, NY, NZ
+	// back face (z=-1)
+	-1.0, -1.0, -1.0, 0.0, 0.0, -1.0,
+	1.0, 1.0, -1.0, 0.0, 0.0, -1.0,
+	1.0, -1.0, -1.0, 0.0, 0.0, -1.0,
+	1.0, 1.0, -1.0, 0.0, 0.0, -1.0,
+	-1.0, -1.0, -1.0, 0.0, 0.0, -1.0,
+	-1.0, 1.0, -1.0, 0.0, 0.0, -1.0,
+
+	// front face (z=1)
+	-1.0, -1.0, 1.0, 0.0, 0.0, 1.0,
+	1.0, -1.0, 1.0, 0.0, 0.0, 1.0,
+	1.0, 1.0, 1.0, 0.0, 0.0, 1.0,
+	1.0, 1.0, 1.0, 0.0, 0.0, 1.0,
+	-1.0, 1.0, 1.0, 0.0, 0.0, 1.0,
+	-1.0, -1.0, 1.0, 0.0, 0.0, 1.0,
+
+	// left face (x=-1)
+	-1.0, 1.0, 1.0, -1.0, 0.0, 0.0,
+	-1.0, 1.0, -1.0, -1.0, 0.0, 0.0,
+	-1.0, -1.0, -1.0, -1.0, 0.0, 0.0,
+	-1.0, -1.0, -1.0, -1.0, 0.0, 0.0,
+	-1.0, -1.0, 1.0, -1.0, 0.0, 0.0,
+	-1.0, 1.0, 1.0, -1.0, 0.0, 0.0,
+
+	// right face (x=1)
+	1.0, 1.0, 1.0, 1.0, 0.0, 0.0,
+	1.0, -1.0, -1.0, 1.0, 0.0, 0.0,
+	1.0, 1.0, -1.0, 1.0, 0.0, 0.0,
+	1.0, -1.0, -1.0, 1.0, 0.0, 0.0,
+	1.0, 1.0, 1.0, 1.0, 0.0, 0.0,
+	1.0, -1.0, 1.0, 1.0, 0.0, 0.0,
+
+	// bottom face (y=-1)
+	-1.0, -1.0, -1.0, 0.0, -1.0, 0.0,
+	1.0, -1.0, -1.0, 0.0, -1.0, 0.0,
+	1.0, -1.0, 1.0, 0.0, -1.0, 0.0,
+	1.0, -1.0, 1.0, 0.0, -1.0, 0.0,
+	-1.0, -1.0, 1.0, 0.0, -1.0, 0.0,
+	-1.0, -1.0, -1.0, 0.0, -1.0, 0.0,
+
+	// top face (y=1)
+	-1.0, 1.0, -1.0, 0.0, 1.0, 0.0,
+	1.0, 1.0, 1.0, 0.0, 1.0, 0.0,
+	1.0, 1.0, -1.0, 0.0, 1.0, 0.0,
+	1.0, 1.0, 1.0, 0.0, 1.0, 0.0,
+	-1.0, 1.0, -1.0, 0.0, 1.0, 0.0,
+	-1.0, 1.0, 1.0, 0.0, 1.0, 0.0,
+}
@@ -0,0 +1,187 @@
+package normalmapping
+
+import (
+	"log"
+	"math"
+
+	"github.com/Grindlemire/gl/assets"
+	"github.com/Grindlemire/gl/engine"
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/pkg/errors"
+)
+
+// width and height of the window we are creating
+const (
+	winWidth  = 960
+	winHeight = 540
+)
+
+// bumpStrength is how steep the bumps made from the wall texture are
+const bumpStrength = 2
+
+// eye is where the camera sits looking at the cube
+var eye = mgl32.Vec3{0, 1.5, 5}
+
+func init() {
+	engine.Register(engine.Scene{
+		Name:   "normalMapping",
+		Title:  "Normal Mapping",
+		Width:  winWidth,
+		Height: winHeight,
+		Setup:  setup,
+	})
+}
+
+// setup sets the hooks that run the normal mapping demo. N turns the normal map on and off
+func setup(a *engine.App) {
+	var (
+		program    uint32
+		model      *Model
+		projection mgl32.Mat4
+		frame      *engine.FrameUniforms
+		uniforms   *engine.NormalMapUniforms
+		material   engine.NormalMappedMaterial
+		normalMap  *engine.Texture
+		vao        VertexArrayObject
+		vbo        VertexBufferObject
+		vertices   int32
+	)
+
+	view := mgl32.LookAtV(eye, mgl32.Vec3{0, 0, 0}, mgl32.Vec3{0, 1, 0})
+
+	// a light close to the cube shows off the bumps best as it moves across them
+	lights := []engine.Light{
+		engine.NewDirectionalLight(mgl32.Vec3{-0.2, -1, -0.4}, mgl32.Vec3{0.2, 0.2, 0.2}),
+		engine.NewPointLight(mgl32.Vec3{}, mgl32.Vec3{1, 0.9, 0.8}),
+	}
+
+	t, previousT := 0.0, 0.0
+
+	a.Init = func(*engine.App) (err error) {
+		program, err = engine.NewProgram(vertexShaderSrc, fragShaderSrc)
+		if err != nil {
+			return err
+		}
+
+		frame, err = engine.NewFrameUniforms()
+		if err != nil {
+			return err
+		}
+		err = frame.Bind(program)
+		if err != nil {
+			return err
+		}
+
+		// create our transformations
+		model = NewModel(program, "model", "normalMatrix")
+		uniforms = engine.NewNormalMapUniforms(program)
+
+		// the wall has no normal map of its own so make one from how bright it is
+		wall, err := engine.DecodeImage(assets.FS, "wall.jpg")
+		if err != nil {
+			return errors.Wrap(err, "unable to load the wall texture")
+		}
+		normalMap = engine.NewTexture(engine.NormalMapFromHeight(wall, bumpStrength))
+		material = engine.NormalMappedMaterial{
+			Material:   engine.Material{Diffuse: mgl32.Vec3{1, 1, 1}, Specular: mgl32.Vec3{0.3, 0.3, 0.3}, Shininess: 16},
+			DiffuseMap: engine.NewTexture(wall),
+			NormalMap:  normalMap,
+		}
+
+		// the cube only has positions and uvs, the mesh fills in the normals and tangents
+		mesh, err := engine.NewMesh(cubeVertices, nil)
+		if err != nil {
+			return err
+		}
+		mesh.ComputeTangents()
+		vertices = int32(len(mesh.Positions))
+
+		// load our data into our buffers
+		vao = NewVAO()
+		vbo = NewVBO(mesh.Vertices())
+
+		// name everything so debug messages and tools like RenderDoc can tell us what they mean
+		engine.Label(gl.PROGRAM, program, "normal mapped")
+		engine.Label(gl.VERTEX_ARRAY, vao.addr, "cube")
+		engine.Label(gl.BUFFER, vbo.addr, "cube vertices")
+		engine.Label(gl.TEXTURE, material.DiffuseMap.ID, "wall.jpg")
+		engine.Label(gl.TEXTURE, normalMap.ID, "wall normals")
+
+		// map our data into the shader
+		vao.MapAttribute(program, "vert", engine.MeshPositionOffset, 3, engine.MeshStride)
+		vao.MapAttribute(program, "vertTexCoord", engine.MeshUVOffset, 2, engine.MeshStride)
+		vao.MapAttribute(program, "vertNormal", engine.MeshNormalOffset, 3, engine.MeshStride)
+		vao.MapAttribute(program, "vertTangent", engine.MeshTangentOffset, 4, engine.MeshStride)
+
+		a.Window.SetKeyCallback(func(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+			if action != glfw.Press {
+				return
+			}
+			switch key {
+			case glfw.KeyEscape:
+				w.SetShouldClose(true)
+			case glfw.KeyN:
+				if material.NormalMap != nil {
+					material.NormalMap = nil
+				} else {
+					material.NormalMap = normalMap
+				}
+				log.Printf("normal mapping: %v", material.NormalMap != nil)
+			}
+		})
+
+		// enable depth of field and general constants
+		engine.State.Enable(gl.DEPTH_TEST)
+		engine.State.DepthFunc(gl.LESS)
+		gl.ClearColor(0.02, 0.02, 0.05, 0.0)
+		return nil
+	}
+
+	a.Update = func(dt float64) {
+		previousT = t
+		t += dt
+	}
+
+	a.Render = func(alpha float64) {
+		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+
+		renderT := previousT + (t-previousT)*alpha
+		lights[1].Position = mgl32.Vec3{float32(2.5 * math.Cos(renderT)), 1, float32(2.5 * math.Sin(renderT))}
+
+		_ = frame.SetCamera(view, projection, eye)
+		_ = frame.SetLights(lights, true)
+
+		engine.State.UseProgram(program)
+		model.UpdateMatrix(mgl32.HomogRotate3DY(float32(renderT * 0.3)))
+		model.UpdateUniform()
+		uniforms.Upload(material)
+
+		engine.State.BindVertexArray(vao.addr)
+		engine.DrawArrays(gl.TRIANGLES, 0, vertices)
+	}
+
+	a.Resize = func(width, height int) {
+		// a minimized window has no height so keep the old aspect ratio
+		if height == 0 {
+			return
+		}
+		projection = mgl32.Perspective(mgl32.DegToRad(45.0), float32(width)/float32(height), 0.1, 100.0)
+	}
+
+	a.Shutdown = func() {
+		if frame != nil {
+			frame.Delete()
+		}
+		if material.DiffuseMap != nil {
+			material.DiffuseMap.Delete()
+		}
+		if normalMap != nil {
+			normalMap.Delete()
+		}
+		vao.Delete()
+		vbo.Delete()
+		engine.DeleteProgram(program)
+	}
+}
@@ -0,0 +1,49 @@
+package normalmapping
+
+import (
+	"github.com/Grindlemire/gl/engine"
+)
+
+var vertexShaderSrc = `
+	#version 410
+` + engine.CameraGLSL + `
+	uniform mat4 model;
+	uniform mat3 normalMatrix;
+
+	layout(location = 0) in vec3 vert;
+	layout(location = 1) in vec2 vertTexCoord;
+	layout(location = 2) in vec3 vertNormal;
+	layout(location = 3) in vec4 vertTangent;
+
+	out vec3 fragPosition;
+	out vec2 fragTexCoord;
+	out vec3 fragNormal;
+	out vec4 fragTangent;
+
+	void main() {
+		vec4 world = model * vec4(vert, 1.0);
+		fragPosition = world.xyz;
+		fragTexCoord = vertTexCoord;
+		fragNormal = normalMatrix * vertNormal;
+		// tangents lie along the surface so they move with the model, not like normals
+		fragTangent = vec4(mat3(model) * vertTangent.xyz, vertTangent.w);
+		gl_Position = projection * view * world;
+	}
+` + "\x00"
+
+var fragShaderSrc = `
+	#version 410
+` + engine.CameraGLSL + engine.LightsGLSL + engine.NormalMapGLSL + `
+	in vec3 fragPosition;
+	in vec2 fragTexCoord;
+	in vec3 fragNormal;
+	in vec4 fragTangent;
+
+	out vec4 outputColor;
+
+	void main() {
+		vec3 albedo = texture(texSampler, fragTexCoord).rgb * material.diffuse;
+		vec3 normal = perturbNormal(fragNormal, fragTangent, fragTexCoord);
+		outputColor = vec4(shadeLights(normal, fragPosition, albedo), 1.0);
+	}
+` + "\x00"
@@ -0,0 +1,384 @@
+package pbr
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/Grindlemire/gl/assets"
+	"github.com/Grindlemire/gl/engine"
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/pkg/errors"
+)
+
+// width and height of the window we are creating
+const (
+	winWidth  = 960
+	winHeight = 540
+)
+
+// the spheres are laid out in a grid: metallic goes up the rows and roughness across the columns
+const (
+	gridSize    = 5
+	gridSpacing = 2.5
+)
+
+// samples is how many samples per pixel the post processing renders the scene with when
+// multisampling is on
+const samples = 4
+
+// environmentSize is how many texels across each face of the environment cubemap is
+const environmentSize = 512
+
+// flags are the demo's options. envFile is an equirectangular Radiance .hdr panorama to light the
+// scene with
+var (
+	flags   = flag.NewFlagSet("pbr", flag.ExitOnError)
+	envFile = flags.String("env", "", "light the spheres with this Radiance .hdr environment map instead of the made up sky")
+)
+
+// eye is where the camera sits looking at the grid
+var eye = mgl32.Vec3{0, 0, 14}
+
+// sphere is a sphere in the scene with its own material
+type sphere struct {
+	position mgl32.Vec3
+	material engine.PBRMaterial
+}
+
+func init() {
+	engine.Register(engine.Scene{
+		Name:   "pbr",
+		Title:  "Physically Based Rendering",
+		Width:  winWidth,
+		Height: winHeight,
+		Flags:  flags,
+		Setup:  setup,
+	})
+}
+
+// setup sets the hooks that run the PBR demo. Up and down change the exposure, E turns the
+// environment lighting and skybox on and off, P turns post processing on and off, 1-6 turn each
+// effect on and off, B moves bloom after the tonemapping to show why it goes first and M turns
+// multisampling on and off
+func setup(a *engine.App) {
+	var (
+		program    uint32
+		model      *Model
+		projection mgl32.Mat4
+		frame      *engine.FrameUniforms
+		uniforms   *engine.PBRUniforms
+		env        *engine.Environment
+		skybox     *engine.Skybox
+		post       *engine.PostProcess
+		lut        *engine.Texture
+		msaa       = true
+		spheres    []sphere
+		baseColor  *engine.Texture
+		normalMap  *engine.Texture
+		vao        VertexArrayObject
+		vbo        VertexBufferObject
+		ebo        ElementBufferObject
+		indices    int32
+	)
+
+	view := mgl32.LookAtV(eye, mgl32.Vec3{0, 0, 0}, mgl32.Vec3{0, 1, 0})
+
+	// physically based lights are a lot brighter than the phong ones. The tonemapping brings them
+	// back into range
+	lights := []engine.Light{
+		engine.NewDirectionalLight(mgl32.Vec3{-0.3, -0.5, -1}, mgl32.Vec3{2, 2, 2}),
+	}
+	for _, p := range []mgl32.Vec3{{-6, 6, 8}, {6, 6, 8}, {-6, -6, 8}, {6, -6, 8}} {
+		light := engine.NewPointLight(p, mgl32.Vec3{40, 40, 40})
+		light.Ambient = 0
+		lights = append(lights, light)
+	}
+
+	a.Init = func(*engine.App) (err error) {
+		program, err = engine.NewProgram(vertexShaderSrc, fragShaderSrc)
+		if err != nil {
+			return err
+		}
+
+		frame, err = engine.NewFrameUniforms()
+		if err != nil {
+			return err
+		}
+		err = frame.Bind(program)
+		if err != nil {
+			return err
+		}
+
+		// create our transformations
+		model = NewModel(program, "model", "normalMatrix")
+		uniforms = engine.NewPBRUniforms(program)
+
+		// the environment lights the spheres from every direction and fills in the background
+		sky := newSky(1024, 512)
+		if *envFile != "" {
+			sky, err = engine.LoadHDR(os.DirFS("."), *envFile)
+			if err != nil {
+				return err
+			}
+		}
+		env, err = engine.NewEnvironment(sky, environmentSize)
+		if err != nil {
+			return err
+		}
+		frame.Environment = env
+		skybox, err = engine.NewSkybox(frame)
+		if err != nil {
+			return err
+		}
+		engine.State.UseProgram(program)
+
+		// the spheres are rendered in linear light and the post processing tonemaps them
+		width, height := a.Window.GetFramebufferSize()
+		post, err = engine.NewPostProcess(width, height)
+		if err != nil {
+			return err
+		}
+		err = post.SetSamples(sceneSamples(msaa))
+		if err != nil {
+			return err
+		}
+		// a warm grade that lifts the shadows a little
+		lut = engine.NewTexture(engine.NewColorGradeLUT(16, func(c mgl32.Vec3) mgl32.Vec3 {
+			return mgl32.Vec3{c.X()*0.95 + 0.05, c.Y()*0.92 + 0.03, c.Z() * 0.85}
+		}))
+		err = addEffects(post, lut)
+		if err != nil {
+			return err
+		}
+		frame.LinearOutput = true
+
+		// the wall is painted in sRGB so it is uploaded as sRGB, the normals made from it are not
+		wall, err := engine.DecodeImage(assets.FS, "wall.jpg")
+		if err != nil {
+			return errors.Wrap(err, "unable to load the wall texture")
+		}
+		baseColor = engine.NewSRGBTexture(wall)
+		normalMap = engine.NewTexture(engine.NormalMapFromHeight(wall, 2))
+
+		spheres = nil
+		for row := 0; row < gridSize; row++ {
+			for col := 0; col < gridSize; col++ {
+				material := engine.NewPBRMaterial()
+				material.BaseColor = mgl32.Vec4{0.8, 0.1, 0.1, 1}
+				material.Metallic = float32(row) / (gridSize - 1)
+				material.Roughness = float32(col) / (gridSize - 1)
+				spheres = append(spheres, sphere{
+					position: mgl32.Vec3{
+						(float32(col) - (gridSize-1)/2.0) * gridSpacing,
+						(float32(row) - (gridSize-1)/2.0) * gridSpacing,
+						0,
+					},
+					material: material,
+				})
+			}
+		}
+
+		// a textured sphere off to the side looks like something imported from a modelling tool
+		textured := engine.NewPBRMaterial()
+		textured.Metallic = 0
+		textured.BaseColorMap = baseColor
+		textured.NormalMap = normalMap
+		spheres = append(spheres, sphere{position: mgl32.Vec3{(gridSize + 1) / 2.0 * gridSpacing, 0, 0}, material: textured})
+
+		// load our data into our buffers
+		mesh := engine.NewSphereMesh(48, 24)
+		indices = int32(len(mesh.Indices))
+		vao = NewVAO()
+		vbo = NewVBO(mesh.Vertices())
+		ebo = NewEBO(mesh.Indices)
+
+		// name everything so debug messages and tools like RenderDoc can tell us what they mean
+		engine.Label(gl.PROGRAM, program, "pbr")
+		engine.Label(gl.VERTEX_ARRAY, vao.addr, "sphere")
+		engine.Label(gl.BUFFER, vbo.addr, "sphere vertices")
+		engine.Label(gl.BUFFER, ebo.addr, "sphere indices")
+		engine.Label(gl.TEXTURE, baseColor.ID, "wall.jpg")
+		engine.Label(gl.TEXTURE, normalMap.ID, "wall normals")
+
+		// map our data into the shader
+		vao.MapAttribute(program, "vert", engine.MeshPositionOffset, 3, engine.MeshStride)
+		vao.MapAttribute(program, "vertTexCoord", engine.MeshUVOffset, 2, engine.MeshStride)
+		vao.MapAttribute(program, "vertNormal", engine.MeshNormalOffset, 3, engine.MeshStride)
+		vao.MapAttribute(program, "vertTangent", engine.MeshTangentOffset, 4, engine.MeshStride)
+
+		a.Window.SetKeyCallback(func(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+			if action != glfw.Press && action != glfw.Repeat {
+				return
+			}
+			switch key {
+			case glfw.KeyEscape:
+				w.SetShouldClose(true)
+			case glfw.KeyUp:
+				frame.Exposure *= 1.25
+				log.Printf("exposure: %.2f", frame.Exposure)
+			case glfw.KeyDown:
+				frame.Exposure /= 1.25
+				log.Printf("exposure: %.2f", frame.Exposure)
+			case glfw.KeyE:
+				if frame.Environment != nil {
+					frame.Environment = nil
+				} else {
+					frame.Environment = env
+				}
+				log.Printf("environment lighting: %v", frame.Environment != nil)
+			case glfw.KeyP:
+				// without the post processing the shaders tonemap for themselves
+				frame.LinearOutput = !frame.LinearOutput
+				log.Printf("post processing: %v", frame.LinearOutput)
+			case glfw.Key1, glfw.Key2, glfw.Key3, glfw.Key4, glfw.Key5, glfw.Key6:
+				i := int(key - glfw.Key1)
+				if i < len(post.Effects) {
+					e := post.Effects[i]
+					e.Enabled = !e.Enabled
+					log.Printf("%s: %v", e.Name, e.Enabled)
+				}
+			case glfw.KeyM:
+				// the window's samples are used when the post processing is off
+				msaa = !msaa
+				engine.SetMultisample(msaa)
+				err := post.SetSamples(sceneSamples(msaa))
+				if err != nil {
+					log.Printf("unable to change the samples: %v", err)
+					return
+				}
+				log.Printf("multisampling: %v (%d samples)", msaa, post.Samples)
+			case glfw.KeyB:
+				// bloom after the tonemapping only finds the few pixels that are still over 1
+				index := 0
+				if post.Effects[0].Name == "bloom" {
+					index = 1
+				}
+				err := post.Move("bloom", index)
+				if err != nil {
+					log.Printf("unable to move bloom: %v", err)
+					return
+				}
+				for i, e := range post.Effects {
+					log.Printf("%d: %s", i+1, e.Name)
+				}
+			}
+		})
+
+		// enable depth of field and general constants
+		engine.State.Enable(gl.DEPTH_TEST)
+		engine.State.DepthFunc(gl.LESS)
+		gl.ClearColor(0.0, 0.0, 0.0, 0.0)
+		return nil
+	}
+
+	a.Render = func(alpha float64) {
+		if frame.LinearOutput {
+			post.Begin()
+			defer post.End()
+		} else {
+			gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+		}
+
+		_ = frame.SetCamera(view, projection, eye)
+		_ = frame.SetLights(lights, false)
+
+		engine.State.UseProgram(program)
+		engine.State.BindVertexArray(vao.addr)
+		for _, s := range spheres {
+			model.UpdateMatrix(mgl32.Translate3D(s.position.X(), s.position.Y(), s.position.Z()))
+			model.UpdateUniform()
+			uniforms.Upload(s.material)
+			engine.DrawElements(gl.TRIANGLES, indices, gl.UNSIGNED_INT, 0)
+		}
+
+		if frame.Environment != nil {
+			skybox.Draw(env.Skybox)
+		}
+	}
+
+	a.Resize = func(width, height int) {
+		// a minimized window has no height so keep the old aspect ratio
+		if height == 0 {
+			return
+		}
+		projection = mgl32.Perspective(mgl32.DegToRad(45.0), float32(width)/float32(height), 0.1, 100.0)
+		err := post.Resize(width, height)
+		if err != nil {
+			log.Printf("unable to resize the post processing: %v", err)
+		}
+	}
+
+	a.Shutdown = func() {
+		if frame != nil {
+			frame.Delete()
+		}
+		if skybox != nil {
+			skybox.Delete()
+		}
+		if post != nil {
+			post.Delete()
+		}
+		if lut != nil {
+			lut.Delete()
+		}
+		if env != nil {
+			env.Delete()
+		}
+		if baseColor != nil {
+			baseColor.Delete()
+		}
+		if normalMap != nil {
+			normalMap.Delete()
+		}
+		vao.Delete()
+		vbo.Delete()
+		ebo.Delete()
+		engine.DeleteProgram(program)
+	}
+}
+
+// addEffects adds the effects to the post processing in the order they work best in. Bloom needs
+// the colors brighter than 1 so it goes before the tonemapping, and the grading and anti aliasing
+// work on the colors the screen will show so they go after the gamma
+func addEffects(post *engine.PostProcess, lut *engine.Texture) (err error) {
+	effects := []func() (*engine.Effect, error){
+		engine.NewBloomEffect,
+		func() (*engine.Effect, error) { return engine.NewTonemapEffect(engine.TonemapACES) },
+		engine.NewGammaEffect,
+		func() (*engine.Effect, error) { return engine.NewColorGradeEffect(lut) },
+		engine.NewFXAAEffect,
+		engine.NewVignetteEffect,
+	}
+	for _, newEffect := range effects {
+		e, err := newEffect()
+		if err != nil {
+			return err
+		}
+		// once it is added the post processing deletes it
+		err = post.Add(e)
+		if err != nil {
+			e.Delete()
+			return err
+		}
+	}
+	return nil
+}
+
+// sceneSamples returns how many samples the post processing should render with, which is as many
+// of samples as the driver supports for framebuffers and textures
+func sceneSamples(msaa bool) int {
+	if !msaa {
+		return 0
+	}
+	most := samples
+	if limit := engine.MaxSamples(); limit < most {
+		most = limit
+	}
+	if limit := engine.MaxTextureSamples(); limit < most {
+		most = limit
+	}
+	return most
+}
@@ -0,0 +1,46 @@
+package pbr
+
+import (
+	"math"
+
+	"github.com/Grindlemire/gl/engine"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// sun is the direction the sun in the made up sky shines from
+var sun = mgl32.Vec3{0.4, 0.5, 0.75}.Normalize()
+
+// newSky makes an equirectangular panorama of a clear sky with a bright sun over dark ground, for
+// when there is no .hdr file to light the scene with
+func newSky(width, height int) (img *engine.HDRImage) {
+	var (
+		zenith  = mgl32.Vec3{0.15, 0.3, 0.8}
+		horizon = mgl32.Vec3{0.9, 0.9, 1.0}
+		ground  = mgl32.Vec3{0.12, 0.1, 0.08}
+	)
+
+	img = engine.NewHDRImage(width, height)
+	for y := 0; y < height; y++ {
+		// rows go from straight up to straight down, columns all the way around
+		latitude := math.Pi/2 - math.Pi*(float64(y)+0.5)/float64(height)
+		for x := 0; x < width; x++ {
+			longitude := 2*math.Pi*(float64(x)+0.5)/float64(width) - math.Pi
+			direction := mgl32.Vec3{
+				float32(math.Cos(latitude) * math.Cos(longitude)),
+				float32(math.Sin(latitude)),
+				float32(math.Cos(latitude) * math.Sin(longitude)),
+			}
+
+			color := ground
+			if direction.Y() > 0 {
+				t := float32(math.Pow(float64(direction.Y()), 0.5))
+				color = horizon.Mul(1 - t).Add(zenith.Mul(t))
+			}
+			if direction.Dot(sun) > 0.999 {
+				color = mgl32.Vec3{50, 45, 40}
+			}
+			img.Set(x, y, color)
+		}
+	}
+	return img
+}
@@ -0,0 +1,79 @@
+package pbr
+
+import (
+	"github.com/Grindlemire/gl/engine"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Transformation is the generic struct defining a transformation matrix used to convert coordinates
+// The path for converting coordinates is Model -> World -> Camera -> Screen
+// Model handles Model -> World
+// The view (World -> Camera) and projection (Camera -> Screen) are shared by every program so they
+// live in the engine's Camera uniform block instead
+type Transformation struct {
+	addr   int32      // the location in memory of the matrix
+	matrix mgl32.Mat4 // the actual matrix value
+}
+
+// UpdateUniform sends an update to the openGL shader for the transformation matrix
+// This is called when the transformation matrix has changed and we want to push that change
+// to the shader
+func (t *Transformation) UpdateUniform() {
+	engine.Backend.UniformMatrix4fv(t.addr, 1, false, &t.matrix[0])
+	engine.CheckError("UpdateUniform")
+}
+
+// UpdateMatrix updates the matrix to the new matrix
+func (t *Transformation) UpdateMatrix(newMatrix mgl32.Mat4) {
+	t.matrix = newMatrix
+}
+
+// GetAddr returns the address of the transformation matrix
+func (t *Transformation) GetAddr() int32 {
+	return t.addr
+}
+
+// GetMatrix returns the value of the transformation matrix
+func (t *Transformation) GetMatrix() mgl32.Mat4 {
+	return t.matrix
+}
+
+// Model handles the model transformation matrix (it converts model coordinates to world coordinates)
+// This converts a standard model to placing it somewhere in the world. Lighting also needs the
+// normal matrix, which turns the model's normals into world space normals
+type Model struct {
+	Transformation
+	normalAddr int32 // the location in memory of the normal matrix
+}
+
+// NewModel creates a model transformation matrix
+// It takes the program pointer, the name of the model transformation in GLSL and the name of the
+// normal matrix in GLSL
+func NewModel(program uint32, name, normalName string) (model *Model) {
+	// transform from world coordinates
+	matrix := mgl32.Ident4()
+	addr := engine.Backend.GetUniformLocation(program, name)
+
+	model = &Model{
+		Transformation: Transformation{
+			addr:   addr,
+			matrix: matrix,
+		},
+		normalAddr: engine.Backend.GetUniformLocation(program, normalName),
+	}
+	model.UpdateUniform()
+
+	return model
+}
+
+// UpdateUniform sends the model matrix and the normal matrix that goes with it to the shader
+func (m *Model) UpdateUniform() {
+	m.Transformation.UpdateUniform()
+	normal := m.NormalMatrix()
+	engine.Backend.UniformMatrix3fv(m.normalAddr, 1, false, &normal[0])
+}
+
+// NormalMatrix returns the matrix that transforms the model's normals into world space
+func (m *Model) NormalMatrix() mgl32.Mat3 {
+	return engine.NormalMatrix(m.matrix)
+}
@@ -0,0 +1,339 @@
+package renderqueue
+
+import (
+	"log"
+	"math"
+
+	"github.com/Grindlemire/gl/assets"
+	"github.com/Grindlemire/gl/engine"
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/pkg/errors"
+)
+
+// width and height of the window we are creating
+const (
+	winWidth  = 960
+	winHeight = 540
+)
+
+// the objects ride around a carousel this far from the middle. The outer ring goes all the way
+// around the camera so most of it is out of sight and culled
+const (
+	carouselObjects = 16
+	carouselRadius  = 6
+	outerObjects    = 120
+	outerRadius     = 24
+)
+
+// eye is where the camera sits looking down at the carousel
+var eye = mgl32.Vec3{0, 7, 16}
+
+func init() {
+	engine.Register(engine.Scene{
+		Name:   "renderQueue",
+		Title:  "Render Queue",
+		Width:  winWidth,
+		Height: winHeight,
+		Setup:  setup,
+	})
+}
+
+// setup sets the hooks that run the render queue demo. Every object is submitted to a renderer
+// each frame, which sorts them so objects sharing a material are drawn together and the glass is
+// drawn last. Objects the camera can't see are culled. S logs how many times the renderer had to
+// change state and how many objects it culled, C turns culling off and on, L shows the axes of every
+// object and B the boxes around them, streamed as debug lines. Clicking an object picks it with a
+// ray from the camera, or by reading back an id buffer after G switches to picking on the GPU
+func setup(a *engine.App) {
+	var (
+		program    uint32
+		projection mgl32.Mat4
+		frame      *engine.FrameUniforms
+		renderer   *engine.Renderer
+		lines      *engine.DebugLines
+		showAxes   bool
+		showBounds bool
+		camera     engine.Camera
+		ids        *engine.IDBuffer
+		pickGPU    bool
+		picked     *engine.Node
+		cube       *engine.MeshBuffers
+		sphere     *engine.MeshBuffers
+		wall       *engine.Texture
+		root       *engine.Node
+		carousel   *engine.Node
+		spinners   []*engine.Node
+	)
+
+	// spin at a fixed rate no matter how fast we are rendering. We keep the previous angle
+	// around so rendering can blend between the last two updates
+	angle, previousAngle := 0.0, 0.0
+
+	lights := []engine.Light{
+		engine.NewDirectionalLight(mgl32.Vec3{-0.3, -1, -0.5}, mgl32.Vec3{2, 2, 2}),
+		engine.NewPointLight(mgl32.Vec3{-8, 6, 8}, mgl32.Vec3{60, 60, 60}),
+		engine.NewPointLight(mgl32.Vec3{8, 6, 8}, mgl32.Vec3{60, 60, 60}),
+	}
+
+	a.Init = func(*engine.App) (err error) {
+		program, err = engine.NewProgram(vertexShaderSrc, fragShaderSrc)
+		if err != nil {
+			return err
+		}
+		engine.Label(gl.PROGRAM, program, "render queue")
+
+		frame, err = engine.NewFrameUniforms()
+		if err != nil {
+			return err
+		}
+		renderer = engine.NewRenderer(frame)
+		lines, err = engine.NewDebugLines()
+		if err != nil {
+			return err
+		}
+		width, height := a.Window.GetFramebufferSize()
+		ids, err = engine.NewIDBuffer(width, height)
+		if err != nil {
+			return err
+		}
+
+		// the wall is painted in sRGB so it is uploaded as sRGB
+		rgba, err := engine.DecodeImage(assets.FS, "wall.jpg")
+		if err != nil {
+			return errors.Wrap(err, "unable to load the wall texture")
+		}
+		wall = engine.NewSRGBTexture(rgba)
+		engine.Label(gl.TEXTURE, wall.ID, "wall.jpg")
+
+		cube = engine.NewMeshBuffers(engine.NewCubeMesh())
+		sphere = engine.NewMeshBuffers(engine.NewSphereMesh(32, 16))
+		engine.Label(gl.VERTEX_ARRAY, cube.VAO, "cube")
+		engine.Label(gl.VERTEX_ARRAY, sphere.VAO, "sphere")
+
+		// every object using a material shares it so the renderer only sets it up once
+		engine.State.UseProgram(program)
+		uniforms := engine.NewPBRUniforms(program)
+		newMaterial := func(name string, m engine.PBRMaterial) *engine.RenderMaterial {
+			material := &engine.RenderMaterial{
+				Name:        name,
+				Program:     program,
+				Bind:        func() { uniforms.Upload(m) },
+				Transparent: m.BaseColor.W() < 1,
+			}
+			if m.BaseColorMap != nil {
+				material.Textures = []*engine.Texture{m.BaseColorMap}
+			}
+			return material
+		}
+		colored := func(name string, color mgl32.Vec4, metallic, roughness float32) *engine.RenderMaterial {
+			m := engine.NewPBRMaterial()
+			m.BaseColor = color
+			m.Metallic = metallic
+			m.Roughness = roughness
+			return newMaterial(name, m)
+		}
+		textured := engine.NewPBRMaterial()
+		textured.Metallic = 0
+		textured.BaseColorMap = wall
+
+		floor := colored("floor", mgl32.Vec4{0.5, 0.5, 0.5, 1}, 0, 0.8)
+		materials := []*engine.RenderMaterial{
+			colored("red", mgl32.Vec4{0.8, 0.1, 0.1, 1}, 0, 0.4),
+			colored("gold", mgl32.Vec4{1, 0.77, 0.34, 1}, 1, 0.3),
+			newMaterial("wall", textured),
+			colored("glass", mgl32.Vec4{0.6, 0.8, 1, 0.35}, 0, 0.05),
+			colored("green", mgl32.Vec4{0.1, 0.7, 0.2, 1}, 0, 0.6),
+			colored("smoke", mgl32.Vec4{0.2, 0.2, 0.2, 0.5}, 0, 0.5),
+		}
+
+		// the carousel carries its objects around and each one spins on its own too
+		root = engine.NewNode("root")
+		ground := engine.NewNode("floor")
+		ground.Mesh, ground.Material = cube, floor
+		ground.SetPosition(mgl32.Vec3{0, -1.2, 0})
+		ground.SetScale(mgl32.Vec3{10, 0.2, 10})
+		_ = root.Attach(ground)
+
+		carousel = engine.NewNode("carousel")
+		_ = root.Attach(carousel)
+		spinners = nil
+		for i := 0; i < carouselObjects; i++ {
+			around := 2 * math.Pi * float64(i) / carouselObjects
+			object := engine.NewNode("object")
+			object.Mesh = cube
+			object.SetScale(mgl32.Vec3{0.6, 0.6, 0.6})
+			if i%2 == 1 {
+				object.Mesh = sphere
+				object.SetScale(mgl32.Vec3{0.8, 0.8, 0.8})
+			}
+			object.Material = materials[i%len(materials)]
+			object.SetPosition(mgl32.Vec3{
+				float32(carouselRadius * math.Cos(around)),
+				0,
+				float32(carouselRadius * math.Sin(around)),
+			})
+			_ = carousel.Attach(object)
+			spinners = append(spinners, object)
+		}
+		for i := 0; i < outerObjects; i++ {
+			around := 2 * math.Pi * float64(i) / outerObjects
+			object := engine.NewNode("outer")
+			object.Mesh = cube
+			object.Material = materials[i%len(materials)]
+			object.SetPosition(mgl32.Vec3{
+				float32(outerRadius * math.Cos(around)),
+				0,
+				float32(outerRadius * math.Sin(around)),
+			})
+			_ = carousel.Attach(object)
+		}
+
+		// a big glass ball in the middle that everything behind it shows through
+		middle := engine.NewNode("middle")
+		middle.Mesh, middle.Material = sphere, materials[3]
+		middle.SetScale(mgl32.Vec3{2.5, 2.5, 2.5})
+		_ = root.Attach(middle)
+
+		a.Window.SetKeyCallback(func(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+			if action != glfw.Press {
+				return
+			}
+			switch key {
+			case glfw.KeyEscape:
+				w.SetShouldClose(true)
+			case glfw.KeyS:
+				s := renderer.Stats
+				log.Printf("%d items (%d transparent), %d drawn and %d culled, with %d program and %d material changes in %d draw calls",
+					s.Items, s.Transparent, s.Drawn, s.Culled, s.Programs, s.Materials, engine.Draws.LastFrame.Calls)
+			case glfw.KeyC:
+				renderer.Cull = !renderer.Cull
+				log.Printf("culling: %v", renderer.Cull)
+			case glfw.KeyB:
+				showBounds = !showBounds
+			case glfw.KeyG:
+				pickGPU = !pickGPU
+				log.Printf("picking on the GPU: %v", pickGPU)
+			case glfw.KeyL:
+				showAxes = !showAxes
+			}
+		})
+
+		a.Window.SetMouseButtonCallback(func(w *glfw.Window, button glfw.MouseButton, action glfw.Action, mods glfw.ModifierKey) {
+			if button != glfw.MouseButtonLeft || action != glfw.Press {
+				return
+			}
+			x, y := w.GetCursorPos()
+			width, height := w.GetSize()
+			picked = nil
+
+			if pickGPU {
+				ids.Render(root, camera)
+				if node, ok := ids.Pick(x, y, width, height); ok {
+					picked = node
+					log.Printf("picked %s on the GPU", node.Name)
+				}
+				return
+			}
+
+			picker := engine.Picker{Root: root, Camera: camera, Width: width, Height: height}
+			if hit, ok := picker.Pick(x, y); ok {
+				picked = hit.Node
+				log.Printf("picked %s %.2f away at triangle %d", hit.Node.Name, hit.Distance, hit.Triangle)
+			}
+		})
+
+		// enable depth of field and general constants
+		engine.State.Enable(gl.DEPTH_TEST)
+		engine.State.DepthFunc(gl.LESS)
+		engine.State.Enable(gl.CULL_FACE)
+		gl.ClearColor(0.05, 0.05, 0.08, 1.0)
+		return nil
+	}
+
+	a.Update = func(dt float64) {
+		previousAngle = angle
+		angle += dt * 0.3
+	}
+
+	a.Render = func(alpha float64) {
+		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+
+		// calculate the angle between the last two updates
+		renderAngle := float32(previousAngle + (angle-previousAngle)*alpha)
+		carousel.SetRotation(mgl32.QuatRotate(renderAngle, mgl32.Vec3{0, 1, 0}))
+		for _, spinner := range spinners {
+			spinner.SetRotation(mgl32.QuatRotate(4*renderAngle, mgl32.Vec3{1, 1, 0}.Normalize()))
+		}
+
+		_ = frame.SetLights(lights, false)
+		renderer.SubmitNode(root)
+		camera = engine.Camera{
+			View:       mgl32.LookAtV(eye, mgl32.Vec3{0, 0, 0}, mgl32.Vec3{0, 1, 0}),
+			Projection: projection,
+			Position:   eye,
+		}
+		err := renderer.Render(camera)
+		if err != nil {
+			log.Printf("unable to render: %v", err)
+		}
+
+		if picked != nil {
+			if bounds, ok := picked.WorldBounds(); ok {
+				lines.Box(bounds, mgl32.Vec3{0, 1, 1})
+			}
+		}
+		if showAxes || showBounds {
+			root.Walk(func(n *engine.Node) bool {
+				if n.Mesh == nil {
+					return true
+				}
+				if showAxes {
+					lines.Axes(n.World(), 1)
+				}
+				if bounds, ok := n.WorldBounds(); ok && showBounds {
+					lines.Box(bounds, mgl32.Vec3{1, 1, 0})
+				}
+				return true
+			})
+		}
+		err = lines.Draw(camera)
+		if err != nil {
+			log.Printf("unable to draw the debug lines: %v", err)
+		}
+	}
+
+	a.Resize = func(width, height int) {
+		// a minimized window has no height so keep the old aspect ratio
+		if height == 0 {
+			return
+		}
+		projection = mgl32.Perspective(mgl32.DegToRad(45.0), float32(width)/float32(height), 0.1, 100.0)
+		if err := ids.Resize(width, height); err != nil {
+			log.Printf("unable to resize the id buffer: %v", err)
+		}
+	}
+
+	a.Shutdown = func() {
+		if frame != nil {
+			frame.Delete()
+		}
+		if lines != nil {
+			lines.Delete()
+		}
+		if ids != nil {
+			ids.Delete()
+		}
+		if cube != nil {
+			cube.Delete()
+		}
+		if sphere != nil {
+			sphere.Delete()
+		}
+		if wall != nil {
+			wall.Delete()
+		}
+		engine.DeleteProgram(program)
+	}
+}
@@ -0,0 +1,10 @@
+// Package assets holds the files shared by the tutorials. They are embedded in the binary so the
+// tutorials run from any working directory
+package assets
+
+import "embed"
+
+// FS holds the embedded files
+//
+//go:embed wall.jpg
+var FS embed.FS
@@ -0,0 +1,166 @@
+// Command gl runs any of the tutorials by name
+//
+//	go run ./cmd/gl -list
+//	go run ./cmd/gl texturedCube
+//	go run ./cmd/gl -demo helloCube -headless -screenshot cube.png
+//	go run ./cmd/gl inputCapturing -record session.json
+//
+// A demo's own flags go after its name, or after -- when it is picked with -demo
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/Grindlemire/gl/engine"
+
+	// the tutorials register their scenes when they are imported
+	_ "github.com/Grindlemire/gl/0-helloTriangle"
+	_ "github.com/Grindlemire/gl/1-helloCube"
+	_ "github.com/Grindlemire/gl/10-instancing"
+	_ "github.com/Grindlemire/gl/2-coloredCube"
+	_ "github.com/Grindlemire/gl/4-texturedCube"
+	_ "github.com/Grindlemire/gl/5-InputCapturing"
+	_ "github.com/Grindlemire/gl/6-lighting"
+	_ "github.com/Grindlemire/gl/7-normalMapping"
+	_ "github.com/Grindlemire/gl/8-pbr"
+	_ "github.com/Grindlemire/gl/9-renderQueue"
+)
+
+var (
+	demo       = flag.String("demo", "", "name of the demo to run (it can also be passed as the first argument)")
+	list       = flag.Bool("list", false, "list the demos and exit")
+	width      = flag.Int("width", 0, "width of the window (defaults to the size the demo was made for)")
+	height     = flag.Int("height", 0, "height of the window (defaults to the size the demo was made for)")
+	fullscreen = flag.Bool("fullscreen", false, "run fullscreen on the primary monitor")
+	headless   = flag.Bool("headless", false, "run without showing a window, one update per frame")
+	screenshot = flag.String("screenshot", "", "save a png of a frame to this file and exit")
+	frames     = flag.Int("frames", 1, "how many frames to render before taking the screenshot")
+	samples    = flag.Int("samples", engine.DefaultConfig().Samples, "samples per pixel to smooth edges with (MSAA), 0 turns it off")
+	debug      = flag.Bool("debug", false, "create a debug context and log the errors openGL reports")
+	profile    = flag.Bool("profile", false, "log the frame rate and how long the cpu and gpu spend on each frame")
+)
+
+// runs the program
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+
+	if *list {
+		listScenes()
+		return
+	}
+
+	if *samples < 0 {
+		log.Printf("-samples can't be negative")
+		os.Exit(2)
+	}
+
+	name, args := *demo, flag.Args()
+	if name == "" && len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+	if name == "" {
+		usage()
+		os.Exit(2)
+	}
+
+	scene, ok := engine.LookupScene(name)
+	if !ok {
+		log.Printf("There is no demo named %q", name)
+		listScenes()
+		os.Exit(2)
+	}
+
+	if scene.Flags != nil {
+		// the scene's flag set exits with its own usage when they don't parse
+		scene.Flags.Parse(args)
+		args = scene.Flags.Args()
+	}
+	if len(args) > 0 {
+		log.Printf("Unexpected arguments for %s: %v", scene.Name, args)
+		os.Exit(2)
+	}
+
+	config := engine.DefaultConfig()
+	config.Title = ""
+	config.Width = *width
+	config.Height = *height
+	config.Fullscreen = *fullscreen
+	config.Hidden = *headless
+	config.Samples = *samples
+	config.Debug = *debug
+
+	log.Printf("Starting %s!", scene.Title)
+
+	app := engine.NewSceneApp(scene, config)
+
+	// running headless is for automation so make every run of the demo look the same
+	// instead of depending on how fast the machine is
+	if *headless {
+		app.Lockstep = true
+	}
+
+	if *profile {
+		app.Profiler = engine.NewProfiler(time.Second)
+	}
+
+	if *screenshot != "" {
+		takeScreenshot(app, *screenshot, *frames)
+	}
+
+	if err := app.Run(); err != nil {
+		log.Fatalf("Error running %s: %v", scene.Name, err)
+	}
+}
+
+// takeScreenshot wraps the app's render hook so it saves the given frame to a file and
+// closes the window
+func takeScreenshot(app *engine.App, file string, frames int) {
+	render := app.Render
+	count := 0
+
+	app.Render = func(alpha float64) {
+		render(alpha)
+
+		count++
+		if count < frames {
+			return
+		}
+
+		if err := app.Screenshot(file); err != nil {
+			log.Printf("Error taking screenshot: %v", err)
+		} else {
+			log.Printf("Saved screenshot to %s", file)
+		}
+		app.Window.SetShouldClose(true)
+	}
+}
+
+// listScenes prints every demo we can run
+func listScenes() {
+	fmt.Fprintln(os.Stderr, "Demos:")
+	for _, scene := range engine.Scenes() {
+		fmt.Fprintf(os.Stderr, "  %-16s %s\n", scene.Name, scene.Title)
+	}
+}
+
+// usage prints how to run the launcher
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s [flags] [demo] [demo flags]\n\n", os.Args[0])
+	listScenes()
+	fmt.Fprintln(os.Stderr, "\nFlags:")
+	flag.PrintDefaults()
+
+	for _, scene := range engine.Scenes() {
+		if scene.Flags == nil {
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "\nFlags for %s (after its name):\n", scene.Name)
+		scene.Flags.SetOutput(os.Stderr)
+		scene.Flags.PrintDefaults()
+	}
+}
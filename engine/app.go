@@ -0,0 +1,308 @@
+// Package engine holds the pieces shared between the tutorials
+package engine
+
+import (
+	"log"
+	"runtime"
+	"time"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/pkg/errors"
+)
+
+// Config is the set of options for the window and openGL context an App creates
+type Config struct {
+	Width  int
+	Height int
+	Title  string
+
+	// VSync waits for the monitor's refresh before swapping buffers
+	VSync bool
+	// Samples is how many samples per pixel to use for multisampling (MSAA). 0 turns it off
+	Samples int
+	// GLMajor and GLMinor are the openGL version to ask for. Our bindings are for 4.1 core
+	// so anything newer won't have its functions loaded
+	GLMajor int
+	GLMinor int
+
+	// Resizable lets the user resize the window
+	Resizable bool
+	// Fullscreen puts the window on the primary monitor at the monitor's resolution
+	Fullscreen bool
+	// Hidden creates the window without showing it (for running headless)
+	Hidden bool
+
+	// Debug creates a debug context and logs the problems openGL reports
+	Debug bool
+	// DebugSeverity is the least severe debug message that gets logged
+	DebugSeverity DebugSeverity
+}
+
+// DefaultConfig returns the config all of the tutorials start from
+func DefaultConfig() Config {
+	return Config{
+		Width:     960,
+		Height:    540,
+		Title:     "OpenGL",
+		VSync:     true,
+		Samples:   4,
+		GLMajor:   4,
+		GLMinor:   1,
+		Resizable: true,
+
+		DebugSeverity: DebugLow,
+	}
+}
+
+// App runs a program. It creates the window and openGL context, calls the hooks that make up the
+// program, and tears everything down when the window closes. The simulation is updated in fixed
+// time steps so movement doesn't depend on the frame rate, and rendered as often as it can in between
+type App struct {
+	Config Config
+	Window *glfw.Window
+
+	// Init is called once the window and openGL context exist. It is where the program loads its
+	// shaders, buffers and textures. Returning an error stops the app
+	Init func(a *App) error
+	// Update advances the simulation by dt seconds. dt is always TimeStep
+	Update func(dt float64)
+	// Render draws a frame. alpha is how far we are between the last update and the next one
+	// (0 to 1) so rendering can interpolate between the previous and current state
+	Render func(alpha float64)
+	// Resize is called with the new size of the framebuffer in pixels whenever it changes
+	Resize func(width, height int)
+	// Shutdown is called after the window closes and before the context is destroyed. It is
+	// where the program deletes its openGL resources
+	Shutdown func()
+
+	// TimeStep is how many seconds each update advances the simulation by
+	TimeStep float64
+	// MaxFrameTime caps how much time a single frame can catch up on. Without it a long hitch
+	// would run so many updates that the next frame falls even further behind (the spiral of death)
+	MaxFrameTime float64
+	// FrameLimit caps how many frames we render a second. 0 means no limit
+	FrameLimit float64
+	// Lockstep runs exactly one update every frame without looking at the clock. This is for
+	// replays and offline rendering where we want to run as fast as possible
+	Lockstep bool
+	// MainThreadBudget is how long each frame can spend running work other goroutines queued
+	// with Do and DoAsync (like texture uploads) before leaving the rest for the next frame
+	MainThreadBudget time.Duration
+
+	// Profiler times each frame's update and render when it is set. Programs can add their own
+	// scopes with Begin and End
+	Profiler *Profiler
+}
+
+// NewApp creates an app that updates 60 times a second. Every hook is optional
+func NewApp(config Config) (a *App) {
+	a = &App{
+		Config:       config,
+		Init:         func(a *App) error { return nil },
+		Update:       func(dt float64) {},
+		Render:       func(alpha float64) {},
+		Resize:       func(width, height int) {},
+		Shutdown:     func() {},
+		TimeStep:     1.0 / 60.0,
+		MaxFrameTime: 0.25,
+
+		MainThreadBudget: 4 * time.Millisecond,
+	}
+	return a
+}
+
+// Run creates the window and runs the main loop until the window is closed. It has to be called
+// from the main goroutine since glfw and openGL can only be used from the main thread
+func (a *App) Run() (err error) {
+	runtime.LockOSThread()
+
+	a.Window, err = initGlfw(a.Config)
+	if err != nil {
+		return errors.Wrap(err, "unable to initialize glfw")
+	}
+	defer glfw.Terminate()
+
+	err = initOpenGL(a.Config)
+	if err != nil {
+		return errors.Wrap(err, "unable to initialize openGL")
+	}
+
+	// escape closes the window unless the program sets its own key callback
+	a.Window.SetKeyCallback(func(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+		if key == glfw.KeyEscape {
+			w.SetShouldClose(true)
+		}
+	})
+	a.Window.SetFramebufferSizeCallback(func(w *glfw.Window, width, height int) {
+		State.Viewport(0, 0, int32(width), int32(height))
+		a.Resize(width, height)
+	})
+
+	err = a.Init(a)
+	if err != nil {
+		return errors.Wrap(err, "unable to initialize the app")
+	}
+	defer func() {
+		a.Shutdown()
+		a.Profiler.Delete()
+		// everything the app created should be deleted by now
+		Resources.Report()
+	}()
+
+	// the framebuffer can be bigger than the window on high dpi displays so let the program
+	// know the real size before the first frame
+	width, height := a.Window.GetFramebufferSize()
+	State.Viewport(0, 0, int32(width), int32(height))
+	a.Resize(width, height)
+
+	a.loop()
+	return nil
+}
+
+// loop runs the main loop until the window is closed
+func (a *App) loop() {
+	previousTime := glfw.GetTime()
+	accumulator := 0.0
+
+	for !a.Window.ShouldClose() {
+		frameStart := glfw.GetTime()
+		frameTime := frameStart - previousTime
+		previousTime = frameStart
+		a.Profiler.BeginFrame()
+
+		if frameTime > a.MaxFrameTime {
+			frameTime = a.MaxFrameTime
+		}
+		accumulator += frameTime
+		if a.Lockstep {
+			accumulator = a.TimeStep
+		}
+
+		// catch the simulation up to the current time
+		a.Profiler.Begin("update")
+		for accumulator >= a.TimeStep {
+			a.Update(a.TimeStep)
+			accumulator -= a.TimeStep
+		}
+		a.Profiler.End()
+
+		// do the openGL work other goroutines have handed us
+		a.Profiler.Begin("main thread")
+		runMainThread(a.MainThreadBudget)
+		a.Profiler.End()
+
+		a.Profiler.Begin("render")
+		a.Render(accumulator / a.TimeStep)
+		a.Profiler.End()
+
+		a.Window.SwapBuffers()
+		glfw.PollEvents()
+		State.EndFrame()
+		Draws.EndFrame()
+		a.Profiler.EndFrame()
+
+		if a.FrameLimit > 0 {
+			a.limitFrame(frameStart)
+		}
+	}
+}
+
+// limitFrame sleeps out whatever is left of the frame's time budget
+func (a *App) limitFrame(frameStart float64) {
+	remaining := 1/a.FrameLimit - (glfw.GetTime() - frameStart)
+	if remaining > 0 {
+		time.Sleep(time.Duration(remaining * float64(time.Second)))
+	}
+}
+
+// initGlfw initiallizes glfw and creates our window
+func initGlfw(config Config) (window *glfw.Window, err error) {
+	err = glfw.Init()
+	if err != nil {
+		return nil, err
+	}
+
+	// lets us resize the window
+	glfw.WindowHint(glfw.Resizable, glfwBool(config.Resizable))
+	glfw.WindowHint(glfw.Visible, glfwBool(!config.Hidden))
+
+	// sets the version of openGL we will be using
+	glfw.WindowHint(glfw.ContextVersionMajor, config.GLMajor)
+	glfw.WindowHint(glfw.ContextVersionMinor, config.GLMinor)
+
+	// set the profile for compatibility
+	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+	glfw.WindowHint(glfw.OpenGLDebugContext, glfwBool(config.Debug))
+
+	// ask for a multisampled default framebuffer
+	glfw.WindowHint(glfw.Samples, config.Samples)
+
+	// a fullscreen window takes over the primary monitor at its current resolution
+	var monitor *glfw.Monitor
+	if config.Fullscreen {
+		monitor = glfw.GetPrimaryMonitor()
+		if monitor == nil {
+			glfw.Terminate()
+			return nil, errors.New("no monitor for fullscreen")
+		}
+		mode := monitor.GetVideoMode()
+		if mode == nil {
+			glfw.Terminate()
+			return nil, errors.New("no video mode for the fullscreen monitor")
+		}
+		config.Width, config.Height = mode.Width, mode.Height
+	}
+
+	// actually create the window with the title (share is nil here)
+	window, err = glfw.CreateWindow(config.Width, config.Height, config.Title, monitor, nil)
+	if err != nil {
+		glfw.Terminate()
+		return nil, errors.Wrap(err, "unable to create the window")
+	}
+	// bind it to this thread
+	window.MakeContextCurrent()
+
+	if config.VSync {
+		glfw.SwapInterval(1)
+	} else {
+		glfw.SwapInterval(0)
+	}
+
+	return window, nil
+}
+
+// initOpenGL loads the openGL functions for the current context
+func initOpenGL(config Config) (err error) {
+	err = gl.Init()
+	if err != nil {
+		return err
+	}
+
+	version := gl.GoStr(gl.GetString(gl.VERSION))
+	log.Printf("OpenGL version: %s\n", version)
+
+	// a new context starts with openGL's default state
+	State.Invalidate()
+
+	if config.Samples > 0 {
+		State.Enable(gl.MULTISAMPLE)
+		if samples := WindowSamples(); samples < config.Samples {
+			log.Printf("Asked for %d samples but the window has %d (the driver supports up to %d)", config.Samples, samples, MaxSamples())
+		}
+	}
+
+	initDebug(config)
+	bufferStorage = glfw.ExtensionSupported("GL_ARB_buffer_storage")
+
+	return nil
+}
+
+// glfwBool converts a go bool to the int glfw uses for hints
+func glfwBool(b bool) int {
+	if b {
+		return glfw.True
+	}
+	return glfw.False
+}
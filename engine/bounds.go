@@ -0,0 +1,149 @@
+package engine
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Bounded is anything that knows the space it takes up, like MeshBuffers. The renderer uses it to
+// skip what the camera can't see
+type Bounded interface {
+	Bounds() AABB
+	BoundingSphere() Sphere
+}
+
+// AABB is a box lined up with the axes that something fits in
+type AABB struct {
+	Min mgl32.Vec3
+	Max mgl32.Vec3
+}
+
+// NewAABB returns the smallest box around the points. It is empty (Min above Max) when there are
+// none
+func NewAABB(points []mgl32.Vec3) (b AABB) {
+	inf := float32(math.Inf(1))
+	b = AABB{Min: mgl32.Vec3{inf, inf, inf}, Max: mgl32.Vec3{-inf, -inf, -inf}}
+	for _, p := range points {
+		b = b.Extend(p)
+	}
+	return b
+}
+
+// Empty reports whether the box has nothing in it
+func (b AABB) Empty() bool {
+	return b.Min.X() > b.Max.X() || b.Min.Y() > b.Max.Y() || b.Min.Z() > b.Max.Z()
+}
+
+// Center returns the middle of the box
+func (b AABB) Center() mgl32.Vec3 {
+	return b.Min.Add(b.Max).Mul(0.5)
+}
+
+// Extents returns half the size of the box along each axis
+func (b AABB) Extents() mgl32.Vec3 {
+	return b.Max.Sub(b.Min).Mul(0.5)
+}
+
+// Extend returns the box grown to hold the point
+func (b AABB) Extend(p mgl32.Vec3) AABB {
+	for i := 0; i < 3; i++ {
+		b.Min[i] = float32(math.Min(float64(b.Min[i]), float64(p[i])))
+		b.Max[i] = float32(math.Max(float64(b.Max[i]), float64(p[i])))
+	}
+	return b
+}
+
+// Union returns the box around both boxes
+func (b AABB) Union(o AABB) AABB {
+	if o.Empty() {
+		return b
+	}
+	return b.Extend(o.Min).Extend(o.Max)
+}
+
+// Contains reports whether the point is inside the box or on its surface
+func (b AABB) Contains(p mgl32.Vec3) bool {
+	for i := 0; i < 3; i++ {
+		if p[i] < b.Min[i] || p[i] > b.Max[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Corners returns the 8 corners of the box
+func (b AABB) Corners() (corners [8]mgl32.Vec3) {
+	for i := range corners {
+		for axis := 0; axis < 3; axis++ {
+			corners[i][axis] = b.Min[axis]
+			if i&(1<<uint(axis)) != 0 {
+				corners[i][axis] = b.Max[axis]
+			}
+		}
+	}
+	return corners
+}
+
+// Transform returns the box around this one once it has been moved by m. Rotated boxes don't line
+// up with the axes anymore so the new box is usually a little bigger. Each axis of the result is
+// built from how far every column of m can stretch it (Arvo's method), which is cheaper than
+// transforming all 8 corners
+func (b AABB) Transform(m mgl32.Mat4) (t AABB) {
+	if b.Empty() {
+		return b
+	}
+	translation := m.Col(3).Vec3()
+	t.Min, t.Max = translation, translation
+	for row := 0; row < 3; row++ {
+		for column := 0; column < 3; column++ {
+			e := m.At(row, column)
+			low, high := e*b.Min[column], e*b.Max[column]
+			if low > high {
+				low, high = high, low
+			}
+			t.Min[row] += low
+			t.Max[row] += high
+		}
+	}
+	return t
+}
+
+// Sphere is a ball that something fits in
+type Sphere struct {
+	Center mgl32.Vec3
+	Radius float32
+}
+
+// NewBoundingSphere returns a sphere around the points. It is centered on the box around them, which
+// isn't always the smallest sphere but is close and quick to find
+func NewBoundingSphere(points []mgl32.Vec3) (s Sphere) {
+	if len(points) == 0 {
+		return s
+	}
+	s.Center = NewAABB(points).Center()
+	for _, p := range points {
+		s.Radius = float32(math.Max(float64(s.Radius), float64(p.Sub(s.Center).Len())))
+	}
+	return s
+}
+
+// Transform returns the sphere once it has been moved by m. The radius grows with the largest scale
+// in m so the sphere still holds everything when it is scaled unevenly
+func (s Sphere) Transform(m mgl32.Mat4) Sphere {
+	scale := math.Max(float64(m.Col(0).Vec3().Len()), math.Max(float64(m.Col(1).Vec3().Len()), float64(m.Col(2).Vec3().Len())))
+	return Sphere{
+		Center: m.Mul4x1(s.Center.Vec4(1)).Vec3(),
+		Radius: s.Radius * float32(scale),
+	}
+}
+
+// Bounds returns the box around the mesh's positions
+func (m *Mesh) Bounds() AABB {
+	return NewAABB(m.Positions)
+}
+
+// BoundingSphere returns a sphere around the mesh's positions
+func (m *Mesh) BoundingSphere() Sphere {
+	return NewBoundingSphere(m.Positions)
+}
@@ -0,0 +1,297 @@
+package engine
+
+import (
+	"reflect"
+	"time"
+	"unsafe"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/pkg/errors"
+)
+
+// bufferStorage is set when the driver supports ARB_buffer_storage so ring buffers can stay mapped
+var bufferStorage bool
+
+// Buffer is a buffer object that remembers how big it is so its data can be replaced or patched
+// after it is created. It grows to fit whatever is written to it. The usage hints how often the
+// data changes: gl.STATIC_DRAW when it is written once, gl.DYNAMIC_DRAW when it is rewritten now
+// and then and gl.STREAM_DRAW when it is rewritten every frame
+type Buffer struct {
+	ID     uint32
+	Target uint32
+	Usage  uint32
+	// Size is how many bytes the buffer has room for
+	Size int
+}
+
+// NewBuffer creates a buffer for target (gl.ARRAY_BUFFER, gl.ELEMENT_ARRAY_BUFFER, ...) with room
+// for size bytes
+func NewBuffer(target uint32, size int, usage uint32) (b *Buffer) {
+	b = &Buffer{
+		ID:     GenBuffer(),
+		Target: target,
+		Usage:  usage,
+		Size:   size,
+	}
+	b.bindForWrite()
+	Backend.BufferData(gl.COPY_WRITE_BUFFER, size, nil, usage)
+	CheckError("NewBuffer")
+	return b
+}
+
+// NewBufferData creates a buffer for target holding data, a slice
+func NewBufferData(target uint32, data interface{}, usage uint32) (b *Buffer) {
+	b = &Buffer{
+		ID:     GenBuffer(),
+		Target: target,
+		Usage:  usage,
+		Size:   byteSize(data),
+	}
+	b.bindForWrite()
+	Backend.BufferData(gl.COPY_WRITE_BUFFER, b.Size, data, usage)
+	CheckError("NewBufferData")
+	return b
+}
+
+// Bind binds the buffer to its target
+func (b *Buffer) Bind() {
+	State.BindBuffer(b.Target, b.ID)
+}
+
+// bindForWrite binds the buffer to gl.COPY_WRITE_BUFFER to change its data. Binding an element
+// buffer to its own target would swap out the element buffer of whatever vao is bound
+func (b *Buffer) bindForWrite() {
+	State.BindBuffer(gl.COPY_WRITE_BUFFER, b.ID)
+}
+
+// SetData replaces the contents of the buffer with data, a slice, growing it if it doesn't fit.
+// The old storage is orphaned first so we don't wait on draws that are still reading it
+func (b *Buffer) SetData(data interface{}) {
+	size := byteSize(data)
+	b.bindForWrite()
+	if size > b.Size {
+		b.Size = grownSize(b.Size, size)
+	}
+	Backend.BufferData(gl.COPY_WRITE_BUFFER, b.Size, nil, b.Usage)
+	if size > 0 {
+		Backend.BufferSubData(gl.COPY_WRITE_BUFFER, 0, size, data)
+	}
+	CheckError("Buffer.SetData")
+}
+
+// SubData writes data, a slice, offset bytes into the buffer and leaves the rest of it alone. The
+// buffer grows, keeping what is already in it, when data runs past the end
+func (b *Buffer) SubData(offset int, data interface{}) {
+	size := byteSize(data)
+	if size == 0 {
+		return
+	}
+	b.Grow(offset + size)
+	b.bindForWrite()
+	Backend.BufferSubData(gl.COPY_WRITE_BUFFER, offset, size, data)
+	CheckError("Buffer.SubData")
+}
+
+// Orphan hands the buffer new storage of the same size so it can be rewritten without waiting on
+// draws still reading the old data. What was in it is gone
+func (b *Buffer) Orphan() {
+	b.bindForWrite()
+	Backend.BufferData(gl.COPY_WRITE_BUFFER, b.Size, nil, b.Usage)
+	CheckError("Buffer.Orphan")
+}
+
+// Grow makes room for at least size bytes and keeps what is already in the buffer. The buffer is
+// at least doubled so growing a little at a time doesn't copy it every frame. It keeps its ID so
+// vaos pointing at it still work
+func (b *Buffer) Grow(size int) {
+	if size <= b.Size {
+		return
+	}
+
+	// park the old data in a scratch buffer while the buffer is reallocated
+	scratch := GenBuffer()
+	State.BindBuffer(gl.COPY_READ_BUFFER, scratch)
+	Backend.BufferData(gl.COPY_READ_BUFFER, b.Size, nil, gl.STREAM_COPY)
+	b.bindForWrite()
+	Backend.CopyBufferSubData(gl.COPY_WRITE_BUFFER, gl.COPY_READ_BUFFER, 0, 0, b.Size)
+
+	old := b.Size
+	b.Size = grownSize(b.Size, size)
+	Backend.BufferData(gl.COPY_WRITE_BUFFER, b.Size, nil, b.Usage)
+	Backend.CopyBufferSubData(gl.COPY_READ_BUFFER, gl.COPY_WRITE_BUFFER, 0, 0, old)
+	DeleteBuffer(scratch)
+	CheckError("Buffer.Grow")
+}
+
+// Delete deletes the buffer
+func (b *Buffer) Delete() {
+	DeleteBuffer(b.ID)
+}
+
+// grownSize returns the size to grow a buffer of size bytes to so it holds at least needed
+func grownSize(size, needed int) int {
+	if 2*size < needed {
+		return needed
+	}
+	for size < needed {
+		size *= 2
+	}
+	return size
+}
+
+// byteSize returns how many bytes the slice data takes up. nil takes up none
+func byteSize(data interface{}) int {
+	if data == nil {
+		return 0
+	}
+	v := reflect.ValueOf(data)
+	return v.Len() * int(v.Type().Elem().Size())
+}
+
+// sliceBytes returns the memory behind the slice data as bytes
+func sliceBytes(data interface{}) []byte {
+	if data == nil {
+		return nil
+	}
+	v := reflect.ValueOf(data)
+	if v.Len() == 0 {
+		return nil
+	}
+	return unsafe.Slice((*byte)(unsafe.Pointer(v.Pointer())), byteSize(data))
+}
+
+// RingFrames is how many frames a RingBuffer has sections for: one being written while the GPU
+// can still be reading the two before it
+const RingFrames = 3
+
+// ringFlags map a ring buffer's storage once and keep it mapped while the GPU reads from it.
+// Coherent writes show up without flushing them
+const ringFlags = gl.MAP_WRITE_BIT | gl.MAP_PERSISTENT_BIT | gl.MAP_COHERENT_BIT
+
+// RingBuffer streams data that is rewritten every frame, like particles or debug lines. It is split
+// into a section per frame in flight so the CPU fills one while the GPU is still drawing from the
+// others. When the driver supports ARB_buffer_storage the buffer stays mapped and a write is a
+// plain copy, with a fence on each section so it isn't reused before the GPU is done with it.
+// Otherwise writes go through glBufferSubData and the buffer is orphaned each time it wraps around
+type RingBuffer struct {
+	// ID changes when the ring grows, so bind it again after each Write
+	ID     uint32
+	Target uint32
+	// Size is how many bytes each section has room for
+	Size int
+	// Persistent is set when the buffer is mapped for as long as it lives
+	Persistent bool
+
+	sections int
+	// section is the one being written and written is how many bytes of it are used
+	section int
+	written int
+	mapped  []byte
+	fences  []uintptr
+}
+
+// NewRingBuffer creates a ring for target with sections sections of size bytes, one per frame in
+// flight (usually RingFrames)
+func NewRingBuffer(target uint32, size, sections int) (r *RingBuffer) {
+	r = &RingBuffer{Target: target, sections: sections}
+	r.allocate(size)
+	return r
+}
+
+// allocate creates the buffer with sections of size bytes
+func (r *RingBuffer) allocate(size int) {
+	r.ID = GenBuffer()
+	r.Size = size
+	r.Persistent = bufferStorage
+	r.section, r.written = 0, 0
+	r.fences = make([]uintptr, r.sections)
+
+	total := size * r.sections
+	State.BindBuffer(gl.COPY_WRITE_BUFFER, r.ID)
+	if r.Persistent {
+		Backend.BufferStorage(gl.COPY_WRITE_BUFFER, total, nil, ringFlags)
+		r.mapped = Backend.MapBufferRange(gl.COPY_WRITE_BUFFER, 0, total, ringFlags)
+	} else {
+		Backend.BufferData(gl.COPY_WRITE_BUFFER, total, nil, gl.STREAM_DRAW)
+	}
+	CheckError("NewRingBuffer")
+}
+
+// Write copies data, a slice, into the current section after whatever was written to it already
+// this frame. It returns where the data starts in bytes from the start of the buffer so attributes
+// and draws can be pointed at it. The ring grows when a frame writes more than a section holds
+func (r *RingBuffer) Write(data interface{}) (offset int, err error) {
+	size := byteSize(data)
+	if r.written+size > r.Size {
+		if r.written > 0 {
+			// what has been written this frame would be lost with the old buffer
+			return 0, errors.Errorf("unable to write %d bytes, only %d of the %d in this frame's section are left", size, r.Size-r.written, r.Size)
+		}
+		r.release()
+		r.allocate(grownSize(r.Size, size))
+	}
+
+	offset = r.section*r.Size + r.written
+	if r.Persistent {
+		copy(r.mapped[offset:], sliceBytes(data))
+	} else if size > 0 {
+		State.BindBuffer(gl.COPY_WRITE_BUFFER, r.ID)
+		Backend.BufferSubData(gl.COPY_WRITE_BUFFER, offset, size, data)
+		CheckError("RingBuffer.Write")
+	}
+	r.written += size
+	return offset, nil
+}
+
+// Next finishes this frame's section and moves on to the next one, waiting for the GPU to finish
+// with it first if it is still drawing from it. Call it once a frame after the draws that read
+// what was written
+func (r *RingBuffer) Next() {
+	if r.Persistent {
+		r.fences[r.section] = Backend.FenceSync(gl.SYNC_GPU_COMMANDS_COMPLETE, 0)
+	}
+	r.section = (r.section + 1) % r.sections
+	r.written = 0
+
+	if !r.Persistent {
+		if r.section == 0 {
+			State.BindBuffer(gl.COPY_WRITE_BUFFER, r.ID)
+			Backend.BufferData(gl.COPY_WRITE_BUFFER, r.Size*r.sections, nil, gl.STREAM_DRAW)
+			CheckError("RingBuffer.Next")
+		}
+		return
+	}
+
+	fence := r.fences[r.section]
+	if fence == 0 {
+		return
+	}
+	// wait a second at a time until the GPU is done or the wait fails
+	result := uint32(gl.TIMEOUT_EXPIRED)
+	for result == gl.TIMEOUT_EXPIRED {
+		result = Backend.ClientWaitSync(fence, gl.SYNC_FLUSH_COMMANDS_BIT, uint64(time.Second))
+	}
+	Backend.DeleteSync(fence)
+	r.fences[r.section] = 0
+}
+
+// release unmaps and deletes the buffer and its fences
+func (r *RingBuffer) release() {
+	for i, fence := range r.fences {
+		if fence != 0 {
+			Backend.DeleteSync(fence)
+			r.fences[i] = 0
+		}
+	}
+	if r.Persistent {
+		State.BindBuffer(gl.COPY_WRITE_BUFFER, r.ID)
+		Backend.UnmapBuffer(gl.COPY_WRITE_BUFFER)
+		r.mapped = nil
+	}
+	DeleteBuffer(r.ID)
+}
+
+// Delete deletes the buffer
+func (r *RingBuffer) Delete() {
+	r.release()
+}
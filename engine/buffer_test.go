@@ -0,0 +1,229 @@
+package engine
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// useBufferStorage pretends the driver does or doesn't support ARB_buffer_storage for the test
+func useBufferStorage(t *testing.T, supported bool) {
+	t.Helper()
+	old := bufferStorage
+	t.Cleanup(func() { bufferStorage = old })
+	bufferStorage = supported
+}
+
+func TestBufferGrowKeepsData(t *testing.T) {
+	fake := useFakeGL(t)
+
+	b := NewBufferData(gl.ARRAY_BUFFER, []byte{1, 2, 3, 4}, gl.DYNAMIC_DRAW)
+	id := b.ID
+	fake.Calls = nil
+	b.Grow(6)
+
+	// the data goes out to a scratch buffer and back again after the buffer is reallocated
+	expectCalls(t, fake,
+		"GenBuffers", "BindBuffer", "BufferData", "CopyBufferSubData",
+		"BufferData", "CopyBufferSubData", "DeleteBuffers",
+	)
+	copies := fake.Find("CopyBufferSubData")
+	if copies[0].Args[0] != uint32(gl.COPY_WRITE_BUFFER) || copies[1].Args[0] != uint32(gl.COPY_READ_BUFFER) {
+		t.Errorf("copied %v, want out of the buffer and then back into it", copies)
+	}
+	if b.ID != id || b.Size != 8 {
+		t.Errorf("buffer is %d with %d bytes, want %d with 8", b.ID, b.Size, id)
+	}
+	if got := fake.Contents[b.ID]; !bytes.Equal(got, []byte{1, 2, 3, 4, 0, 0, 0, 0}) {
+		t.Errorf("buffer holds %v after growing, want what it had before", got)
+	}
+	if alive := Resources.Counts()[ResourceBuffer]; alive != 1 {
+		t.Errorf("%d buffers are alive, want the scratch buffer deleted", alive)
+	}
+
+	// growing well past double jumps straight to the size needed and smaller sizes do nothing
+	b.Grow(100)
+	if b.Size != 100 || !bytes.Equal(fake.Contents[b.ID][:4], []byte{1, 2, 3, 4}) {
+		t.Errorf("buffer has %d bytes starting %v, want 100 starting with the old data", b.Size, fake.Contents[b.ID][:4])
+	}
+	fake.Calls = nil
+	b.Grow(50)
+	if len(fake.Calls) != 0 || b.Size != 100 {
+		t.Errorf("growing to a smaller size made %v and left %d bytes", fake.Calls, b.Size)
+	}
+}
+
+func TestBufferSubDataGrows(t *testing.T) {
+	fake := useFakeGL(t)
+
+	b := NewBufferData(gl.ARRAY_BUFFER, []float32{1, 2}, gl.DYNAMIC_DRAW)
+	b.SubData(4, []float32{3, 4, 5})
+	if b.Size != 16 {
+		t.Errorf("buffer has %d bytes, want it doubled to 16", b.Size)
+	}
+	expectFloats(t, fake.Contents[b.ID], map[int]float32{0: 1, 4: 3, 8: 4, 12: 5})
+
+	// writing past the end of a big enough buffer patches it in place
+	fake.Calls = nil
+	b.SubData(12, []float32{6})
+	expectCalls(t, fake, "BufferSubData")
+	expectFloats(t, fake.Contents[b.ID], map[int]float32{0: 1, 4: 3, 8: 4, 12: 6})
+
+	fake.Calls = nil
+	b.SubData(0, nil)
+	b.SubData(0, []float32{})
+	if len(fake.Calls) != 0 {
+		t.Errorf("writing nothing made %v", fake.Calls)
+	}
+}
+
+func TestBufferSetDataNil(t *testing.T) {
+	fake := useFakeGL(t)
+
+	b := NewBuffer(gl.ARRAY_BUFFER, 16, gl.STREAM_DRAW)
+	fake.Calls = nil
+	b.SetData(nil)
+	// the buffer is still orphaned but there is nothing to write
+	expectCalls(t, fake, "BufferData")
+	if b.Size != 16 {
+		t.Errorf("buffer has %d bytes, want 16", b.Size)
+	}
+	if size := byteSize(nil); size != 0 {
+		t.Errorf("nil takes up %d bytes, want 0", size)
+	}
+}
+
+func TestRingBufferWriteOffsets(t *testing.T) {
+	for _, persistent := range []bool{false, true} {
+		fake := useFakeGL(t)
+		useBufferStorage(t, persistent)
+
+		r := NewRingBuffer(gl.ARRAY_BUFFER, 16, 3)
+		if r.Persistent != persistent {
+			t.Fatalf("ring is persistent %v, want %v", r.Persistent, persistent)
+		}
+		// contents reads what the GPU would see at the offset
+		contents := func(offset int) []byte {
+			if persistent {
+				return fake.Mapped[r.ID][offset:]
+			}
+			return fake.Contents[r.ID][offset:]
+		}
+
+		var offsets []int
+		write := func(data []byte) {
+			t.Helper()
+			offset, err := r.Write(data)
+			if err != nil {
+				t.Fatalf("persistent %v: unable to write: %v", persistent, err)
+			}
+			if got := contents(offset)[:len(data)]; !bytes.Equal(got, data) {
+				t.Errorf("persistent %v: wrote %v at %d but it holds %v", persistent, data, offset, got)
+			}
+			offsets = append(offsets, offset)
+		}
+
+		// each frame starts at the start of its own section and writes follow on from each other
+		write([]byte{1, 2, 3, 4, 5, 6, 7, 8})
+		write([]byte{9, 10})
+		r.Next()
+		write([]byte{11, 12, 13, 14})
+		r.Next()
+		write([]byte{15})
+		r.Next()
+		write([]byte{16, 17})
+		if want := []int{0, 8, 16, 32, 0}; !reflect.DeepEqual(offsets, want) {
+			t.Errorf("persistent %v: writes went to %v, want %v", persistent, offsets, want)
+		}
+		r.Delete()
+	}
+}
+
+func TestRingBufferOrphansWhenItWraps(t *testing.T) {
+	fake := useFakeGL(t)
+	useBufferStorage(t, false)
+
+	r := NewRingBuffer(gl.ARRAY_BUFFER, 16, 3)
+	defer r.Delete()
+	fake.Calls = nil
+	r.Next()
+	r.Next()
+	if data := fake.Find("BufferData"); len(data) != 0 {
+		t.Errorf("orphaned the ring before it wrapped: %v", data)
+	}
+	r.Next()
+	if data := fake.Find("BufferData"); len(data) != 1 || data[0].Args[1] != 48 {
+		t.Errorf("wrapping around made %v, want one BufferData of all 48 bytes", data)
+	}
+}
+
+func TestRingBufferFrameOverflow(t *testing.T) {
+	useFakeGL(t)
+	useBufferStorage(t, true)
+
+	r := NewRingBuffer(gl.ARRAY_BUFFER, 16, 3)
+	defer r.Delete()
+	if _, err := r.Write(make([]byte, 12)); err != nil {
+		t.Fatalf("unable to write: %v", err)
+	}
+	id := r.ID
+	_, err := r.Write(make([]byte, 8))
+	if err == nil || !strings.Contains(err.Error(), "only 4 of the 16") {
+		t.Errorf("overflowing the section gave %v", err)
+	}
+	if r.ID != id || r.Size != 16 {
+		t.Errorf("a failed write replaced the ring with %d of %d bytes", r.ID, r.Size)
+	}
+
+	// the first write of a frame can grow the ring because nothing in it would be lost
+	r.Next()
+	offset, err := r.Write(make([]byte, 40))
+	if err != nil {
+		t.Fatalf("unable to grow the ring: %v", err)
+	}
+	if r.ID == id || r.Size != 40 || offset != 0 || len(r.mapped) != 120 {
+		t.Errorf("ring is %d with sections of %d and %d bytes mapped, writing at %d, want a new ring of 40 byte sections", r.ID, r.Size, len(r.mapped), offset)
+	}
+}
+
+func TestRingBufferFenceWaits(t *testing.T) {
+	fake := useFakeGL(t)
+	useBufferStorage(t, true)
+
+	r := NewRingBuffer(gl.ARRAY_BUFFER, 16, 3)
+	defer r.Delete()
+	fake.Calls = nil
+
+	// the first time around there is nothing to wait for
+	r.Next()
+	r.Next()
+	if waits := fake.Find("ClientWaitSync"); len(waits) != 0 {
+		t.Errorf("waited on sections that were never drawn from: %v", waits)
+	}
+	fences := fake.Find("FenceSync")
+	if len(fences) != 2 {
+		t.Fatalf("fenced %d sections, want 2", len(fences))
+	}
+
+	// back at the first section the GPU is still busy for a while. Next keeps waiting on the
+	// fence it put on the section and then deletes it
+	fence := r.fences[0]
+	fake.SyncTimeouts = 2
+	r.Next()
+	waits := fake.Find("ClientWaitSync")
+	if len(waits) != 3 {
+		t.Fatalf("waited %d times, want 3", len(waits))
+	}
+	for _, w := range waits {
+		if w.Args[0] != fence {
+			t.Errorf("waited on %v, want the first section's fence %v", w.Args[0], fence)
+		}
+	}
+	deleted := fake.Find("DeleteSync")
+	if len(deleted) != 1 || deleted[0].Args[0] != fence || r.fences[0] != 0 {
+		t.Errorf("deleted %v and the first section's fence is %v, want %v deleted", deleted, r.fences[0], fence)
+	}
+}
@@ -0,0 +1,127 @@
+package engine
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Cubemap is a texture with 6 square faces that is looked up by direction instead of position.
+// The faces go +X, -X, +Y, -Y, +Z, -Z like gl.TEXTURE_CUBE_MAP_POSITIVE_X and the ones after it
+type Cubemap struct {
+	ID uint32
+	// Size is the width and height of each face at the top mip level
+	Size int
+	// Levels is how many mip levels the faces have
+	Levels int
+}
+
+// NewCubemap creates an empty half float cubemap with faces size texels across and levels mip
+// levels (1 for no mipmaps). It has to be called on the main thread
+func NewCubemap(size, levels int) (c *Cubemap) {
+	c = &Cubemap{
+		Size:   size,
+		Levels: levels,
+	}
+
+	Backend.GenTextures(1, &c.ID)
+	Resources.Track(ResourceTexture, c.ID)
+	c.Bind(gl.TEXTURE0)
+
+	minFilter := int32(gl.LINEAR)
+	if levels > 1 {
+		minFilter = gl.LINEAR_MIPMAP_LINEAR
+	}
+	Backend.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MIN_FILTER, minFilter)
+	Backend.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	Backend.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	Backend.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	Backend.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_R, gl.CLAMP_TO_EDGE)
+	Backend.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MAX_LEVEL, int32(levels-1))
+	for level := 0; level < levels; level++ {
+		faceSize := int32(c.LevelSize(level))
+		for face := uint32(0); face < 6; face++ {
+			Backend.TexImage2D(gl.TEXTURE_CUBE_MAP_POSITIVE_X+face, int32(level), gl.RGB16F, faceSize, faceSize, gl.RGB, gl.FLOAT, nil)
+		}
+	}
+	CheckError("NewCubemap")
+
+	return c
+}
+
+// LevelSize returns the width and height of the faces at a mip level
+func (c *Cubemap) LevelSize(level int) int {
+	size := c.Size >> uint(level)
+	if size < 1 {
+		return 1
+	}
+	return size
+}
+
+// GenerateMipmaps fills in every mip level below the top one from the top one
+func (c *Cubemap) GenerateMipmaps() {
+	c.Bind(gl.TEXTURE0)
+	Backend.GenerateMipmap(gl.TEXTURE_CUBE_MAP)
+	CheckError("Cubemap.GenerateMipmaps")
+}
+
+// Bind binds the cubemap to a texture unit (gl.TEXTURE0, gl.TEXTURE1, ...)
+func (c *Cubemap) Bind(unit uint32) {
+	State.BindTexture(unit, gl.TEXTURE_CUBE_MAP, c.ID)
+}
+
+// Delete deletes the cubemap
+func (c *Cubemap) Delete() {
+	Backend.DeleteTextures(1, &c.ID)
+	State.forgetTexture(gl.TEXTURE_CUBE_MAP, c.ID)
+	Resources.Untrack(ResourceTexture, c.ID)
+}
+
+// cubeFaceViews look from the middle of a cube at each face in the order of the faces. The up
+// vectors are upside down because cubemap faces are laid out with v going down
+var cubeFaceViews = [6]mgl32.Mat4{
+	mgl32.LookAtV(mgl32.Vec3{}, mgl32.Vec3{1, 0, 0}, mgl32.Vec3{0, -1, 0}),
+	mgl32.LookAtV(mgl32.Vec3{}, mgl32.Vec3{-1, 0, 0}, mgl32.Vec3{0, -1, 0}),
+	mgl32.LookAtV(mgl32.Vec3{}, mgl32.Vec3{0, 1, 0}, mgl32.Vec3{0, 0, 1}),
+	mgl32.LookAtV(mgl32.Vec3{}, mgl32.Vec3{0, -1, 0}, mgl32.Vec3{0, 0, -1}),
+	mgl32.LookAtV(mgl32.Vec3{}, mgl32.Vec3{0, 0, 1}, mgl32.Vec3{0, -1, 0}),
+	mgl32.LookAtV(mgl32.Vec3{}, mgl32.Vec3{0, 0, -1}, mgl32.Vec3{0, -1, 0}),
+}
+
+// cubeFaceProjection sees exactly one face of the cube from its middle
+var cubeFaceProjection = mgl32.Perspective(mgl32.DegToRad(90), 1, 0.1, 10)
+
+// cubePositions are the 36 corners of the 12 triangles of a cube from -1 to 1 wound counter
+// clockwise from the outside
+var cubePositions = []float32{
+	// -Z
+	-1, -1, -1, -1, 1, -1, 1, 1, -1,
+	1, 1, -1, 1, -1, -1, -1, -1, -1,
+	// +Z
+	-1, -1, 1, 1, -1, 1, 1, 1, 1,
+	1, 1, 1, -1, 1, 1, -1, -1, 1,
+	// -X
+	-1, 1, 1, -1, 1, -1, -1, -1, -1,
+	-1, -1, -1, -1, -1, 1, -1, 1, 1,
+	// +X
+	1, 1, 1, 1, -1, 1, 1, -1, -1,
+	1, -1, -1, 1, 1, -1, 1, 1, 1,
+	// -Y
+	-1, -1, -1, 1, -1, -1, 1, -1, 1,
+	1, -1, 1, -1, -1, 1, -1, -1, -1,
+	// +Y
+	-1, 1, -1, -1, 1, 1, 1, 1, 1,
+	1, 1, 1, 1, 1, -1, -1, 1, -1,
+}
+
+// newCubeVAO loads cubePositions into a vao with the positions in attribute 0
+func newCubeVAO() (vao, vbo uint32) {
+	vao = GenVertexArray()
+	State.BindVertexArray(vao)
+	vbo = GenBuffer()
+	State.BindBuffer(gl.ARRAY_BUFFER, vbo)
+	Backend.BufferData(gl.ARRAY_BUFFER, 4*len(cubePositions), cubePositions, gl.STATIC_DRAW)
+	Backend.VertexAttribPointer(0, 3, gl.FLOAT, false, 3*4, 0)
+	Backend.EnableVertexAttribArray(0)
+	CheckError("newCubeVAO")
+	return vao, vbo
+}
@@ -0,0 +1,181 @@
+package engine
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"runtime"
+	"unsafe"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+// DebugSeverity is how serious an openGL debug message is
+type DebugSeverity int
+
+// The debug severities from least to most serious
+const (
+	DebugNotification DebugSeverity = iota
+	DebugLow
+	DebugMedium
+	DebugHigh
+)
+
+// String returns the name of the severity
+func (s DebugSeverity) String() string {
+	switch s {
+	case DebugNotification:
+		return "notification"
+	case DebugLow:
+		return "low"
+	case DebugMedium:
+		return "medium"
+	case DebugHigh:
+		return "high"
+	}
+	return fmt.Sprintf("DebugSeverity(%d)", int(s))
+}
+
+var (
+	// debugLabels is set when the driver supports KHR_debug so objects can be labeled
+	debugLabels bool
+	// checkErrors is set in debug mode when the driver can't report errors itself so CheckError
+	// has to ask for them
+	checkErrors bool
+)
+
+// initDebug turns on debug output for the current context. Drivers with KHR_debug or
+// ARB_debug_output report problems as they happen through a callback, everything else
+// (like macOS) falls back to checking glGetError after each call the engine wraps
+func initDebug(config Config) {
+	debugLabels, checkErrors = false, false
+	Resources.Traces = config.Debug
+	if !config.Debug {
+		return
+	}
+
+	callback := func(source, gltype, id, severity uint32, length int32, message string, userParam unsafe.Pointer) {
+		level := debugSeverity(severity)
+		if level < config.DebugSeverity {
+			return
+		}
+		log.Printf("GL %s %s (%s, id %d): %s", level, debugTypeName(gltype), debugSourceName(source), id, message)
+	}
+
+	switch {
+	case glfw.ExtensionSupported("GL_KHR_debug"):
+		// in a core profile the KHR_debug functions don't have a suffix
+		State.Enable(gl.DEBUG_OUTPUT)
+		State.Enable(gl.DEBUG_OUTPUT_SYNCHRONOUS)
+		gl.DebugMessageCallback(callback, nil)
+		debugLabels = true
+		log.Printf("OpenGL debug output enabled with KHR_debug")
+	case glfw.ExtensionSupported("GL_ARB_debug_output"):
+		// ARB_debug_output is always on in a debug context, we only need to make it synchronous
+		State.Enable(gl.DEBUG_OUTPUT_SYNCHRONOUS_ARB)
+		gl.DebugMessageCallbackARB(callback, nil)
+		log.Printf("OpenGL debug output enabled with ARB_debug_output")
+	default:
+		checkErrors = true
+		log.Printf("OpenGL debug output isn't supported, checking glGetError instead")
+	}
+}
+
+// CheckError logs any openGL errors raised since the last check along with the file and line
+// that called the wrapped function. call is the name of the function being checked. It only does
+// anything in debug mode when the driver can't report errors itself, so it is cheap to leave in
+func CheckError(call string) {
+	if !checkErrors {
+		return
+	}
+
+	for code := Backend.GetError(); code != gl.NO_ERROR; code = Backend.GetError() {
+		// skip CheckError and the function that wraps the call to find where it was called from
+		_, file, line, ok := runtime.Caller(2)
+		if !ok {
+			file, line = "unknown", 0
+		}
+		log.Printf("GL error %s in %s called from %s:%d", errorName(code), call, filepath.Base(file), line)
+	}
+}
+
+// Label names an openGL object so it shows up by name in debug messages and tools like RenderDoc.
+// identifier is the kind of object (gl.VERTEX_ARRAY, gl.BUFFER, gl.TEXTURE, gl.PROGRAM, ...).
+// It does nothing unless the driver supports KHR_debug
+func Label(identifier, name uint32, label string) {
+	if !debugLabels || label == "" {
+		return
+	}
+	Backend.ObjectLabel(identifier, name, label)
+}
+
+// debugSeverity converts the openGL severity to a DebugSeverity
+func debugSeverity(severity uint32) DebugSeverity {
+	switch severity {
+	case gl.DEBUG_SEVERITY_HIGH:
+		return DebugHigh
+	case gl.DEBUG_SEVERITY_MEDIUM:
+		return DebugMedium
+	case gl.DEBUG_SEVERITY_LOW:
+		return DebugLow
+	}
+	return DebugNotification
+}
+
+// debugTypeName returns a readable name for the type of a debug message
+func debugTypeName(gltype uint32) string {
+	switch gltype {
+	case gl.DEBUG_TYPE_ERROR:
+		return "error"
+	case gl.DEBUG_TYPE_DEPRECATED_BEHAVIOR:
+		return "deprecated behavior"
+	case gl.DEBUG_TYPE_UNDEFINED_BEHAVIOR:
+		return "undefined behavior"
+	case gl.DEBUG_TYPE_PORTABILITY:
+		return "portability"
+	case gl.DEBUG_TYPE_PERFORMANCE:
+		return "performance"
+	case gl.DEBUG_TYPE_MARKER:
+		return "marker"
+	}
+	return "message"
+}
+
+// debugSourceName returns a readable name for where a debug message came from
+func debugSourceName(source uint32) string {
+	switch source {
+	case gl.DEBUG_SOURCE_API:
+		return "api"
+	case gl.DEBUG_SOURCE_WINDOW_SYSTEM:
+		return "window system"
+	case gl.DEBUG_SOURCE_SHADER_COMPILER:
+		return "shader compiler"
+	case gl.DEBUG_SOURCE_THIRD_PARTY:
+		return "third party"
+	case gl.DEBUG_SOURCE_APPLICATION:
+		return "application"
+	}
+	return "other"
+}
+
+// errorName returns the name of an openGL error code
+func errorName(code uint32) string {
+	switch code {
+	case gl.INVALID_ENUM:
+		return "GL_INVALID_ENUM"
+	case gl.INVALID_VALUE:
+		return "GL_INVALID_VALUE"
+	case gl.INVALID_OPERATION:
+		return "GL_INVALID_OPERATION"
+	case gl.INVALID_FRAMEBUFFER_OPERATION:
+		return "GL_INVALID_FRAMEBUFFER_OPERATION"
+	case gl.OUT_OF_MEMORY:
+		return "GL_OUT_OF_MEMORY"
+	case gl.STACK_OVERFLOW:
+		return "GL_STACK_OVERFLOW"
+	case gl.STACK_UNDERFLOW:
+		return "GL_STACK_UNDERFLOW"
+	}
+	return fmt.Sprintf("0x%x", code)
+}
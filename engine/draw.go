@@ -0,0 +1,85 @@
+package engine
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// DrawStats counts the draw calls made in a frame
+type DrawStats struct {
+	Calls     int
+	Triangles int
+}
+
+// DrawCounter counts the draws that go through DrawArrays, DrawElements and their instanced
+// versions
+type DrawCounter struct {
+	// Frame counts the draws for the frame being drawn and LastFrame holds the counts for the
+	// frame before it
+	Frame     DrawStats
+	LastFrame DrawStats
+}
+
+// Draws counts the draws made in the app's openGL context
+var Draws = &DrawCounter{}
+
+// EndFrame moves this frame's counts to LastFrame and starts counting again
+func (d *DrawCounter) EndFrame() {
+	d.LastFrame = d.Frame
+	d.Frame = DrawStats{}
+}
+
+// count adds a draw of count vertices
+func (d *DrawCounter) count(mode uint32, count int32) {
+	d.countInstances(mode, count, 1)
+}
+
+// countInstances adds one draw of instances copies of count vertices
+func (d *DrawCounter) countInstances(mode uint32, count, instances int32) {
+	d.Frame.Calls++
+	d.Frame.Triangles += triangles(mode, int(count)) * int(instances)
+}
+
+// DrawArrays draws count vertices from the bound vao starting at first
+func DrawArrays(mode uint32, first, count int32) {
+	Backend.DrawArrays(mode, first, count)
+	CheckError("glDrawArrays")
+	Draws.count(mode, count)
+}
+
+// DrawElements draws count indices from the bound vao's element buffer. xtype is the type of the
+// indices (gl.UNSIGNED_INT, ...) and offset is where they start in bytes
+func DrawElements(mode uint32, count int32, xtype uint32, offset int) {
+	Backend.DrawElements(mode, count, xtype, offset)
+	CheckError("glDrawElements")
+	Draws.count(mode, count)
+}
+
+// DrawArraysInstanced draws instances copies of count vertices from the bound vao starting at
+// first. Attributes with a divisor step once per instance instead of once per vertex
+func DrawArraysInstanced(mode uint32, first, count, instances int32) {
+	Backend.DrawArraysInstanced(mode, first, count, instances)
+	CheckError("glDrawArraysInstanced")
+	Draws.countInstances(mode, count, instances)
+}
+
+// DrawElementsInstanced draws instances copies of count indices from the bound vao's element
+// buffer. xtype and offset are the same as DrawElements
+func DrawElementsInstanced(mode uint32, count int32, xtype uint32, offset int, instances int32) {
+	Backend.DrawElementsInstanced(mode, count, xtype, offset, instances)
+	CheckError("glDrawElementsInstanced")
+	Draws.countInstances(mode, count, instances)
+}
+
+// triangles returns how many triangles count vertices make with the primitive mode
+func triangles(mode uint32, count int) int {
+	switch mode {
+	case gl.TRIANGLES:
+		return count / 3
+	case gl.TRIANGLE_STRIP, gl.TRIANGLE_FAN:
+		if count < 3 {
+			return 0
+		}
+		return count - 2
+	}
+	return 0
+}
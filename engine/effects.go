@@ -0,0 +1,406 @@
+package engine
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// TonemapOperator picks the curve NewTonemapEffect squeezes bright colors into 0-1 with
+type TonemapOperator int
+
+const (
+	// TonemapACES is the filmic curve from the Academy Color Encoding System. It has contrast and
+	// keeps saturated highlights from turning white too early
+	TonemapACES TonemapOperator = iota
+	// TonemapReinhard divides by one more than the color. It is flat but never clips
+	TonemapReinhard
+)
+
+// tonemapCurves are the GLSL for each operator. They take and return linear light
+var tonemapCurves = map[TonemapOperator]string{
+	TonemapACES: `
+	vec3 curve(vec3 color) {
+		return clamp((color * (2.51 * color + 0.03)) / (color * (2.43 * color + 0.59) + 0.14), 0.0, 1.0);
+	}
+`,
+	TonemapReinhard: `
+	vec3 curve(vec3 color) {
+		return color / (1.0 + color);
+	}
+`,
+}
+
+// NewTonemapEffect creates the "tonemap" effect. It scales the scene by its exposure param and
+// brings it into 0-1 with the operator. It leaves the colors linear so follow it with gamma
+func NewTonemapEffect(operator TonemapOperator) (e *Effect, err error) {
+	e, err = NewEffect("tonemap", `
+	#version 410
+`+EffectGLSL+`
+	uniform float exposure;
+`+tonemapCurves[operator]+`
+	void main() {
+		vec4 color = texture(scene, fragTexCoord);
+		outputColor = vec4(curve(color.rgb * exposure), color.a);
+	}
+`+"\x00")
+	if err != nil {
+		return nil, err
+	}
+	e.Params["exposure"] = 1
+	return e, nil
+}
+
+// gammaFragShaderSrc encodes linear colors for the screen
+var gammaFragShaderSrc = `
+	#version 410
+` + EffectGLSL + `
+	uniform float gamma;
+
+	void main() {
+		vec4 color = texture(scene, fragTexCoord);
+		outputColor = vec4(pow(max(color.rgb, 0.0), vec3(1.0 / gamma)), color.a);
+	}
+` + "\x00"
+
+// NewGammaEffect creates the "gamma" effect, which encodes linear colors for the screen with its
+// gamma param (2.2 to start)
+func NewGammaEffect() (e *Effect, err error) {
+	e, err = NewEffect("gamma", gammaFragShaderSrc)
+	if err != nil {
+		return nil, err
+	}
+	e.Params["gamma"] = 2.2
+	return e, nil
+}
+
+// fxaaFragShaderSrc is the small version of Timothy Lottes' FXAA. It finds edges from the
+// brightness of the corners around each pixel and blurs along them
+var fxaaFragShaderSrc = `
+	#version 410
+` + EffectGLSL + `
+	#define FXAA_REDUCE_MIN (1.0 / 128.0)
+	#define FXAA_REDUCE_MUL (1.0 / 8.0)
+	#define FXAA_SPAN_MAX 8.0
+
+	void main() {
+		vec3 rgbNW = texture(scene, fragTexCoord + vec2(-1.0, -1.0) * texelSize).rgb;
+		vec3 rgbNE = texture(scene, fragTexCoord + vec2(1.0, -1.0) * texelSize).rgb;
+		vec3 rgbSW = texture(scene, fragTexCoord + vec2(-1.0, 1.0) * texelSize).rgb;
+		vec3 rgbSE = texture(scene, fragTexCoord + vec2(1.0, 1.0) * texelSize).rgb;
+		vec4 rgbM = texture(scene, fragTexCoord);
+
+		vec3 luma = vec3(0.299, 0.587, 0.114);
+		float lumaNW = dot(rgbNW, luma);
+		float lumaNE = dot(rgbNE, luma);
+		float lumaSW = dot(rgbSW, luma);
+		float lumaSE = dot(rgbSE, luma);
+		float lumaM = dot(rgbM.rgb, luma);
+		float lumaMin = min(lumaM, min(min(lumaNW, lumaNE), min(lumaSW, lumaSE)));
+		float lumaMax = max(lumaM, max(max(lumaNW, lumaNE), max(lumaSW, lumaSE)));
+
+		// the edge runs across the way the brightness changes
+		vec2 dir = vec2(-((lumaNW + lumaNE) - (lumaSW + lumaSE)), (lumaNW + lumaSW) - (lumaNE + lumaSE));
+		float dirReduce = max((lumaNW + lumaNE + lumaSW + lumaSE) * (0.25 * FXAA_REDUCE_MUL), FXAA_REDUCE_MIN);
+		float rcpDirMin = 1.0 / (min(abs(dir.x), abs(dir.y)) + dirReduce);
+		dir = clamp(dir * rcpDirMin, vec2(-FXAA_SPAN_MAX), vec2(FXAA_SPAN_MAX)) * texelSize;
+
+		vec3 rgbA = 0.5 * (
+			texture(scene, fragTexCoord + dir * (1.0 / 3.0 - 0.5)).rgb +
+			texture(scene, fragTexCoord + dir * (2.0 / 3.0 - 0.5)).rgb);
+		vec3 rgbB = rgbA * 0.5 + 0.25 * (
+			texture(scene, fragTexCoord + dir * -0.5).rgb +
+			texture(scene, fragTexCoord + dir * 0.5).rgb);
+
+		// the wide blur went past the edge if it is brighter or darker than anything around us
+		float lumaB = dot(rgbB, luma);
+		if (lumaB < lumaMin || lumaB > lumaMax) {
+			outputColor = vec4(rgbA, rgbM.a);
+		} else {
+			outputColor = vec4(rgbB, rgbM.a);
+		}
+	}
+` + "\x00"
+
+// NewFXAAEffect creates the "fxaa" effect, which smooths jagged edges. It works on the colors the
+// screen shows so put it after tonemap and gamma
+func NewFXAAEffect() (e *Effect, err error) {
+	return NewEffect("fxaa", fxaaFragShaderSrc)
+}
+
+// vignetteFragShaderSrc darkens the corners of the screen
+var vignetteFragShaderSrc = `
+	#version 410
+` + EffectGLSL + `
+	uniform float strength;
+	uniform float radius;
+	uniform float softness;
+
+	void main() {
+		vec4 color = texture(scene, fragTexCoord);
+		// 0 in the middle of the screen and 1 in the corners
+		float fromCenter = length(fragTexCoord - 0.5) * 1.41421356;
+		float vignette = 1.0 - smoothstep(radius - softness, radius, fromCenter);
+		outputColor = vec4(color.rgb * mix(1.0, vignette, strength), color.a);
+	}
+` + "\x00"
+
+// NewVignetteEffect creates the "vignette" effect. Its params are strength (0 for none to 1 for
+// black corners), radius (where the darkening ends, 1 is the corners) and softness (how far in
+// from the radius it starts)
+func NewVignetteEffect() (e *Effect, err error) {
+	e, err = NewEffect("vignette", vignetteFragShaderSrc)
+	if err != nil {
+		return nil, err
+	}
+	e.Params["strength"] = 0.5
+	e.Params["radius"] = 1.1
+	e.Params["softness"] = 0.6
+	return e, nil
+}
+
+// colorGradeFragShaderSrc looks the color up in a 3D table laid out as a strip of 2D slices, one
+// for each step of blue
+var colorGradeFragShaderSrc = `
+	#version 410
+` + EffectGLSL + `
+	uniform sampler2D lut;
+	uniform float lutSize;
+	uniform float strength;
+
+	void main() {
+		vec4 color = texture(scene, fragTexCoord);
+		vec3 c = clamp(color.rgb, 0.0, 1.0);
+
+		// the hardware blends red and green in a slice, we blend the two nearest blue slices
+		float slice = c.b * (lutSize - 1.0);
+		float below = floor(slice);
+		float above = min(below + 1.0, lutSize - 1.0);
+		float x = (c.r * (lutSize - 1.0) + 0.5) / (lutSize * lutSize);
+		float y = (c.g * (lutSize - 1.0) + 0.5) / lutSize;
+		vec3 graded = mix(
+			texture(lut, vec2(x + below / lutSize, y)).rgb,
+			texture(lut, vec2(x + above / lutSize, y)).rgb,
+			slice - below);
+
+		outputColor = vec4(mix(color.rgb, graded, strength), color.a);
+	}
+` + "\x00"
+
+// NewColorGradeEffect creates the "colorGrade" effect, which maps every color through a lookup
+// table made by NewColorGradeLUT (or painted over one in an image editor). Its strength param
+// blends between the original and graded colors. It grades the colors the screen shows so put it
+// after tonemap and gamma
+func NewColorGradeEffect(lut *Texture) (e *Effect, err error) {
+	e, err = NewEffect("colorGrade", colorGradeFragShaderSrc)
+	if err != nil {
+		return nil, err
+	}
+	e.Inputs["lut"] = lut
+	e.Params["lutSize"] = float32(lut.Height)
+	e.Params["strength"] = 1
+	return e, nil
+}
+
+// NewColorGradeLUT makes a lookup table for NewColorGradeEffect with size steps of each channel.
+// grade maps each color (0-1) to its graded color, nil leaves them as they are. The table is
+// size*size wide and size tall: red goes across each slice, green down and blue from slice to slice
+func NewColorGradeLUT(size int, grade func(c mgl32.Vec3) mgl32.Vec3) (rgba *image.RGBA) {
+	rgba = image.NewRGBA(image.Rect(0, 0, size*size, size))
+	step := 1 / float32(size-1)
+	for b := 0; b < size; b++ {
+		for g := 0; g < size; g++ {
+			for r := 0; r < size; r++ {
+				c := mgl32.Vec3{float32(r) * step, float32(g) * step, float32(b) * step}
+				if grade != nil {
+					c = grade(c)
+				}
+				rgba.SetRGBA(b*size+r, g, color.RGBA{
+					R: unitToByte(c.X()),
+					G: unitToByte(c.Y()),
+					B: unitToByte(c.Z()),
+					A: 255,
+				})
+			}
+		}
+	}
+	return rgba
+}
+
+// unitToByte clamps a 0-1 value and scales it to 0-255
+func unitToByte(v float32) uint8 {
+	return uint8(mgl32.Clamp(v, 0, 1)*255 + 0.5)
+}
+
+// bloomBlurPasses is how many times the bright parts are blurred across and then down. More is
+// a wider glow
+const bloomBlurPasses = 5
+
+// bloomThresholdFragShaderSrc keeps only what is brighter than the threshold
+var bloomThresholdFragShaderSrc = `
+	#version 410
+` + EffectGLSL + `
+	uniform float threshold;
+
+	void main() {
+		vec3 color = texture(scene, fragTexCoord).rgb;
+		float brightness = max(color.r, max(color.g, color.b));
+		// fade in above the threshold instead of cutting off so the glow doesn't flicker
+		outputColor = vec4(color * max(brightness - threshold, 0.0) / max(brightness, 1e-4), 1.0);
+	}
+` + "\x00"
+
+// bloomBlurFragShaderSrc is one direction of a separable 9 tap gaussian blur
+var bloomBlurFragShaderSrc = `
+	#version 410
+` + EffectGLSL + `
+	uniform vec2 direction;
+
+	const float weights[5] = float[](0.227027, 0.1945946, 0.1216216, 0.054054, 0.016216);
+
+	void main() {
+		vec2 offset = direction * texelSize;
+		vec3 color = texture(scene, fragTexCoord).rgb * weights[0];
+		for (int i = 1; i < 5; i++) {
+			color += texture(scene, fragTexCoord + offset * float(i)).rgb * weights[i];
+			color += texture(scene, fragTexCoord - offset * float(i)).rgb * weights[i];
+		}
+		outputColor = vec4(color, 1.0);
+	}
+` + "\x00"
+
+// bloomFragShaderSrc adds the blurred bright parts back onto the scene
+var bloomFragShaderSrc = `
+	#version 410
+` + EffectGLSL + `
+	uniform sampler2D bloom;
+	uniform float intensity;
+
+	void main() {
+		vec4 color = texture(scene, fragTexCoord);
+		outputColor = vec4(color.rgb + texture(bloom, fragTexCoord).rgb * intensity, color.a);
+	}
+` + "\x00"
+
+// bloom is the extra passes of the bloom effect. They run at half size since they are blurred
+// anyway
+type bloom struct {
+	effect       *Effect
+	threshold    uint32
+	blur         uint32
+	width        int
+	height       int
+	framebuffers [2]*Framebuffer
+	textures     [2]*Texture
+}
+
+// NewBloomEffect creates the "bloom" effect, which makes anything brighter than its threshold
+// param glow. Its intensity param is how much glow is added. It needs the bright colors so put it
+// before tonemap
+func NewBloomEffect() (e *Effect, err error) {
+	e, err = NewEffect("bloom", bloomFragShaderSrc)
+	if err != nil {
+		return nil, err
+	}
+	b := &bloom{effect: e}
+	b.threshold, err = NewProgram(fullscreenVertexShaderSrc, bloomThresholdFragShaderSrc)
+	if err != nil {
+		e.Delete()
+		return nil, err
+	}
+	b.blur, err = NewProgram(fullscreenVertexShaderSrc, bloomBlurFragShaderSrc)
+	if err != nil {
+		DeleteProgram(b.threshold)
+		e.Delete()
+		return nil, err
+	}
+	Label(gl.PROGRAM, b.threshold, "bloom threshold")
+	Label(gl.PROGRAM, b.blur, "bloom blur")
+
+	e.Params["threshold"] = 1
+	e.Params["intensity"] = 0.6
+	e.prepare = b.prepare
+	e.resize = b.resize
+	e.cleanup = b.delete
+	return e, nil
+}
+
+// resize recreates the half size framebuffers
+func (b *bloom) resize(width, height int) (err error) {
+	b.deleteTargets()
+	b.width, b.height = width/2, height/2
+	if b.width < 1 {
+		b.width = 1
+	}
+	if b.height < 1 {
+		b.height = 1
+	}
+	for i := range b.framebuffers {
+		b.framebuffers[i] = NewFramebuffer(b.width, b.height)
+		b.textures[i] = NewRenderTexture(b.width, b.height, gl.RGBA16F, gl.RGBA)
+		b.framebuffers[i].AttachTexture(gl.COLOR_ATTACHMENT0, b.textures[i])
+		err = b.framebuffers[i].Check()
+		if err != nil {
+			return err
+		}
+	}
+	State.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	return nil
+}
+
+// prepare pulls the bright parts out of the source and blurs them, leaving them in the effect's
+// bloom input
+func (b *bloom) prepare(p *PostProcess, source *Texture) {
+	e := b.effect
+
+	b.framebuffers[0].Bind()
+	State.UseProgram(b.threshold)
+	source.Bind(gl.TEXTURE0)
+	Backend.Uniform1i(Backend.GetUniformLocation(b.threshold, "scene"), 0)
+	Backend.Uniform1f(Backend.GetUniformLocation(b.threshold, "threshold"), e.Params["threshold"])
+	DrawArrays(gl.TRIANGLE_STRIP, 0, 4)
+	b.framebuffers[0].Unbind()
+
+	// ping pong between the two textures, across then down
+	State.UseProgram(b.blur)
+	Backend.Uniform1i(Backend.GetUniformLocation(b.blur, "scene"), 0)
+	Backend.Uniform2f(Backend.GetUniformLocation(b.blur, "texelSize"), 1/float32(b.width), 1/float32(b.height))
+	direction := Backend.GetUniformLocation(b.blur, "direction")
+	for i := 0; i < 2*bloomBlurPasses; i++ {
+		target := (i + 1) % 2
+		b.framebuffers[target].Bind()
+		b.textures[i%2].Bind(gl.TEXTURE0)
+		if i%2 == 0 {
+			Backend.Uniform2f(direction, 1, 0)
+		} else {
+			Backend.Uniform2f(direction, 0, 1)
+		}
+		DrawArrays(gl.TRIANGLE_STRIP, 0, 4)
+		b.framebuffers[target].Unbind()
+	}
+	CheckError("bloom.prepare")
+
+	// an even number of passes always ends back in the first texture
+	e.Inputs["bloom"] = b.textures[0]
+}
+
+// delete deletes the extra programs and framebuffers
+func (b *bloom) delete() {
+	b.deleteTargets()
+	DeleteProgram(b.threshold)
+	DeleteProgram(b.blur)
+}
+
+// deleteTargets deletes the framebuffers and their textures if they have been made
+func (b *bloom) deleteTargets() {
+	for i := range b.framebuffers {
+		if b.framebuffers[i] != nil {
+			b.framebuffers[i].Delete()
+			b.textures[i].Delete()
+		}
+	}
+	b.framebuffers = [2]*Framebuffer{}
+	b.textures = [2]*Texture{}
+}
@@ -0,0 +1,698 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"unsafe"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// Call is one openGL call made to a FakeGL
+type Call struct {
+	Name string
+	Args []interface{}
+}
+
+// String formats the call like go code, for example BindBuffer(34962, 1)
+func (c Call) String() string {
+	args := make([]string, len(c.Args))
+	for i, arg := range c.Args {
+		args[i] = fmt.Sprintf("%v", arg)
+	}
+	return fmt.Sprintf("%s(%s)", c.Name, strings.Join(args, ", "))
+}
+
+// FakeGL is a GL that records every call instead of making it, so engine logic can be checked
+// without a context. It hands out object names counting up from 1, tracks what is bound, and
+// reports shaders and programs as compiled and linked. Set it as the Backend (and invalidate
+// the State cache) before running the code under test:
+//
+//	fake := engine.NewFakeGL()
+//	engine.Backend = fake
+//	engine.State.Invalidate()
+type FakeGL struct {
+	// Calls is every call in the order it was made
+	Calls []Call
+
+	// the state the calls left behind
+	Program       uint32
+	VertexArray   uint32
+	Buffers       map[uint32]uint32
+	ActiveUnit    uint32
+	Textures      map[uint32]uint32
+	Capabilities  map[uint32]bool
+	BufferSizes   map[uint32]int
+	Locations     map[string]int32
+	Uniforms      map[int32]interface{}
+	EnabledArrays map[uint32]bool
+	Labels        map[uint32]string
+	Framebuffers  map[uint32]uint32
+	Attachments   map[uint32]map[uint32]uint32
+
+	// Errors are returned by GetError one at a time before it reports gl.NO_ERROR
+	Errors []uint32
+	// LinkFails makes every program fail to link with this message when it isn't empty
+	LinkFails string
+	// QueryResult is what every query reports once it has ended (nanoseconds for timer queries)
+	QueryResult uint64
+	// BlockSizes is the size every program reports for a uniform block by name. Blocks that
+	// aren't in it don't exist
+	BlockSizes map[string]int32
+	// BlockBindings is the binding point each program's uniform blocks were bound to by block index
+	BlockBindings map[uint32]map[uint32]uint32
+	// BufferBases is the buffer bound to each indexed binding point by target
+	BufferBases map[uint32]map[uint32]uint32
+	// Integers is what GetIntegerv reports for each parameter. It starts with gl.MAX_SAMPLES and
+	// the color and depth texture sample limits at 8
+	Integers map[uint32]int32
+	// Contents is what each buffer holds. BufferData, BufferSubData and CopyBufferSubData keep it
+	// up to date so data can be followed through copies
+	Contents map[uint32][]byte
+	// SyncTimeouts is how many times ClientWaitSync times out before it reports a sync as signaled
+	SyncTimeouts int
+	// Mapped is the memory handed out for each mapped buffer. It stays around after the buffer is
+	// unmapped so what was written can be checked
+	Mapped map[uint32][]byte
+
+	nextName uint32
+}
+
+// NewFakeGL creates a fake with nothing bound
+func NewFakeGL() (f *FakeGL) {
+	f = &FakeGL{}
+	f.Reset()
+	return f
+}
+
+// Reset forgets every call, all of the state and any queued errors
+func (f *FakeGL) Reset() {
+	*f = FakeGL{
+		Buffers:       map[uint32]uint32{},
+		ActiveUnit:    gl.TEXTURE0,
+		Textures:      map[uint32]uint32{},
+		Capabilities:  map[uint32]bool{},
+		BufferSizes:   map[uint32]int{},
+		Locations:     map[string]int32{},
+		Uniforms:      map[int32]interface{}{},
+		EnabledArrays: map[uint32]bool{},
+		Labels:        map[uint32]string{},
+		Framebuffers:  map[uint32]uint32{},
+		Attachments:   map[uint32]map[uint32]uint32{},
+		BlockSizes:    map[string]int32{},
+		BlockBindings: map[uint32]map[uint32]uint32{},
+		BufferBases:   map[uint32]map[uint32]uint32{},
+		Integers: map[uint32]int32{
+			gl.MAX_SAMPLES:               8,
+			gl.MAX_COLOR_TEXTURE_SAMPLES: 8,
+			gl.MAX_DEPTH_TEXTURE_SAMPLES: 8,
+		},
+		Contents: map[uint32][]byte{},
+		Mapped:   map[uint32][]byte{},
+	}
+}
+
+// Names returns the name of every call in order, which is handy for checking a sequence
+func (f *FakeGL) Names() (names []string) {
+	for _, c := range f.Calls {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+// Find returns every call with the name
+func (f *FakeGL) Find(name string) (calls []Call) {
+	for _, c := range f.Calls {
+		if c.Name == name {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+// record adds a call to the log
+func (f *FakeGL) record(name string, args ...interface{}) {
+	f.Calls = append(f.Calls, Call{Name: name, Args: args})
+}
+
+// gen hands out n new object names
+func (f *FakeGL) gen(n int32, names *uint32) {
+	// names points at the first of n names like it does in openGL, the wrappers only ever ask for 1
+	for i := int32(0); i < n; i++ {
+		f.nextName++
+		if i == 0 {
+			*names = f.nextName
+		}
+	}
+}
+
+// location returns the location for a name in a program, making one up the first time it is asked
+func (f *FakeGL) location(program uint32, name string) int32 {
+	key := fmt.Sprintf("%d/%s", program, name)
+	loc, ok := f.Locations[key]
+	if !ok {
+		loc = int32(len(f.Locations))
+		f.Locations[key] = loc
+	}
+	return loc
+}
+
+// GenBuffers records the call and hands out a buffer name
+func (f *FakeGL) GenBuffers(n int32, buffers *uint32) {
+	f.gen(n, buffers)
+	f.record("GenBuffers", n, *buffers)
+}
+
+// DeleteBuffers records the call
+func (f *FakeGL) DeleteBuffers(n int32, buffers *uint32) {
+	f.record("DeleteBuffers", n, *buffers)
+}
+
+// BindBuffer records the call and the binding
+func (f *FakeGL) BindBuffer(target, buffer uint32) {
+	f.record("BindBuffer", target, buffer)
+	f.Buffers[target] = buffer
+}
+
+// BufferData records the call, the size of the bound buffer and its new contents
+func (f *FakeGL) BufferData(target uint32, size int, data interface{}, usage uint32) {
+	f.record("BufferData", target, size, data, usage)
+	f.BufferSizes[f.Buffers[target]] = size
+	contents := make([]byte, size)
+	copy(contents, sliceBytes(data))
+	f.Contents[f.Buffers[target]] = contents
+}
+
+// BufferSubData records the call and writes the data into the bound buffer. Writing past the end
+// is a gl.INVALID_VALUE error like it is in openGL
+func (f *FakeGL) BufferSubData(target uint32, offset, size int, data interface{}) {
+	f.record("BufferSubData", target, offset, size, data)
+	contents := f.Contents[f.Buffers[target]]
+	if offset < 0 || offset+size > len(contents) {
+		f.Errors = append(f.Errors, gl.INVALID_VALUE)
+		return
+	}
+	copy(contents[offset:offset+size], sliceBytes(data))
+}
+
+// BindBufferBase records the call and the binding. Like openGL it also binds the buffer to the
+// target's generic binding
+func (f *FakeGL) BindBufferBase(target, index, buffer uint32) {
+	f.record("BindBufferBase", target, index, buffer)
+	if f.BufferBases[target] == nil {
+		f.BufferBases[target] = map[uint32]uint32{}
+	}
+	f.BufferBases[target][index] = buffer
+	f.Buffers[target] = buffer
+}
+
+// CopyBufferSubData records the call and copies between the buffers bound to the targets.
+// Reading or writing past the end of either is a gl.INVALID_VALUE error like it is in openGL
+func (f *FakeGL) CopyBufferSubData(readTarget, writeTarget uint32, readOffset, writeOffset, size int) {
+	f.record("CopyBufferSubData", readTarget, writeTarget, readOffset, writeOffset, size)
+	read, write := f.Contents[f.Buffers[readTarget]], f.Contents[f.Buffers[writeTarget]]
+	if readOffset < 0 || writeOffset < 0 || readOffset+size > len(read) || writeOffset+size > len(write) {
+		f.Errors = append(f.Errors, gl.INVALID_VALUE)
+		return
+	}
+	copy(write[writeOffset:writeOffset+size], read[readOffset:readOffset+size])
+}
+
+// BufferStorage records the call and the size of the bound buffer
+func (f *FakeGL) BufferStorage(target uint32, size int, data interface{}, flags uint32) {
+	f.record("BufferStorage", target, size, data, flags)
+	f.BufferSizes[f.Buffers[target]] = size
+}
+
+// MapBufferRange records the call and hands out length bytes of memory for the bound buffer
+func (f *FakeGL) MapBufferRange(target uint32, offset, length int, access uint32) []byte {
+	f.record("MapBufferRange", target, offset, length, access)
+	mapped := make([]byte, length)
+	f.Mapped[f.Buffers[target]] = mapped
+	return mapped
+}
+
+// UnmapBuffer records the call and reports the data as intact
+func (f *FakeGL) UnmapBuffer(target uint32) bool {
+	f.record("UnmapBuffer", target)
+	return true
+}
+
+// GenVertexArrays records the call and hands out a vertex array name
+func (f *FakeGL) GenVertexArrays(n int32, arrays *uint32) {
+	f.gen(n, arrays)
+	f.record("GenVertexArrays", n, *arrays)
+}
+
+// DeleteVertexArrays records the call
+func (f *FakeGL) DeleteVertexArrays(n int32, arrays *uint32) {
+	f.record("DeleteVertexArrays", n, *arrays)
+}
+
+// BindVertexArray records the call and the binding
+func (f *FakeGL) BindVertexArray(array uint32) {
+	f.record("BindVertexArray", array)
+	f.VertexArray = array
+}
+
+// VertexAttribPointer records the call
+func (f *FakeGL) VertexAttribPointer(index uint32, size int32, xtype uint32, normalized bool, stride int32, offset int) {
+	f.record("VertexAttribPointer", index, size, xtype, normalized, stride, offset)
+}
+
+// EnableVertexAttribArray records the call and that the attribute is enabled
+func (f *FakeGL) EnableVertexAttribArray(index uint32) {
+	f.record("EnableVertexAttribArray", index)
+	f.EnabledArrays[index] = true
+}
+
+// VertexAttribDivisor records the call
+func (f *FakeGL) VertexAttribDivisor(index, divisor uint32) {
+	f.record("VertexAttribDivisor", index, divisor)
+}
+
+// CreateShader records the call and hands out a shader name
+func (f *FakeGL) CreateShader(shaderType uint32) (shader uint32) {
+	f.gen(1, &shader)
+	f.record("CreateShader", shaderType)
+	return shader
+}
+
+// ShaderSource records the call
+func (f *FakeGL) ShaderSource(shader uint32, source string) { f.record("ShaderSource", shader, source) }
+
+// CompileShader records the call
+func (f *FakeGL) CompileShader(shader uint32) { f.record("CompileShader", shader) }
+
+// GetShaderiv records the call and reports the shader as compiled
+func (f *FakeGL) GetShaderiv(shader, pname uint32, params *int32) {
+	f.record("GetShaderiv", shader, pname)
+	*params = 0
+	if pname == gl.COMPILE_STATUS {
+		*params = gl.TRUE
+	}
+}
+
+// GetShaderInfoLog records the call and returns an empty log
+func (f *FakeGL) GetShaderInfoLog(shader uint32) string {
+	f.record("GetShaderInfoLog", shader)
+	return ""
+}
+
+// DeleteShader records the call
+func (f *FakeGL) DeleteShader(shader uint32) { f.record("DeleteShader", shader) }
+
+// CreateProgram records the call and hands out a program name
+func (f *FakeGL) CreateProgram() (program uint32) {
+	f.gen(1, &program)
+	f.record("CreateProgram")
+	return program
+}
+
+// AttachShader records the call
+func (f *FakeGL) AttachShader(program, shader uint32) { f.record("AttachShader", program, shader) }
+
+// LinkProgram records the call
+func (f *FakeGL) LinkProgram(program uint32) { f.record("LinkProgram", program) }
+
+// GetProgramiv records the call and reports the program as linked unless LinkFails is set
+func (f *FakeGL) GetProgramiv(program, pname uint32, params *int32) {
+	f.record("GetProgramiv", program, pname)
+	*params = 0
+	switch pname {
+	case gl.LINK_STATUS:
+		*params = gl.TRUE
+		if f.LinkFails != "" {
+			*params = gl.FALSE
+		}
+	case gl.INFO_LOG_LENGTH:
+		*params = int32(len(f.LinkFails))
+	}
+}
+
+// GetProgramInfoLog records the call and returns LinkFails
+func (f *FakeGL) GetProgramInfoLog(program uint32) string {
+	f.record("GetProgramInfoLog", program)
+	return f.LinkFails
+}
+
+// DeleteProgram records the call
+func (f *FakeGL) DeleteProgram(program uint32) { f.record("DeleteProgram", program) }
+
+// UseProgram records the call and the active program
+func (f *FakeGL) UseProgram(program uint32) {
+	f.record("UseProgram", program)
+	f.Program = program
+}
+
+// GetAttribLocation records the call and returns a made up location for the attribute
+func (f *FakeGL) GetAttribLocation(program uint32, name string) int32 {
+	f.record("GetAttribLocation", program, name)
+	return f.location(program, name)
+}
+
+// GetUniformLocation records the call and returns a made up location for the uniform
+func (f *FakeGL) GetUniformLocation(program uint32, name string) int32 {
+	f.record("GetUniformLocation", program, name)
+	return f.location(program, name)
+}
+
+// Uniform1i records the call and the value
+func (f *FakeGL) Uniform1i(location, v0 int32) {
+	f.record("Uniform1i", location, v0)
+	f.Uniforms[location] = v0
+}
+
+// Uniform1ui records the call and the value
+func (f *FakeGL) Uniform1ui(location int32, v0 uint32) {
+	f.record("Uniform1ui", location, v0)
+	f.Uniforms[location] = v0
+}
+
+// Uniform1f records the call and the value
+func (f *FakeGL) Uniform1f(location int32, v0 float32) {
+	f.record("Uniform1f", location, v0)
+	f.Uniforms[location] = v0
+}
+
+// Uniform2f records the call and the value
+func (f *FakeGL) Uniform2f(location int32, v0, v1 float32) {
+	f.record("Uniform2f", location, v0, v1)
+	f.Uniforms[location] = [2]float32{v0, v1}
+}
+
+// Uniform3f records the call and the value
+func (f *FakeGL) Uniform3f(location int32, v0, v1, v2 float32) {
+	f.record("Uniform3f", location, v0, v1, v2)
+	f.Uniforms[location] = [3]float32{v0, v1, v2}
+}
+
+// Uniform4f records the call and the value
+func (f *FakeGL) Uniform4f(location int32, v0, v1, v2, v3 float32) {
+	f.record("Uniform4f", location, v0, v1, v2, v3)
+	f.Uniforms[location] = [4]float32{v0, v1, v2, v3}
+}
+
+// UniformMatrix3fv records the call and a copy of the matrix
+func (f *FakeGL) UniformMatrix3fv(location, count int32, transpose bool, value *float32) {
+	var matrix [9]float32
+	if value != nil {
+		matrix = *(*[9]float32)(unsafe.Pointer(value))
+	}
+	f.record("UniformMatrix3fv", location, count, transpose, matrix)
+	f.Uniforms[location] = matrix
+}
+
+// UniformMatrix4fv records the call and a copy of the matrix
+func (f *FakeGL) UniformMatrix4fv(location, count int32, transpose bool, value *float32) {
+	var matrix [16]float32
+	if value != nil {
+		// value points at the first element of a 4x4 column major matrix
+		matrix = *(*[16]float32)(unsafe.Pointer(value))
+	}
+	f.record("UniformMatrix4fv", location, count, transpose, matrix)
+	f.Uniforms[location] = matrix
+}
+
+// GetUniformBlockIndex records the call and returns the block's index in BlockSizes order, or
+// gl.INVALID_INDEX if it isn't there
+func (f *FakeGL) GetUniformBlockIndex(program uint32, name string) uint32 {
+	f.record("GetUniformBlockIndex", program, name)
+	if _, ok := f.BlockSizes[name]; !ok {
+		return gl.INVALID_INDEX
+	}
+	return f.blockIndex(name)
+}
+
+// UniformBlockBinding records the call and the binding
+func (f *FakeGL) UniformBlockBinding(program, blockIndex, binding uint32) {
+	f.record("UniformBlockBinding", program, blockIndex, binding)
+	if f.BlockBindings[program] == nil {
+		f.BlockBindings[program] = map[uint32]uint32{}
+	}
+	f.BlockBindings[program][blockIndex] = binding
+}
+
+// GetActiveUniformBlockiv records the call and reports the block's size from BlockSizes
+func (f *FakeGL) GetActiveUniformBlockiv(program, blockIndex, pname uint32, params *int32) {
+	f.record("GetActiveUniformBlockiv", program, blockIndex, pname)
+	*params = 0
+	if pname != gl.UNIFORM_BLOCK_DATA_SIZE {
+		return
+	}
+	for name, size := range f.BlockSizes {
+		if f.blockIndex(name) == blockIndex {
+			*params = size
+		}
+	}
+}
+
+// blockIndex gives every block name a stable index
+func (f *FakeGL) blockIndex(name string) uint32 {
+	return uint32(f.location(0, "block "+name))
+}
+
+// GenTextures records the call and hands out a texture name
+func (f *FakeGL) GenTextures(n int32, textures *uint32) {
+	f.gen(n, textures)
+	f.record("GenTextures", n, *textures)
+}
+
+// DeleteTextures records the call
+func (f *FakeGL) DeleteTextures(n int32, textures *uint32) {
+	f.record("DeleteTextures", n, *textures)
+}
+
+// ActiveTexture records the call and the active unit
+func (f *FakeGL) ActiveTexture(unit uint32) {
+	f.record("ActiveTexture", unit)
+	f.ActiveUnit = unit
+}
+
+// BindTexture records the call and the texture bound to the active unit. Only one target per unit
+// is tracked since the engine only uses 2D textures
+func (f *FakeGL) BindTexture(target, texture uint32) {
+	f.record("BindTexture", target, texture)
+	f.Textures[f.ActiveUnit] = texture
+}
+
+// TexParameteri records the call
+func (f *FakeGL) TexParameteri(target, pname uint32, param int32) {
+	f.record("TexParameteri", target, pname, param)
+}
+
+// TexParameterfv records the call
+func (f *FakeGL) TexParameterfv(target, pname uint32, params []float32) {
+	f.record("TexParameterfv", target, pname, append([]float32(nil), params...))
+}
+
+// GenFramebuffers records the call and hands out a framebuffer name
+func (f *FakeGL) GenFramebuffers(n int32, framebuffers *uint32) {
+	f.gen(n, framebuffers)
+	f.record("GenFramebuffers", n, *framebuffers)
+}
+
+// DeleteFramebuffers records the call
+func (f *FakeGL) DeleteFramebuffers(n int32, framebuffers *uint32) {
+	f.record("DeleteFramebuffers", n, *framebuffers)
+}
+
+// BindFramebuffer records the call and the binding. gl.FRAMEBUFFER binds both the draw and read
+// framebuffers like it does in openGL
+func (f *FakeGL) BindFramebuffer(target, framebuffer uint32) {
+	f.record("BindFramebuffer", target, framebuffer)
+	if target == gl.FRAMEBUFFER {
+		f.Framebuffers[gl.DRAW_FRAMEBUFFER] = framebuffer
+		f.Framebuffers[gl.READ_FRAMEBUFFER] = framebuffer
+		return
+	}
+	f.Framebuffers[target] = framebuffer
+}
+
+// FramebufferTexture2D records the call and the attachment of the bound framebuffer
+func (f *FakeGL) FramebufferTexture2D(target, attachment, textarget, texture uint32, level int32) {
+	f.record("FramebufferTexture2D", target, attachment, textarget, texture, level)
+	if target == gl.FRAMEBUFFER {
+		target = gl.DRAW_FRAMEBUFFER
+	}
+	fbo := f.Framebuffers[target]
+	if f.Attachments[fbo] == nil {
+		f.Attachments[fbo] = map[uint32]uint32{}
+	}
+	f.Attachments[fbo][attachment] = texture
+}
+
+// CheckFramebufferStatus records the call and reports every framebuffer as complete
+func (f *FakeGL) CheckFramebufferStatus(target uint32) uint32 {
+	f.record("CheckFramebufferStatus", target)
+	return gl.FRAMEBUFFER_COMPLETE
+}
+
+// DrawBuffer records the call
+func (f *FakeGL) DrawBuffer(buf uint32) { f.record("DrawBuffer", buf) }
+
+// ReadBuffer records the call
+func (f *FakeGL) ReadBuffer(src uint32) { f.record("ReadBuffer", src) }
+
+// ReadPixels records the call and leaves pixels as they are
+func (f *FakeGL) ReadPixels(x, y, width, height int32, format, xtype uint32, pixels interface{}) {
+	f.record("ReadPixels", x, y, width, height, format, xtype)
+}
+
+// ClearBufferuiv records the call
+func (f *FakeGL) ClearBufferuiv(buffer uint32, drawbuffer int32, value []uint32) {
+	f.record("ClearBufferuiv", buffer, drawbuffer, value)
+}
+
+// BlitFramebuffer records the call
+func (f *FakeGL) BlitFramebuffer(srcX0, srcY0, srcX1, srcY1, dstX0, dstY0, dstX1, dstY1 int32, mask, filter uint32) {
+	f.record("BlitFramebuffer", srcX0, srcY0, srcX1, srcY1, dstX0, dstY0, dstX1, dstY1, mask, filter)
+}
+
+// TexImage2D records the call with the number of bytes of pixels instead of the pixels
+func (f *FakeGL) TexImage2D(target uint32, level, internalFormat, width, height int32, format, xtype uint32, pixels []uint8) {
+	f.record("TexImage2D", target, level, internalFormat, width, height, format, xtype, len(pixels))
+}
+
+// TexImage2DMultisample records the call
+func (f *FakeGL) TexImage2DMultisample(target uint32, samples int32, internalFormat uint32, width, height int32, fixedSampleLocations bool) {
+	f.record("TexImage2DMultisample", target, samples, internalFormat, width, height, fixedSampleLocations)
+}
+
+// GenerateMipmap records the call
+func (f *FakeGL) GenerateMipmap(target uint32) {
+	f.record("GenerateMipmap", target)
+}
+
+// Enable records the call and the capability
+func (f *FakeGL) Enable(capability uint32) {
+	f.record("Enable", capability)
+	f.Capabilities[capability] = true
+}
+
+// Disable records the call and the capability
+func (f *FakeGL) Disable(capability uint32) {
+	f.record("Disable", capability)
+	f.Capabilities[capability] = false
+}
+
+// BlendFunc records the call
+func (f *FakeGL) BlendFunc(src, dst uint32) { f.record("BlendFunc", src, dst) }
+
+// DepthFunc records the call
+func (f *FakeGL) DepthFunc(function uint32) { f.record("DepthFunc", function) }
+
+// DepthMask records the call
+func (f *FakeGL) DepthMask(flag bool) { f.record("DepthMask", flag) }
+
+// CullFace records the call
+func (f *FakeGL) CullFace(mode uint32) { f.record("CullFace", mode) }
+
+// PolygonMode records the call
+func (f *FakeGL) PolygonMode(face, mode uint32) { f.record("PolygonMode", face, mode) }
+
+// Viewport records the call
+func (f *FakeGL) Viewport(x, y, width, height int32) { f.record("Viewport", x, y, width, height) }
+
+// ClearColor records the call
+func (f *FakeGL) ClearColor(red, green, blue, alpha float32) {
+	f.record("ClearColor", red, green, blue, alpha)
+}
+
+// Clear records the call
+func (f *FakeGL) Clear(mask uint32) { f.record("Clear", mask) }
+
+// DrawArrays records the call
+func (f *FakeGL) DrawArrays(mode uint32, first, count int32) {
+	f.record("DrawArrays", mode, first, count)
+}
+
+// DrawElements records the call
+func (f *FakeGL) DrawElements(mode uint32, count int32, xtype uint32, offset int) {
+	f.record("DrawElements", mode, count, xtype, offset)
+}
+
+// DrawArraysInstanced records the call
+func (f *FakeGL) DrawArraysInstanced(mode uint32, first, count, instances int32) {
+	f.record("DrawArraysInstanced", mode, first, count, instances)
+}
+
+// DrawElementsInstanced records the call
+func (f *FakeGL) DrawElementsInstanced(mode uint32, count int32, xtype uint32, offset int, instances int32) {
+	f.record("DrawElementsInstanced", mode, count, xtype, offset, instances)
+}
+
+// FenceSync records the call and hands out a sync name
+func (f *FakeGL) FenceSync(condition, flags uint32) uintptr {
+	var sync uint32
+	f.gen(1, &sync)
+	f.record("FenceSync", condition, flags)
+	return uintptr(sync)
+}
+
+// ClientWaitSync records the call and reports the sync as already signaled since the fake never
+// has work in flight, after timing out SyncTimeouts times
+func (f *FakeGL) ClientWaitSync(sync uintptr, flags uint32, timeout uint64) uint32 {
+	f.record("ClientWaitSync", sync, flags, timeout)
+	if f.SyncTimeouts > 0 {
+		f.SyncTimeouts--
+		return gl.TIMEOUT_EXPIRED
+	}
+	return gl.ALREADY_SIGNALED
+}
+
+// DeleteSync records the call
+func (f *FakeGL) DeleteSync(sync uintptr) {
+	f.record("DeleteSync", sync)
+}
+
+// GenQueries records the call and hands out a query name
+func (f *FakeGL) GenQueries(n int32, ids *uint32) {
+	f.gen(n, ids)
+	f.record("GenQueries", n, *ids)
+}
+
+// DeleteQueries records the call
+func (f *FakeGL) DeleteQueries(n int32, ids *uint32) {
+	f.record("DeleteQueries", n, *ids)
+}
+
+// BeginQuery records the call
+func (f *FakeGL) BeginQuery(target, id uint32) { f.record("BeginQuery", target, id) }
+
+// EndQuery records the call
+func (f *FakeGL) EndQuery(target uint32) { f.record("EndQuery", target) }
+
+// GetQueryObjectiv records the call and reports every result as available
+func (f *FakeGL) GetQueryObjectiv(id, pname uint32, params *int32) {
+	f.record("GetQueryObjectiv", id, pname)
+	*params = gl.TRUE
+}
+
+// GetQueryObjectui64v records the call and reports QueryResult
+func (f *FakeGL) GetQueryObjectui64v(id, pname uint32, params *uint64) {
+	f.record("GetQueryObjectui64v", id, pname)
+	*params = f.QueryResult
+}
+
+// GetIntegerv records the call and reports the parameter from Integers (0 if it isn't there)
+func (f *FakeGL) GetIntegerv(pname uint32, data *int32) {
+	f.record("GetIntegerv", pname)
+	*data = f.Integers[pname]
+}
+
+// GetError returns the next queued error. It isn't recorded since CheckError polls it
+func (f *FakeGL) GetError() uint32 {
+	if len(f.Errors) == 0 {
+		return gl.NO_ERROR
+	}
+	code := f.Errors[0]
+	f.Errors = f.Errors[1:]
+	return code
+}
+
+// ObjectLabel records the call and the label
+func (f *FakeGL) ObjectLabel(identifier, name uint32, label string) {
+	f.record("ObjectLabel", identifier, name, label)
+	f.Labels[name] = label
+}
@@ -0,0 +1,28 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+)
+
+// useFakeGL swaps a FakeGL in as the Backend with a fresh state cache and resource registry, and
+// puts the real ones back when the test finishes
+func useFakeGL(t *testing.T) (fake *FakeGL) {
+	t.Helper()
+	backend, state, resources := Backend, State, Resources
+	t.Cleanup(func() { Backend, State, Resources = backend, state, resources })
+
+	fake = NewFakeGL()
+	Backend = fake
+	State = NewStateCache()
+	Resources = NewResourceRegistry()
+	return fake
+}
+
+// expectCalls checks the names of the calls made since the fake was last reset
+func expectCalls(t *testing.T, fake *FakeGL, want ...string) {
+	t.Helper()
+	if got := fake.Names(); !reflect.DeepEqual(got, want) {
+		t.Errorf("calls were\n  %v\nwant\n  %v", got, want)
+	}
+}
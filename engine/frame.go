@@ -0,0 +1,122 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/pkg/errors"
+)
+
+// CameraBlock is the Camera uniform block in CameraGLSL
+type CameraBlock struct {
+	View       mgl32.Mat4
+	Projection mgl32.Mat4
+	Position   mgl32.Vec3
+	// Exposure scales the light reaching the camera before it is tonemapped
+	Exposure float32
+	// LinearOutput makes tonemap only apply the exposure so a PostProcess can tonemap instead
+	LinearOutput bool
+}
+
+// CameraGLSL declares the Camera uniform block. Add it to any shader after the #version line to get
+// view, projection, viewPosition, exposure and linearOutput
+const CameraGLSL = `
+	layout(std140) uniform Camera {
+		mat4 view;
+		mat4 projection;
+		vec3 viewPosition;
+		float exposure;
+		bool linearOutput;
+	};
+`
+
+// FrameUniforms holds the uniform blocks that change once a frame and are shared by every program:
+// the camera and the lights. Set them once a frame and bind every program to them once after it
+// is created
+type FrameUniforms struct {
+	Camera *UniformBuffer
+	Lights *UniformBuffer
+	// Exposure is sent with the camera. It starts at 1
+	Exposure float32
+	// LinearOutput is sent with the camera. Set it when rendering into a PostProcess that tonemaps
+	LinearOutput bool
+	// Environment lights the ambient when it is set. SetLights binds it
+	Environment *Environment
+}
+
+// NewFrameUniforms creates the camera and lights uniform buffers
+func NewFrameUniforms() (f *FrameUniforms, err error) {
+	f = &FrameUniforms{Exposure: 1}
+	f.Camera, err = NewUniformBuffer("Camera", CameraBlock{})
+	if err != nil {
+		return nil, err
+	}
+	f.Lights, err = NewUniformBuffer("Lights", LightsBlock{})
+	if err != nil {
+		f.Camera.Delete()
+		return nil, err
+	}
+	return f, nil
+}
+
+// Bind points the program's Camera and Lights blocks at the buffers and its shadow maps at the
+// texture units SetLights binds them to. It leaves the program in use
+func (f *FrameUniforms) Bind(program uint32) (err error) {
+	err = f.Camera.Bind(program)
+	if err != nil {
+		return errors.Wrap(err, "unable to bind the camera block")
+	}
+	err = f.Lights.Bind(program)
+	if err != nil {
+		return errors.Wrap(err, "unable to bind the lights block")
+	}
+
+	// every shadow and environment sampler gets its own unit even when it isn't used. Samplers
+	// default to unit 0 and samplers of different types sharing a unit fail the draw
+	State.UseProgram(program)
+	for i := 0; i < MaxShadows; i++ {
+		location := Backend.GetUniformLocation(program, fmt.Sprintf("shadowMaps[%d]", i))
+		if location >= 0 {
+			Backend.Uniform1i(location, int32(ShadowTextureUnit+i))
+		}
+	}
+	samplers := map[string]int32{
+		"irradianceMap": IrradianceMapUnit,
+		"prefilterMap":  PrefilterMapUnit,
+		"brdfLUT":       BRDFLUTUnit,
+	}
+	for name, unit := range samplers {
+		if location := Backend.GetUniformLocation(program, name); location >= 0 {
+			Backend.Uniform1i(location, unit)
+		}
+	}
+	CheckError("FrameUniforms.Bind")
+	return nil
+}
+
+// SetCamera uploads the camera for this frame
+func (f *FrameUniforms) SetCamera(view, projection mgl32.Mat4, position mgl32.Vec3) (err error) {
+	return f.Camera.Update(CameraBlock{View: view, Projection: projection, Position: position, Exposure: f.Exposure, LinearOutput: f.LinearOutput})
+}
+
+// SetLights uploads the lights for this frame and binds their shadow maps and the environment
+func (f *FrameUniforms) SetLights(lights []Light, blinn bool) (err error) {
+	for i, s := range ShadowMaps(lights) {
+		s.Depth.Bind(gl.TEXTURE0 + ShadowTextureUnit + uint32(i))
+	}
+
+	block := NewLightsBlock(lights, blinn)
+	if f.Environment != nil {
+		f.Environment.Bind()
+		block.Environment = true
+		block.EnvironmentLevels = float32(f.Environment.Prefiltered.Levels - 1)
+	}
+	return f.Lights.Update(block)
+}
+
+// Delete deletes the buffers
+func (f *FrameUniforms) Delete() {
+	f.Camera.Delete()
+	f.Lights.Delete()
+}
@@ -0,0 +1,115 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/pkg/errors"
+)
+
+// Framebuffer wraps an openGL framebuffer object. It lets us render into textures instead of the
+// window
+type Framebuffer struct {
+	ID     uint32
+	Width  int
+	Height int
+
+	// the viewport to go back to when we stop rendering into the framebuffer
+	previous [4]int32
+}
+
+// NewFramebuffer creates an empty framebuffer of the given size. Attach textures to it and Check
+// that it is complete before rendering into it
+func NewFramebuffer(width, height int) (f *Framebuffer) {
+	f = &Framebuffer{
+		Width:  width,
+		Height: height,
+	}
+	Backend.GenFramebuffers(1, &f.ID)
+	Resources.Track(ResourceFramebuffer, f.ID)
+	return f
+}
+
+// AttachTexture attaches the texture to the framebuffer (gl.COLOR_ATTACHMENT0, gl.DEPTH_ATTACHMENT, ...).
+// Multisampled textures can only be attached along with other ones with the same number of samples
+func (f *Framebuffer) AttachTexture(attachment uint32, t *Texture) {
+	State.BindFramebuffer(gl.FRAMEBUFFER, f.ID)
+	Backend.FramebufferTexture2D(gl.FRAMEBUFFER, attachment, t.Target(), t.ID, 0)
+	CheckError("Framebuffer.AttachTexture")
+}
+
+// AttachCubemapFace attaches one face (0-5 for +X, -X, +Y, -Y, +Z, -Z) of a mip level of the
+// cubemap to the framebuffer. Set the viewport to the size of the level when rendering into it
+func (f *Framebuffer) AttachCubemapFace(attachment uint32, c *Cubemap, face int, level int) {
+	State.BindFramebuffer(gl.FRAMEBUFFER, f.ID)
+	Backend.FramebufferTexture2D(gl.FRAMEBUFFER, attachment, gl.TEXTURE_CUBE_MAP_POSITIVE_X+uint32(face), c.ID, int32(level))
+	CheckError("Framebuffer.AttachCubemapFace")
+}
+
+// Check returns an error if the framebuffer can't be rendered into
+func (f *Framebuffer) Check() (err error) {
+	State.BindFramebuffer(gl.FRAMEBUFFER, f.ID)
+	status := Backend.CheckFramebufferStatus(gl.FRAMEBUFFER)
+	if status != gl.FRAMEBUFFER_COMPLETE {
+		return errors.Errorf("framebuffer %d is incomplete: %s", f.ID, framebufferStatusName(status))
+	}
+	return nil
+}
+
+// Bind starts rendering into the framebuffer with a viewport that covers it
+func (f *Framebuffer) Bind() {
+	x, y, width, height := State.ViewportRect()
+	f.previous = [4]int32{x, y, width, height}
+
+	State.BindFramebuffer(gl.FRAMEBUFFER, f.ID)
+	State.Viewport(0, 0, int32(f.Width), int32(f.Height))
+}
+
+// Unbind goes back to rendering into the window with the viewport from before Bind
+func (f *Framebuffer) Unbind() {
+	State.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	State.Viewport(f.previous[0], f.previous[1], f.previous[2], f.previous[3])
+}
+
+// Delete deletes the framebuffer. The textures attached to it have to be deleted separately
+func (f *Framebuffer) Delete() {
+	Backend.DeleteFramebuffers(1, &f.ID)
+	State.forgetFramebuffer(f.ID)
+	Resources.Untrack(ResourceFramebuffer, f.ID)
+}
+
+// framebufferStatusName returns a readable name for a framebuffer status
+func framebufferStatusName(status uint32) string {
+	switch status {
+	case gl.FRAMEBUFFER_UNDEFINED:
+		return "undefined"
+	case gl.FRAMEBUFFER_INCOMPLETE_ATTACHMENT:
+		return "incomplete attachment"
+	case gl.FRAMEBUFFER_INCOMPLETE_MISSING_ATTACHMENT:
+		return "missing attachment"
+	case gl.FRAMEBUFFER_INCOMPLETE_DRAW_BUFFER:
+		return "incomplete draw buffer"
+	case gl.FRAMEBUFFER_INCOMPLETE_READ_BUFFER:
+		return "incomplete read buffer"
+	case gl.FRAMEBUFFER_UNSUPPORTED:
+		return "unsupported"
+	case gl.FRAMEBUFFER_INCOMPLETE_MULTISAMPLE:
+		return "incomplete multisample"
+	}
+	return fmt.Sprintf("0x%x", status)
+}
+
+// fullscreenVertexShaderSrc draws a quad covering the viewport without any vertex data. Draw it
+// as a gl.TRIANGLE_STRIP of 4 vertices with any vao bound
+var fullscreenVertexShaderSrc = `
+	#version 410
+
+	out vec2 fragTexCoord;
+
+	void main() {
+		// a triangle strip of 4 vertices from the vertex id: (0,0) (1,0) (0,1) (1,1)
+		vec2 corner = vec2(gl_VertexID & 1, gl_VertexID >> 1);
+		fragTexCoord = corner;
+		gl_Position = vec4(corner * 2.0 - 1.0, 0.0, 1.0);
+	}
+` + "\x00"
@@ -0,0 +1,95 @@
+package engine
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Plane splits space in two. Points in front of it, on the side the normal points to, have a
+// positive distance
+type Plane struct {
+	Normal mgl32.Vec3
+	D      float32
+}
+
+// NewPlane creates the plane a·x + b·y + c·z + d = 0 from (a, b, c, d), scaled so its normal is
+// unit length and distances to it are real distances
+func NewPlane(v mgl32.Vec4) Plane {
+	length := v.Vec3().Len()
+	if length == 0 {
+		return Plane{}
+	}
+	return Plane{Normal: v.Vec3().Mul(1 / length), D: v.W() / length}
+}
+
+// Distance returns how far the point is in front of the plane, negative when it is behind it
+func (p Plane) Distance(point mgl32.Vec3) float32 {
+	return p.Normal.Dot(point) + p.D
+}
+
+// The planes of a Frustum
+const (
+	FrustumLeft = iota
+	FrustumRight
+	FrustumBottom
+	FrustumTop
+	FrustumNear
+	FrustumFar
+)
+
+// Frustum is the space a camera can see, bounded by 6 planes that face inwards
+type Frustum [6]Plane
+
+// NewFrustum pulls the planes out of a camera's projection × view matrix (Gribb and Hartmann).
+// A point is visible when it lands between -w and w on every axis in clip space, and each of
+// those 6 comparisons is a plane made by adding or subtracting a row of the matrix from its last
+// row. Passing just the projection gives the planes in view space
+func NewFrustum(viewProjection mgl32.Mat4) (f Frustum) {
+	w := viewProjection.Row(3)
+	for axis := 0; axis < 3; axis++ {
+		row := viewProjection.Row(axis)
+		f[2*axis] = NewPlane(w.Add(row))
+		f[2*axis+1] = NewPlane(w.Sub(row))
+	}
+	return f
+}
+
+// ContainsPoint reports whether the point is inside the frustum
+func (f Frustum) ContainsPoint(p mgl32.Vec3) bool {
+	for _, plane := range f {
+		if plane.Distance(p) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// IntersectsSphere reports whether any of the sphere might be inside the frustum. Spheres near a
+// corner can pass without being visible, which is fine for culling since they are only drawn for
+// nothing
+func (f Frustum) IntersectsSphere(s Sphere) bool {
+	for _, plane := range f {
+		if plane.Distance(s.Center) < -s.Radius {
+			return false
+		}
+	}
+	return true
+}
+
+// IntersectsAABB reports whether any of the box might be inside the frustum. Each plane only has
+// to check the corner of the box furthest in front of it: when even that one is behind the plane
+// the whole box is
+func (f Frustum) IntersectsAABB(b AABB) bool {
+	for _, plane := range f {
+		var corner mgl32.Vec3
+		for axis := 0; axis < 3; axis++ {
+			corner[axis] = b.Min[axis]
+			if plane.Normal[axis] >= 0 {
+				corner[axis] = b.Max[axis]
+			}
+		}
+		if plane.Distance(corner) < 0 {
+			return false
+		}
+	}
+	return true
+}
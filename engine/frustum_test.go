@@ -0,0 +1,236 @@
+package engine
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// testFrustum is a camera at z = 5 looking down -z with a 90 degree square view from 1 to 10 units
+// in front of it, so its sides are the planes x = ±(5 - z) and y = ±(5 - z)
+func testFrustum() Frustum {
+	projection := mgl32.Perspective(mgl32.DegToRad(90), 1, 1, 10)
+	view := mgl32.LookAtV(mgl32.Vec3{0, 0, 5}, mgl32.Vec3{0, 0, 0}, mgl32.Vec3{0, 1, 0})
+	return NewFrustum(projection.Mul4(view))
+}
+
+func TestNewFrustumPlanes(t *testing.T) {
+	f := testFrustum()
+
+	s := float32(1 / math.Sqrt2)
+	want := Frustum{
+		FrustumLeft:   {Normal: mgl32.Vec3{s, 0, -s}, D: 5 * s},
+		FrustumRight:  {Normal: mgl32.Vec3{-s, 0, -s}, D: 5 * s},
+		FrustumBottom: {Normal: mgl32.Vec3{0, s, -s}, D: 5 * s},
+		FrustumTop:    {Normal: mgl32.Vec3{0, -s, -s}, D: 5 * s},
+		FrustumNear:   {Normal: mgl32.Vec3{0, 0, -1}, D: 4},
+		FrustumFar:    {Normal: mgl32.Vec3{0, 0, 1}, D: 5},
+	}
+	for i, plane := range f {
+		if !plane.Normal.ApproxEqualThreshold(want[i].Normal, 1e-5) || math.Abs(float64(plane.D-want[i].D)) > 1e-4 {
+			t.Errorf("plane %d is %+v, want %+v", i, plane, want[i])
+		}
+		if length := plane.Normal.Len(); math.Abs(float64(length-1)) > 1e-5 {
+			t.Errorf("plane %d normal is %v long, want 1", i, length)
+		}
+	}
+}
+
+func TestNewPlaneNormalizes(t *testing.T) {
+	p := NewPlane(mgl32.Vec4{0, 3, 4, 10})
+	if p.Normal != (mgl32.Vec3{0, 0.6, 0.8}) || p.D != 2 {
+		t.Errorf("plane is %+v, want normal (0, 0.6, 0.8) and d 2", p)
+	}
+	if d := p.Distance(mgl32.Vec3{0, 3, 4}); d != 7 {
+		t.Errorf("distance is %v, want 7", d)
+	}
+	if p := NewPlane(mgl32.Vec4{0, 0, 0, 1}); p != (Plane{}) {
+		t.Errorf("a plane without a normal is %+v, want the zero plane", p)
+	}
+}
+
+func TestFrustumContainsPoint(t *testing.T) {
+	f := testFrustum()
+
+	// the middle of the frustum is z = 0, where the sides are 5 units out and near and far are
+	// 4 units either way. Each pair of points sits just inside and just outside one plane
+	cases := []struct {
+		plane           int
+		inside, outside mgl32.Vec3
+	}{
+		{FrustumLeft, mgl32.Vec3{-4.9, 0, 0}, mgl32.Vec3{-5.1, 0, 0}},
+		{FrustumRight, mgl32.Vec3{4.9, 0, 0}, mgl32.Vec3{5.1, 0, 0}},
+		{FrustumBottom, mgl32.Vec3{0, -4.9, 0}, mgl32.Vec3{0, -5.1, 0}},
+		{FrustumTop, mgl32.Vec3{0, 4.9, 0}, mgl32.Vec3{0, 5.1, 0}},
+		{FrustumNear, mgl32.Vec3{0, 0, 3.9}, mgl32.Vec3{0, 0, 4.1}},
+		{FrustumFar, mgl32.Vec3{0, 0, -4.9}, mgl32.Vec3{0, 0, -5.1}},
+	}
+	for _, c := range cases {
+		if !f.ContainsPoint(c.inside) {
+			t.Errorf("%v should be inside plane %d", c.inside, c.plane)
+		}
+		if f.ContainsPoint(c.outside) {
+			t.Errorf("%v should be outside plane %d", c.outside, c.plane)
+		}
+		for i, plane := range f {
+			if d := plane.Distance(c.outside); (i == c.plane) != (d < 0) {
+				t.Errorf("%v is %v from plane %d, only plane %d should have it behind", c.outside, d, i, c.plane)
+			}
+		}
+	}
+}
+
+func TestFrustumIntersectsSphere(t *testing.T) {
+	f := testFrustum()
+
+	cases := []struct {
+		sphere Sphere
+		want   bool
+	}{
+		{Sphere{Center: mgl32.Vec3{0, 0, 0}, Radius: 1}, true},
+		// bigger than the whole frustum
+		{Sphere{Center: mgl32.Vec3{0, 0, 0}, Radius: 100}, true},
+		// behind the camera and past the far plane, just short of reaching in and then reaching in
+		{Sphere{Center: mgl32.Vec3{0, 0, 6}, Radius: 1.5}, false},
+		{Sphere{Center: mgl32.Vec3{0, 0, 6}, Radius: 2.5}, true},
+		{Sphere{Center: mgl32.Vec3{0, 0, -7}, Radius: 1.5}, false},
+		{Sphere{Center: mgl32.Vec3{0, 0, -7}, Radius: 2.5}, true},
+		// 2/√2 units left of the left plane
+		{Sphere{Center: mgl32.Vec3{-7, 0, 0}, Radius: 1.4}, false},
+		{Sphere{Center: mgl32.Vec3{-7, 0, 0}, Radius: 1.5}, true},
+		{Sphere{Center: mgl32.Vec3{0, 8, 0}, Radius: 1}, false},
+	}
+	for _, c := range cases {
+		if got := f.IntersectsSphere(c.sphere); got != c.want {
+			t.Errorf("IntersectsSphere(%+v) is %v, want %v", c.sphere, got, c.want)
+		}
+	}
+}
+
+func TestFrustumIntersectsAABB(t *testing.T) {
+	f := testFrustum()
+
+	cases := []struct {
+		name string
+		box  AABB
+		want bool
+	}{
+		{"inside", AABB{mgl32.Vec3{-1, -1, -1}, mgl32.Vec3{1, 1, 1}}, true},
+		{"around everything", AABB{mgl32.Vec3{-50, -50, -50}, mgl32.Vec3{50, 50, 50}}, true},
+		{"straddling near", AABB{mgl32.Vec3{-1, -1, 3}, mgl32.Vec3{1, 1, 6}}, true},
+		{"straddling far", AABB{mgl32.Vec3{-1, -1, -6}, mgl32.Vec3{1, 1, -4}}, true},
+		{"straddling left", AABB{mgl32.Vec3{-6, -1, -1}, mgl32.Vec3{-4, 1, 1}}, true},
+		{"straddling top", AABB{mgl32.Vec3{-1, 4, -1}, mgl32.Vec3{1, 6, 1}}, true},
+		{"behind the camera", AABB{mgl32.Vec3{-1, -1, 5}, mgl32.Vec3{1, 1, 7}}, false},
+		{"past far", AABB{mgl32.Vec3{-1, -1, -9}, mgl32.Vec3{1, 1, -6}}, false},
+		{"left", AABB{mgl32.Vec3{-9, -1, -1}, mgl32.Vec3{-7, 1, 1}}, false},
+		{"right", AABB{mgl32.Vec3{7, -1, -1}, mgl32.Vec3{9, 1, 1}}, false},
+		{"below", AABB{mgl32.Vec3{-1, -9, -1}, mgl32.Vec3{1, -7, 1}}, false},
+	}
+	for _, c := range cases {
+		if got := f.IntersectsAABB(c.box); got != c.want {
+			t.Errorf("%s: IntersectsAABB(%v) is %v, want %v", c.name, c.box, got, c.want)
+		}
+	}
+}
+
+func TestAABBTransformHoldsCorners(t *testing.T) {
+	box := AABB{Min: mgl32.Vec3{-1, -2, 0.5}, Max: mgl32.Vec3{3, 1, 2}}
+	transforms := []mgl32.Mat4{
+		mgl32.Ident4(),
+		mgl32.Translate3D(4, -2, 7),
+		mgl32.Scale3D(2, -1, 0.5),
+		mgl32.HomogRotate3DY(mgl32.DegToRad(45)),
+		mgl32.Translate3D(1, 2, 3).Mul4(mgl32.HomogRotate3D(1.1, mgl32.Vec3{1, 2, 3}.Normalize())).Mul4(mgl32.Scale3D(1, 3, 0.5)),
+	}
+	for i, m := range transforms {
+		var corners []mgl32.Vec3
+		for _, c := range box.Corners() {
+			corners = append(corners, m.Mul4x1(c.Vec4(1)).Vec3())
+		}
+		want := NewAABB(corners)
+		got := box.Transform(m)
+		// the box around the moved corners is the tightest box there is, so they should match
+		if !got.Min.ApproxEqualThreshold(want.Min, 1e-5) || !got.Max.ApproxEqualThreshold(want.Max, 1e-5) {
+			t.Errorf("transform %d gave %v, want %v", i, got, want)
+		}
+	}
+
+	if empty := NewAABB(nil).Transform(mgl32.Translate3D(1, 2, 3)); !empty.Empty() {
+		t.Errorf("moving an empty box gave %v", empty)
+	}
+}
+
+func TestSphereTransform(t *testing.T) {
+	s := Sphere{Center: mgl32.Vec3{1, 0, 0}, Radius: 2}
+	got := s.Transform(mgl32.Translate3D(0, 5, 0).Mul4(mgl32.Scale3D(1, 3, 2)))
+	if !got.Center.ApproxEqualThreshold(mgl32.Vec3{1, 5, 0}, 1e-6) || got.Radius != 6 {
+		t.Errorf("sphere is %+v, want center (1, 5, 0) and radius 6 from the largest scale", got)
+	}
+}
+
+// boundedMesh is a mesh with bounds that counts how many times it is drawn
+type boundedMesh struct {
+	bounds AABB
+	draws  int
+}
+
+func (m *boundedMesh) Draw()        { m.draws++ }
+func (m *boundedMesh) Bounds() AABB { return m.bounds }
+
+func (m *boundedMesh) BoundingSphere() Sphere {
+	corners := m.bounds.Corners()
+	return NewBoundingSphere(corners[:])
+}
+
+// unboundedMesh is a mesh that doesn't know how big it is
+type unboundedMesh struct{ draws int }
+
+func (m *unboundedMesh) Draw() { m.draws++ }
+
+func TestRendererCulling(t *testing.T) {
+	useFakeGL(t)
+
+	camera := Camera{
+		Projection: mgl32.Perspective(mgl32.DegToRad(90), 1, 1, 10),
+		View:       mgl32.LookAtV(mgl32.Vec3{0, 0, 5}, mgl32.Vec3{0, 0, 0}, mgl32.Vec3{0, 1, 0}),
+	}
+	cube := &boundedMesh{bounds: AABB{Min: mgl32.Vec3{-1, -1, -1}, Max: mgl32.Vec3{1, 1, 1}}}
+	unbounded := &unboundedMesh{}
+	material := &RenderMaterial{Program: 1}
+
+	render := func(cull bool) {
+		r := NewRenderer(nil)
+		r.Cull = cull
+		// in front, behind the camera, off to the side and scaled until it pokes in from the side
+		r.Submit(cube, material, mgl32.Ident4())
+		r.Submit(cube, material, mgl32.Translate3D(0, 0, 8))
+		r.Submit(cube, material, mgl32.Translate3D(20, 0, 0))
+		r.Submit(cube, material, mgl32.Translate3D(4, 0, 0).Mul4(mgl32.Scale3D(2, 2, 2)))
+		// unbounded meshes are always drawn, even off screen
+		r.Submit(unbounded, material, mgl32.Translate3D(0, 0, 50))
+		if err := r.Render(camera); err != nil {
+			t.Fatalf("unable to render: %v", err)
+		}
+
+		want := RenderStats{Items: 5, Drawn: 3, Culled: 2}
+		if !cull {
+			want = RenderStats{Items: 5, Drawn: 5}
+		}
+		if r.Stats.Items != want.Items || r.Stats.Drawn != want.Drawn || r.Stats.Culled != want.Culled {
+			t.Errorf("culling %v: stats are %+v, want %+v", cull, r.Stats, want)
+		}
+	}
+
+	render(true)
+	if cube.draws != 2 || unbounded.draws != 1 {
+		t.Errorf("culling drew the cube %d times and the unbounded mesh %d times, want 2 and 1", cube.draws, unbounded.draws)
+	}
+
+	cube.draws, unbounded.draws = 0, 0
+	render(false)
+	if cube.draws != 4 || unbounded.draws != 1 {
+		t.Errorf("without culling drew the cube %d times and the unbounded mesh %d times, want 4 and 1", cube.draws, unbounded.draws)
+	}
+}
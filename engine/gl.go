@@ -0,0 +1,488 @@
+package engine
+
+import (
+	"strings"
+	"unsafe"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// GL is the set of openGL calls the engine and the wrappers around it make. Going through it
+// instead of calling the gl package directly lets engine logic run against a FakeGL without a
+// window or context. The signatures use go types (strings, slices and byte offsets) instead of
+// raw pointers so a fake can record exactly what was passed
+type GL interface {
+	// buffers and vertex arrays
+	GenBuffers(n int32, buffers *uint32)
+	DeleteBuffers(n int32, buffers *uint32)
+	BindBuffer(target, buffer uint32)
+	BufferData(target uint32, size int, data interface{}, usage uint32)
+	BufferSubData(target uint32, offset, size int, data interface{})
+	BindBufferBase(target, index, buffer uint32)
+	CopyBufferSubData(readTarget, writeTarget uint32, readOffset, writeOffset, size int)
+	BufferStorage(target uint32, size int, data interface{}, flags uint32)
+	MapBufferRange(target uint32, offset, length int, access uint32) []byte
+	UnmapBuffer(target uint32) bool
+	GenVertexArrays(n int32, arrays *uint32)
+	DeleteVertexArrays(n int32, arrays *uint32)
+	BindVertexArray(array uint32)
+	VertexAttribPointer(index uint32, size int32, xtype uint32, normalized bool, stride int32, offset int)
+	EnableVertexAttribArray(index uint32)
+	VertexAttribDivisor(index, divisor uint32)
+
+	// shaders and programs
+	CreateShader(shaderType uint32) uint32
+	ShaderSource(shader uint32, source string)
+	CompileShader(shader uint32)
+	GetShaderiv(shader, pname uint32, params *int32)
+	GetShaderInfoLog(shader uint32) string
+	DeleteShader(shader uint32)
+	CreateProgram() uint32
+	AttachShader(program, shader uint32)
+	LinkProgram(program uint32)
+	GetProgramiv(program, pname uint32, params *int32)
+	GetProgramInfoLog(program uint32) string
+	DeleteProgram(program uint32)
+	UseProgram(program uint32)
+	GetAttribLocation(program uint32, name string) int32
+	GetUniformLocation(program uint32, name string) int32
+	Uniform1i(location, v0 int32)
+	Uniform1ui(location int32, v0 uint32)
+	Uniform1f(location int32, v0 float32)
+	Uniform2f(location int32, v0, v1 float32)
+	Uniform3f(location int32, v0, v1, v2 float32)
+	Uniform4f(location int32, v0, v1, v2, v3 float32)
+	UniformMatrix3fv(location, count int32, transpose bool, value *float32)
+	UniformMatrix4fv(location, count int32, transpose bool, value *float32)
+	GetUniformBlockIndex(program uint32, name string) uint32
+	UniformBlockBinding(program, blockIndex, binding uint32)
+	GetActiveUniformBlockiv(program, blockIndex, pname uint32, params *int32)
+
+	// textures
+	GenTextures(n int32, textures *uint32)
+	DeleteTextures(n int32, textures *uint32)
+	ActiveTexture(unit uint32)
+	BindTexture(target, texture uint32)
+	TexParameteri(target, pname uint32, param int32)
+	TexParameterfv(target, pname uint32, params []float32)
+	TexImage2D(target uint32, level, internalFormat, width, height int32, format, xtype uint32, pixels []uint8)
+	TexImage2DMultisample(target uint32, samples int32, internalFormat uint32, width, height int32, fixedSampleLocations bool)
+	GenerateMipmap(target uint32)
+
+	// framebuffers
+	GenFramebuffers(n int32, framebuffers *uint32)
+	DeleteFramebuffers(n int32, framebuffers *uint32)
+	BindFramebuffer(target, framebuffer uint32)
+	FramebufferTexture2D(target, attachment, textarget, texture uint32, level int32)
+	CheckFramebufferStatus(target uint32) uint32
+	DrawBuffer(buf uint32)
+	ReadBuffer(src uint32)
+	ReadPixels(x, y, width, height int32, format, xtype uint32, pixels interface{})
+	ClearBufferuiv(buffer uint32, drawbuffer int32, value []uint32)
+	BlitFramebuffer(srcX0, srcY0, srcX1, srcY1, dstX0, dstY0, dstX1, dstY1 int32, mask, filter uint32)
+
+	// render state
+	Enable(capability uint32)
+	Disable(capability uint32)
+	BlendFunc(src, dst uint32)
+	DepthFunc(function uint32)
+	DepthMask(flag bool)
+	CullFace(mode uint32)
+	PolygonMode(face, mode uint32)
+	Viewport(x, y, width, height int32)
+
+	// drawing
+	ClearColor(red, green, blue, alpha float32)
+	Clear(mask uint32)
+	DrawArrays(mode uint32, first, count int32)
+	DrawElements(mode uint32, count int32, xtype uint32, offset int)
+	DrawArraysInstanced(mode uint32, first, count, instances int32)
+	DrawElementsInstanced(mode uint32, count int32, xtype uint32, offset int, instances int32)
+
+	// queries
+	GenQueries(n int32, ids *uint32)
+	DeleteQueries(n int32, ids *uint32)
+	BeginQuery(target, id uint32)
+	EndQuery(target uint32)
+	GetQueryObjectiv(id, pname uint32, params *int32)
+	GetQueryObjectui64v(id, pname uint32, params *uint64)
+
+	// syncs
+	FenceSync(condition, flags uint32) uintptr
+	ClientWaitSync(sync uintptr, flags uint32, timeout uint64) uint32
+	DeleteSync(sync uintptr)
+
+	GetIntegerv(pname uint32, data *int32)
+
+	// debugging
+	GetError() uint32
+	ObjectLabel(identifier, name uint32, label string)
+}
+
+// Backend is the GL every engine call goes through. It is the real openGL unless a test swaps
+// in a FakeGL
+var Backend GL = RealGL{}
+
+// RealGL passes every call straight through to openGL. It needs a current context
+type RealGL struct{}
+
+// GenBuffers calls glGenBuffers
+func (RealGL) GenBuffers(n int32, buffers *uint32) { gl.GenBuffers(n, buffers) }
+
+// DeleteBuffers calls glDeleteBuffers
+func (RealGL) DeleteBuffers(n int32, buffers *uint32) { gl.DeleteBuffers(n, buffers) }
+
+// BindBuffer calls glBindBuffer
+func (RealGL) BindBuffer(target, buffer uint32) { gl.BindBuffer(target, buffer) }
+
+// BufferData calls glBufferData. data is a slice (or nil to only allocate) and size is in bytes
+func (RealGL) BufferData(target uint32, size int, data interface{}, usage uint32) {
+	if data == nil {
+		gl.BufferData(target, size, nil, usage)
+		return
+	}
+	gl.BufferData(target, size, gl.Ptr(data), usage)
+}
+
+// BufferSubData calls glBufferSubData. data is a slice and offset and size are in bytes
+func (RealGL) BufferSubData(target uint32, offset, size int, data interface{}) {
+	gl.BufferSubData(target, offset, size, gl.Ptr(data))
+}
+
+// BindBufferBase calls glBindBufferBase
+func (RealGL) BindBufferBase(target, index, buffer uint32) { gl.BindBufferBase(target, index, buffer) }
+
+// CopyBufferSubData calls glCopyBufferSubData. The offsets and size are in bytes
+func (RealGL) CopyBufferSubData(readTarget, writeTarget uint32, readOffset, writeOffset, size int) {
+	gl.CopyBufferSubData(readTarget, writeTarget, readOffset, writeOffset, size)
+}
+
+// BufferStorage calls glBufferStorage (ARB_buffer_storage). data is a slice (or nil to only
+// allocate) and size is in bytes
+func (RealGL) BufferStorage(target uint32, size int, data interface{}, flags uint32) {
+	if data == nil {
+		gl.BufferStorage(target, size, nil, flags)
+		return
+	}
+	gl.BufferStorage(target, size, gl.Ptr(data), flags)
+}
+
+// MapBufferRange calls glMapBufferRange and returns the mapped bytes, which are only valid until
+// the buffer is unmapped
+func (RealGL) MapBufferRange(target uint32, offset, length int, access uint32) []byte {
+	pointer := gl.MapBufferRange(target, offset, length, access)
+	if pointer == nil {
+		return nil
+	}
+	return unsafe.Slice((*byte)(pointer), length)
+}
+
+// UnmapBuffer calls glUnmapBuffer
+func (RealGL) UnmapBuffer(target uint32) bool { return gl.UnmapBuffer(target) }
+
+// GenVertexArrays calls glGenVertexArrays
+func (RealGL) GenVertexArrays(n int32, arrays *uint32) { gl.GenVertexArrays(n, arrays) }
+
+// DeleteVertexArrays calls glDeleteVertexArrays
+func (RealGL) DeleteVertexArrays(n int32, arrays *uint32) { gl.DeleteVertexArrays(n, arrays) }
+
+// BindVertexArray calls glBindVertexArray
+func (RealGL) BindVertexArray(array uint32) { gl.BindVertexArray(array) }
+
+// VertexAttribPointer calls glVertexAttribPointer. offset is in bytes
+func (RealGL) VertexAttribPointer(index uint32, size int32, xtype uint32, normalized bool, stride int32, offset int) {
+	gl.VertexAttribPointer(index, size, xtype, normalized, stride, gl.PtrOffset(offset))
+}
+
+// EnableVertexAttribArray calls glEnableVertexAttribArray
+func (RealGL) EnableVertexAttribArray(index uint32) { gl.EnableVertexAttribArray(index) }
+
+// VertexAttribDivisor calls glVertexAttribDivisor
+func (RealGL) VertexAttribDivisor(index, divisor uint32) { gl.VertexAttribDivisor(index, divisor) }
+
+// CreateShader calls glCreateShader
+func (RealGL) CreateShader(shaderType uint32) uint32 { return gl.CreateShader(shaderType) }
+
+// ShaderSource calls glShaderSource with a single null terminated source string
+func (RealGL) ShaderSource(shader uint32, source string) {
+	csources, free := gl.Strs(source)
+	defer free()
+	gl.ShaderSource(shader, 1, csources, nil)
+}
+
+// CompileShader calls glCompileShader
+func (RealGL) CompileShader(shader uint32) { gl.CompileShader(shader) }
+
+// GetShaderiv calls glGetShaderiv
+func (RealGL) GetShaderiv(shader, pname uint32, params *int32) { gl.GetShaderiv(shader, pname, params) }
+
+// GetShaderInfoLog returns the shader's info log
+func (RealGL) GetShaderInfoLog(shader uint32) string {
+	var logLength int32
+	gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &logLength)
+
+	// fill a string with a bunch of C nulls so we can null terminate the string
+	l := strings.Repeat("\x00", int(logLength+1))
+	gl.GetShaderInfoLog(shader, logLength, nil, gl.Str(l))
+	return strings.TrimRight(l, "\x00")
+}
+
+// DeleteShader calls glDeleteShader
+func (RealGL) DeleteShader(shader uint32) { gl.DeleteShader(shader) }
+
+// CreateProgram calls glCreateProgram
+func (RealGL) CreateProgram() uint32 { return gl.CreateProgram() }
+
+// AttachShader calls glAttachShader
+func (RealGL) AttachShader(program, shader uint32) { gl.AttachShader(program, shader) }
+
+// LinkProgram calls glLinkProgram
+func (RealGL) LinkProgram(program uint32) { gl.LinkProgram(program) }
+
+// GetProgramiv calls glGetProgramiv
+func (RealGL) GetProgramiv(program, pname uint32, params *int32) {
+	gl.GetProgramiv(program, pname, params)
+}
+
+// GetProgramInfoLog returns the program's info log
+func (RealGL) GetProgramInfoLog(program uint32) string {
+	var logLength int32
+	gl.GetProgramiv(program, gl.INFO_LOG_LENGTH, &logLength)
+
+	l := strings.Repeat("\x00", int(logLength+1))
+	gl.GetProgramInfoLog(program, logLength, nil, gl.Str(l))
+	return strings.TrimRight(l, "\x00")
+}
+
+// DeleteProgram calls glDeleteProgram
+func (RealGL) DeleteProgram(program uint32) { gl.DeleteProgram(program) }
+
+// UseProgram calls glUseProgram
+func (RealGL) UseProgram(program uint32) { gl.UseProgram(program) }
+
+// GetAttribLocation calls glGetAttribLocation
+func (RealGL) GetAttribLocation(program uint32, name string) int32 {
+	return gl.GetAttribLocation(program, gl.Str(name+"\x00"))
+}
+
+// GetUniformLocation calls glGetUniformLocation
+func (RealGL) GetUniformLocation(program uint32, name string) int32 {
+	return gl.GetUniformLocation(program, gl.Str(name+"\x00"))
+}
+
+// Uniform1i calls glUniform1i
+func (RealGL) Uniform1i(location, v0 int32) { gl.Uniform1i(location, v0) }
+
+// Uniform1ui calls glUniform1ui
+func (RealGL) Uniform1ui(location int32, v0 uint32) { gl.Uniform1ui(location, v0) }
+
+// Uniform1f calls glUniform1f
+func (RealGL) Uniform1f(location int32, v0 float32) { gl.Uniform1f(location, v0) }
+
+// Uniform2f calls glUniform2f
+func (RealGL) Uniform2f(location int32, v0, v1 float32) { gl.Uniform2f(location, v0, v1) }
+
+// Uniform3f calls glUniform3f
+func (RealGL) Uniform3f(location int32, v0, v1, v2 float32) { gl.Uniform3f(location, v0, v1, v2) }
+
+// Uniform4f calls glUniform4f
+func (RealGL) Uniform4f(location int32, v0, v1, v2, v3 float32) {
+	gl.Uniform4f(location, v0, v1, v2, v3)
+}
+
+// UniformMatrix3fv calls glUniformMatrix3fv
+func (RealGL) UniformMatrix3fv(location, count int32, transpose bool, value *float32) {
+	gl.UniformMatrix3fv(location, count, transpose, value)
+}
+
+// UniformMatrix4fv calls glUniformMatrix4fv
+func (RealGL) UniformMatrix4fv(location, count int32, transpose bool, value *float32) {
+	gl.UniformMatrix4fv(location, count, transpose, value)
+}
+
+// GetUniformBlockIndex calls glGetUniformBlockIndex
+func (RealGL) GetUniformBlockIndex(program uint32, name string) uint32 {
+	return gl.GetUniformBlockIndex(program, gl.Str(name+"\x00"))
+}
+
+// UniformBlockBinding calls glUniformBlockBinding
+func (RealGL) UniformBlockBinding(program, blockIndex, binding uint32) {
+	gl.UniformBlockBinding(program, blockIndex, binding)
+}
+
+// GetActiveUniformBlockiv calls glGetActiveUniformBlockiv
+func (RealGL) GetActiveUniformBlockiv(program, blockIndex, pname uint32, params *int32) {
+	gl.GetActiveUniformBlockiv(program, blockIndex, pname, params)
+}
+
+// GenTextures calls glGenTextures
+func (RealGL) GenTextures(n int32, textures *uint32) { gl.GenTextures(n, textures) }
+
+// DeleteTextures calls glDeleteTextures
+func (RealGL) DeleteTextures(n int32, textures *uint32) { gl.DeleteTextures(n, textures) }
+
+// ActiveTexture calls glActiveTexture
+func (RealGL) ActiveTexture(unit uint32) { gl.ActiveTexture(unit) }
+
+// BindTexture calls glBindTexture
+func (RealGL) BindTexture(target, texture uint32) { gl.BindTexture(target, texture) }
+
+// TexParameteri calls glTexParameteri
+func (RealGL) TexParameteri(target, pname uint32, param int32) {
+	gl.TexParameteri(target, pname, param)
+}
+
+// TexParameterfv calls glTexParameterfv
+func (RealGL) TexParameterfv(target, pname uint32, params []float32) {
+	gl.TexParameterfv(target, pname, &params[0])
+}
+
+// GenFramebuffers calls glGenFramebuffers
+func (RealGL) GenFramebuffers(n int32, framebuffers *uint32) { gl.GenFramebuffers(n, framebuffers) }
+
+// DeleteFramebuffers calls glDeleteFramebuffers
+func (RealGL) DeleteFramebuffers(n int32, framebuffers *uint32) {
+	gl.DeleteFramebuffers(n, framebuffers)
+}
+
+// BindFramebuffer calls glBindFramebuffer
+func (RealGL) BindFramebuffer(target, framebuffer uint32) { gl.BindFramebuffer(target, framebuffer) }
+
+// FramebufferTexture2D calls glFramebufferTexture2D
+func (RealGL) FramebufferTexture2D(target, attachment, textarget, texture uint32, level int32) {
+	gl.FramebufferTexture2D(target, attachment, textarget, texture, level)
+}
+
+// CheckFramebufferStatus calls glCheckFramebufferStatus
+func (RealGL) CheckFramebufferStatus(target uint32) uint32 { return gl.CheckFramebufferStatus(target) }
+
+// DrawBuffer calls glDrawBuffer
+func (RealGL) DrawBuffer(buf uint32) { gl.DrawBuffer(buf) }
+
+// ReadBuffer calls glReadBuffer
+func (RealGL) ReadBuffer(src uint32) { gl.ReadBuffer(src) }
+
+// ReadPixels calls glReadPixels. pixels is a slice big enough for what is read
+func (RealGL) ReadPixels(x, y, width, height int32, format, xtype uint32, pixels interface{}) {
+	gl.ReadPixels(x, y, width, height, format, xtype, gl.Ptr(pixels))
+}
+
+// ClearBufferuiv calls glClearBufferuiv. value has one number per channel of the buffer
+func (RealGL) ClearBufferuiv(buffer uint32, drawbuffer int32, value []uint32) {
+	gl.ClearBufferuiv(buffer, drawbuffer, &value[0])
+}
+
+// BlitFramebuffer calls glBlitFramebuffer
+func (RealGL) BlitFramebuffer(srcX0, srcY0, srcX1, srcY1, dstX0, dstY0, dstX1, dstY1 int32, mask, filter uint32) {
+	gl.BlitFramebuffer(srcX0, srcY0, srcX1, srcY1, dstX0, dstY0, dstX1, dstY1, mask, filter)
+}
+
+// TexImage2D calls glTexImage2D. A nil pixels only allocates the texture
+func (RealGL) TexImage2D(target uint32, level, internalFormat, width, height int32, format, xtype uint32, pixels []uint8) {
+	if pixels == nil {
+		gl.TexImage2D(target, level, internalFormat, width, height, 0, format, xtype, nil)
+		return
+	}
+	gl.TexImage2D(target, level, internalFormat, width, height, 0, format, xtype, gl.Ptr(pixels))
+}
+
+// TexImage2DMultisample calls glTexImage2DMultisample
+func (RealGL) TexImage2DMultisample(target uint32, samples int32, internalFormat uint32, width, height int32, fixedSampleLocations bool) {
+	gl.TexImage2DMultisample(target, samples, internalFormat, width, height, fixedSampleLocations)
+}
+
+// GenerateMipmap calls glGenerateMipmap
+func (RealGL) GenerateMipmap(target uint32) { gl.GenerateMipmap(target) }
+
+// Enable calls glEnable
+func (RealGL) Enable(capability uint32) { gl.Enable(capability) }
+
+// Disable calls glDisable
+func (RealGL) Disable(capability uint32) { gl.Disable(capability) }
+
+// BlendFunc calls glBlendFunc
+func (RealGL) BlendFunc(src, dst uint32) { gl.BlendFunc(src, dst) }
+
+// DepthFunc calls glDepthFunc
+func (RealGL) DepthFunc(function uint32) { gl.DepthFunc(function) }
+
+// DepthMask calls glDepthMask
+func (RealGL) DepthMask(flag bool) { gl.DepthMask(flag) }
+
+// CullFace calls glCullFace
+func (RealGL) CullFace(mode uint32) { gl.CullFace(mode) }
+
+// PolygonMode calls glPolygonMode
+func (RealGL) PolygonMode(face, mode uint32) { gl.PolygonMode(face, mode) }
+
+// Viewport calls glViewport
+func (RealGL) Viewport(x, y, width, height int32) { gl.Viewport(x, y, width, height) }
+
+// ClearColor calls glClearColor
+func (RealGL) ClearColor(red, green, blue, alpha float32) { gl.ClearColor(red, green, blue, alpha) }
+
+// Clear calls glClear
+func (RealGL) Clear(mask uint32) { gl.Clear(mask) }
+
+// DrawArrays calls glDrawArrays
+func (RealGL) DrawArrays(mode uint32, first, count int32) { gl.DrawArrays(mode, first, count) }
+
+// DrawElements calls glDrawElements. offset is in bytes into the bound element buffer
+func (RealGL) DrawElements(mode uint32, count int32, xtype uint32, offset int) {
+	gl.DrawElements(mode, count, xtype, gl.PtrOffset(offset))
+}
+
+// DrawArraysInstanced calls glDrawArraysInstanced
+func (RealGL) DrawArraysInstanced(mode uint32, first, count, instances int32) {
+	gl.DrawArraysInstanced(mode, first, count, instances)
+}
+
+// DrawElementsInstanced calls glDrawElementsInstanced. offset is in bytes into the bound element
+// buffer
+func (RealGL) DrawElementsInstanced(mode uint32, count int32, xtype uint32, offset int, instances int32) {
+	gl.DrawElementsInstanced(mode, count, xtype, gl.PtrOffset(offset), instances)
+}
+
+// GenQueries calls glGenQueries
+func (RealGL) GenQueries(n int32, ids *uint32) { gl.GenQueries(n, ids) }
+
+// DeleteQueries calls glDeleteQueries
+func (RealGL) DeleteQueries(n int32, ids *uint32) { gl.DeleteQueries(n, ids) }
+
+// BeginQuery calls glBeginQuery
+func (RealGL) BeginQuery(target, id uint32) { gl.BeginQuery(target, id) }
+
+// EndQuery calls glEndQuery
+func (RealGL) EndQuery(target uint32) { gl.EndQuery(target) }
+
+// GetQueryObjectiv calls glGetQueryObjectiv
+func (RealGL) GetQueryObjectiv(id, pname uint32, params *int32) {
+	gl.GetQueryObjectiv(id, pname, params)
+}
+
+// GetQueryObjectui64v calls glGetQueryObjectui64v
+func (RealGL) GetQueryObjectui64v(id, pname uint32, params *uint64) {
+	gl.GetQueryObjectui64v(id, pname, params)
+}
+
+// FenceSync calls glFenceSync
+func (RealGL) FenceSync(condition, flags uint32) uintptr { return gl.FenceSync(condition, flags) }
+
+// ClientWaitSync calls glClientWaitSync. timeout is in nanoseconds
+func (RealGL) ClientWaitSync(sync uintptr, flags uint32, timeout uint64) uint32 {
+	return gl.ClientWaitSync(sync, flags, timeout)
+}
+
+// DeleteSync calls glDeleteSync
+func (RealGL) DeleteSync(sync uintptr) { gl.DeleteSync(sync) }
+
+// GetIntegerv calls glGetIntegerv
+func (RealGL) GetIntegerv(pname uint32, data *int32) { gl.GetIntegerv(pname, data) }
+
+// GetError calls glGetError
+func (RealGL) GetError() uint32 { return gl.GetError() }
+
+// ObjectLabel calls glObjectLabel
+func (RealGL) ObjectLabel(identifier, name uint32, label string) {
+	gl.ObjectLabel(identifier, name, int32(len(label)), gl.Str(label+"\x00"))
+}
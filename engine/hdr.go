@@ -0,0 +1,212 @@
+package engine
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"io/fs"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/pkg/errors"
+)
+
+// HDRImage is an image of linear RGB light that can be brighter than 1. Pix holds 3 floats a
+// pixel, row by row from the top like image.RGBA
+type HDRImage struct {
+	Width  int
+	Height int
+	Pix    []float32
+}
+
+// NewHDRImage creates a black image
+func NewHDRImage(width, height int) (img *HDRImage) {
+	return &HDRImage{
+		Width:  width,
+		Height: height,
+		Pix:    make([]float32, 3*width*height),
+	}
+}
+
+// At returns the color of a pixel
+func (img *HDRImage) At(x, y int) mgl32.Vec3 {
+	i := 3 * (y*img.Width + x)
+	return mgl32.Vec3{img.Pix[i], img.Pix[i+1], img.Pix[i+2]}
+}
+
+// Set sets the color of a pixel
+func (img *HDRImage) Set(x, y int, c mgl32.Vec3) {
+	i := 3 * (y*img.Width + x)
+	img.Pix[i], img.Pix[i+1], img.Pix[i+2] = c.X(), c.Y(), c.Z()
+}
+
+// LoadHDR opens and decodes a Radiance .hdr file. It doesn't touch openGL so it is safe to call
+// from any goroutine
+func LoadHDR(fsys fs.FS, file string) (img *HDRImage, err error) {
+	f, err := fsys.Open(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to open hdr file")
+	}
+	defer f.Close()
+
+	img, err = DecodeHDR(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to decode %s", file)
+	}
+	return img, nil
+}
+
+// DecodeHDR decodes a Radiance .hdr (RGBE) image, flat or run length encoded. A header without a
+// FORMAT line is taken to be RGBE. Only the usual orientation (-Y height +X width) is supported
+func DecodeHDR(r io.Reader) (img *HDRImage, err error) {
+	br := bufio.NewReader(r)
+
+	// the header is lines of text up to an empty line. The first line says what the file is
+	magic, err := br.ReadString('\n')
+	if err != nil || !strings.HasPrefix(magic, "#?") {
+		return nil, errors.New("not a radiance hdr file")
+	}
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to read the header")
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		if format := strings.TrimPrefix(line, "FORMAT="); format != line && format != "32-bit_rle_rgbe" {
+			return nil, errors.Errorf("unsupported hdr format %s", format)
+		}
+	}
+
+	// then the size and which way the rows and columns go
+	size, err := br.ReadString('\n')
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read the image size")
+	}
+	fields := strings.Fields(size)
+	if len(fields) != 4 || fields[0] != "-Y" || fields[2] != "+X" {
+		return nil, errors.Errorf("unsupported hdr orientation %q", strings.TrimSpace(size))
+	}
+	height, err := strconv.Atoi(fields[1])
+	if err != nil || height <= 0 {
+		return nil, errors.Errorf("bad hdr height %q", fields[1])
+	}
+	width, err := strconv.Atoi(fields[3])
+	if err != nil || width <= 0 {
+		return nil, errors.Errorf("bad hdr width %q", fields[3])
+	}
+
+	img = NewHDRImage(width, height)
+	scanline := make([]byte, 4*width)
+	for y := 0; y < height; y++ {
+		err = readHDRScanline(br, scanline)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read row %d", y)
+		}
+		for x := 0; x < width; x++ {
+			img.Set(x, y, rgbe(scanline[4*x:]))
+		}
+	}
+	return img, nil
+}
+
+// readHDRScanline reads one row of RGBE pixels into scanline. Run length encoded rows start with
+// 2, 2 and the width and store each channel separately
+func readHDRScanline(br *bufio.Reader, scanline []byte) (err error) {
+	width := len(scanline) / 4
+	start, err := br.Peek(4)
+	if err != nil {
+		return err
+	}
+	if width < 8 || width > 0x7fff || start[0] != 2 || start[1] != 2 || start[2]&0x80 != 0 {
+		_, err = io.ReadFull(br, scanline)
+		return err
+	}
+	if int(start[2])<<8|int(start[3]) != width {
+		return errors.Errorf("run length encoded row is %d wide not %d", int(start[2])<<8|int(start[3]), width)
+	}
+	_, _ = br.Discard(4)
+
+	for channel := 0; channel < 4; channel++ {
+		for x := 0; x < width; {
+			count, err := br.ReadByte()
+			if err != nil {
+				return err
+			}
+
+			// over 128 is a run of one value, otherwise that many values follow
+			if count > 128 {
+				run := int(count - 128)
+				if x+run > width {
+					return errors.New("run goes past the end of the row")
+				}
+				value, err := br.ReadByte()
+				if err != nil {
+					return err
+				}
+				for ; run > 0; run-- {
+					scanline[4*x+channel] = value
+					x++
+				}
+				continue
+			}
+
+			if count == 0 || x+int(count) > width {
+				return errors.New("bad run length")
+			}
+			for i := 0; i < int(count); i++ {
+				value, err := br.ReadByte()
+				if err != nil {
+					return err
+				}
+				scanline[4*x+channel] = value
+				x++
+			}
+		}
+	}
+	return nil
+}
+
+// rgbe decodes a pixel stored as 3 mantissas sharing an exponent
+func rgbe(p []byte) mgl32.Vec3 {
+	if p[3] == 0 {
+		return mgl32.Vec3{}
+	}
+	f := float32(math.Ldexp(1, int(p[3])-(128+8)))
+	return mgl32.Vec3{float32(p[0]) * f, float32(p[1]) * f, float32(p[2]) * f}
+}
+
+// NewHDRTexture uploads the image to a half float texture so lighting can use its full range.
+// It has to be called on the main thread
+func NewHDRTexture(img *HDRImage) (t *Texture) {
+	t = &Texture{
+		Width:  img.Width,
+		Height: img.Height,
+	}
+
+	Backend.GenTextures(1, &t.ID)
+	Resources.Track(ResourceTexture, t.ID)
+	State.BindTexture(gl.TEXTURE0, gl.TEXTURE_2D, t.ID)
+	Backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	Backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	Backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.REPEAT)
+	Backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	Backend.TexImage2D(gl.TEXTURE_2D, 0, gl.RGB16F, int32(t.Width), int32(t.Height), gl.RGB, gl.FLOAT, floatBytes(img.Pix))
+	CheckError("NewHDRTexture")
+
+	return t
+}
+
+// floatBytes returns the bytes of floats in the little endian order openGL reads them in
+func floatBytes(floats []float32) (data []byte) {
+	data = make([]byte, 4*len(floats))
+	for i, f := range floats {
+		binary.LittleEndian.PutUint32(data[4*i:], math.Float32bits(f))
+	}
+	return data
+}
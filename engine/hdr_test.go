@@ -0,0 +1,148 @@
+package engine
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// hdrFile builds a radiance file from its header lines, size line and pixel data
+func hdrFile(header []string, size string, data ...byte) *bytes.Reader {
+	text := strings.Join(header, "\n") + "\n\n" + size + "\n"
+	return bytes.NewReader(append([]byte(text), data...))
+}
+
+// rgbeHeader is the usual header written by most tools
+var rgbeHeader = []string{"#?RADIANCE", "# made by hand", "FORMAT=32-bit_rle_rgbe", "EXPOSURE=1.0"}
+
+// expectPixels checks every pixel of the image row by row
+func expectPixels(t *testing.T, img *HDRImage, want ...mgl32.Vec3) {
+	t.Helper()
+	if len(want) != img.Width*img.Height {
+		t.Fatalf("image is %dx%d, want %d pixels", img.Width, img.Height, len(want))
+	}
+	for i, c := range want {
+		x, y := i%img.Width, i/img.Width
+		if got := img.At(x, y); !got.ApproxEqualThreshold(c, 1e-6) {
+			t.Errorf("pixel (%d, %d) is %v, want %v", x, y, got, c)
+		}
+	}
+}
+
+func TestRGBE(t *testing.T) {
+	cases := []struct {
+		p    [4]byte
+		want mgl32.Vec3
+	}{
+		// the mantissas are fractions of 256 scaled by 2^(e-128)
+		{[4]byte{128, 64, 32, 129}, mgl32.Vec3{1, 0.5, 0.25}},
+		{[4]byte{255, 128, 0, 128}, mgl32.Vec3{255.0 / 256, 0.5, 0}},
+		{[4]byte{1, 2, 3, 136}, mgl32.Vec3{1, 2, 3}},
+		{[4]byte{128, 128, 128, 138}, mgl32.Vec3{512, 512, 512}},
+		{[4]byte{128, 0, 0, 120}, mgl32.Vec3{1.0 / 512, 0, 0}},
+		// an exponent of 0 is black whatever the mantissas are
+		{[4]byte{255, 255, 255, 0}, mgl32.Vec3{}},
+	}
+	for _, c := range cases {
+		if got := rgbe(c.p[:]); got != c.want {
+			t.Errorf("rgbe(%v) is %v, want %v", c.p, got, c.want)
+		}
+	}
+}
+
+func TestDecodeHDRFlat(t *testing.T) {
+	img, err := DecodeHDR(hdrFile(rgbeHeader, "-Y 2 +X 3",
+		128, 64, 32, 129, 0, 0, 0, 0, 1, 2, 3, 136,
+		255, 255, 255, 0, 128, 128, 128, 130, 128, 0, 0, 128,
+	))
+	if err != nil {
+		t.Fatalf("unable to decode: %v", err)
+	}
+	expectPixels(t, img,
+		mgl32.Vec3{1, 0.5, 0.25}, mgl32.Vec3{}, mgl32.Vec3{1, 2, 3},
+		mgl32.Vec3{}, mgl32.Vec3{2, 2, 2}, mgl32.Vec3{0.5, 0, 0},
+	)
+}
+
+func TestDecodeHDRFlatWideRows(t *testing.T) {
+	// rows of 8 or more can be run length encoded but these don't start with 2, 2 so they are flat
+	var data []byte
+	for x := 0; x < 8; x++ {
+		data = append(data, byte(16*x), 0, 0, 136)
+	}
+	img, err := DecodeHDR(hdrFile(rgbeHeader, "-Y 1 +X 8", data...))
+	if err != nil {
+		t.Fatalf("unable to decode: %v", err)
+	}
+	for x := 0; x < 8; x++ {
+		if got := img.At(x, 0); got != (mgl32.Vec3{float32(16 * x), 0, 0}) {
+			t.Errorf("pixel %d is %v, want %v", x, got, 16*x)
+		}
+	}
+}
+
+// rleRow is a run length encoded row 8 pixels wide. Red is one run, green is all literals, blue
+// mixes both and the exponent is one run
+var rleRow = []byte{
+	2, 2, 0, 8,
+	128 + 8, 128,
+	8, 0, 16, 32, 48, 64, 80, 96, 112,
+	128 + 5, 64, 3, 1, 2, 3,
+	128 + 8, 129,
+}
+
+func TestDecodeHDRRunLengthEncoded(t *testing.T) {
+	img, err := DecodeHDR(hdrFile(rgbeHeader, "-Y 2 +X 8", append(append([]byte{}, rleRow...), rleRow...)...))
+	if err != nil {
+		t.Fatalf("unable to decode: %v", err)
+	}
+
+	// the exponent of 129 scales the mantissas by 1/128
+	var want []mgl32.Vec3
+	blue := []float32{64, 64, 64, 64, 64, 1, 2, 3}
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 8; x++ {
+			want = append(want, mgl32.Vec3{1, float32(16*x) / 128, blue[x] / 128})
+		}
+	}
+	expectPixels(t, img, want...)
+}
+
+func TestDecodeHDRWithoutFormat(t *testing.T) {
+	// FORMAT defaults to rgbe when it is left out, which some writers do
+	img, err := DecodeHDR(hdrFile([]string{"#?RGBE"}, "-Y 1 +X 1", 1, 2, 3, 136))
+	if err != nil {
+		t.Fatalf("unable to decode without a FORMAT line: %v", err)
+	}
+	expectPixels(t, img, mgl32.Vec3{1, 2, 3})
+}
+
+func TestDecodeHDRErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		file *bytes.Reader
+		want string
+	}{
+		{"bad magic", hdrFile([]string{"P6"}, "-Y 1 +X 1", 1, 2, 3, 136), "not a radiance hdr file"},
+		{"empty", bytes.NewReader(nil), "not a radiance hdr file"},
+		{"xyz format", hdrFile([]string{"#?RADIANCE", "FORMAT=32-bit_rle_xyze"}, "-Y 1 +X 1", 1, 2, 3, 136), "unsupported hdr format 32-bit_rle_xyze"},
+		{"header never ends", bytes.NewReader([]byte("#?RADIANCE\nFORMAT=32-bit_rle_rgbe\n")), "unable to read the header"},
+		{"no size", bytes.NewReader([]byte("#?RADIANCE\n\n")), "unable to read the image size"},
+		{"flipped", hdrFile(rgbeHeader, "+Y 1 +X 1", 1, 2, 3, 136), "unsupported hdr orientation"},
+		{"bad width", hdrFile(rgbeHeader, "-Y 1 +X wide", 1, 2, 3, 136), "bad hdr width"},
+		{"bad height", hdrFile(rgbeHeader, "-Y 0 +X 1"), "bad hdr height"},
+		{"truncated flat", hdrFile(rgbeHeader, "-Y 2 +X 2", 1, 2, 3, 136, 1, 2, 3, 136, 1, 2), "unable to read row 1"},
+		{"truncated rle", hdrFile(rgbeHeader, "-Y 1 +X 8", rleRow[:len(rleRow)-1]...), "unable to read row 0"},
+		{"rle width", hdrFile(rgbeHeader, "-Y 1 +X 8", 2, 2, 0, 9), "run length encoded row is 9 wide not 8"},
+		{"long run", hdrFile(rgbeHeader, "-Y 1 +X 8", 2, 2, 0, 8, 128+9, 1), "run goes past the end of the row"},
+		{"zero run", hdrFile(rgbeHeader, "-Y 1 +X 8", 2, 2, 0, 8, 0), "bad run length"},
+	}
+	for _, c := range cases {
+		img, err := DecodeHDR(c.file)
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%s: got %v and error %v, want an error about %q", c.name, img, err, c.want)
+		}
+	}
+}
@@ -0,0 +1,470 @@
+package engine
+
+import (
+	"math"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/pkg/errors"
+)
+
+// The texture units the environment maps are bound to. They sit between the material maps and
+// the shadow maps
+const (
+	IrradianceMapUnit = 5
+	PrefilterMapUnit  = 6
+	BRDFLUTUnit       = 7
+)
+
+// the sizes of the maps made from an environment. Irradiance is so blurry it can be tiny
+const (
+	irradianceSize  = 32
+	prefilterSize   = 128
+	prefilterLevels = 5
+	brdfLUTSize     = 512
+)
+
+// Environment is the light coming from every direction around the scene, ready to light it
+// (image based lighting). Set it on the FrameUniforms to use it for the ambient light
+type Environment struct {
+	// Skybox is the environment itself, to draw behind everything with a Skybox
+	Skybox *Cubemap
+	// Irradiance is the diffuse light reaching a surface facing each direction
+	Irradiance *Cubemap
+	// Prefiltered is the environment blurred more at each mip level for rougher reflections
+	Prefiltered *Cubemap
+	// BRDF is how much of the reflection a surface sends back at each angle and roughness
+	BRDF *Texture
+}
+
+// NewEnvironment renders an equirectangular HDR image (the usual 2:1 panoramas) into a cubemap
+// with faces size texels across and works out the irradiance, prefiltered reflections and BRDF
+// lookup table from it on the GPU. It has to be called on the main thread and takes a moment, so
+// make environments once at startup
+func NewEnvironment(img *HDRImage, size int) (e *Environment, err error) {
+	programs, err := newIBLPrograms()
+	if err != nil {
+		return nil, err
+	}
+	defer programs.delete()
+
+	// lookups crossing the edges of faces blend them instead of showing seams
+	State.Enable(gl.TEXTURE_CUBE_MAP_SEAMLESS)
+
+	// we look at the insides of cubes without depth so put those back the way they were after
+	depthTest, cullFace := State.capabilities[gl.DEPTH_TEST], State.capabilities[gl.CULL_FACE]
+	State.Disable(gl.DEPTH_TEST)
+	State.Disable(gl.CULL_FACE)
+	defer func() {
+		if depthTest {
+			State.Enable(gl.DEPTH_TEST)
+		}
+		if cullFace {
+			State.Enable(gl.CULL_FACE)
+		}
+	}()
+
+	equirectangular := NewHDRTexture(img)
+	defer equirectangular.Delete()
+	vao, vbo := newCubeVAO()
+	defer DeleteVertexArray(vao)
+	defer DeleteBuffer(vbo)
+
+	e = &Environment{
+		Skybox:      NewCubemap(size, int(math.Log2(float64(size)))+1),
+		Irradiance:  NewCubemap(irradianceSize, 1),
+		Prefiltered: NewCubemap(prefilterSize, prefilterLevels),
+		BRDF:        NewRenderTexture(brdfLUTSize, brdfLUTSize, gl.RG16F, gl.RG),
+	}
+	Label(gl.TEXTURE, e.Skybox.ID, "environment")
+	Label(gl.TEXTURE, e.Irradiance.ID, "irradiance")
+	Label(gl.TEXTURE, e.Prefiltered.ID, "prefiltered environment")
+	Label(gl.TEXTURE, e.BRDF.ID, "brdf lut")
+
+	fb := NewFramebuffer(size, size)
+	defer fb.Delete()
+	fb.AttachCubemapFace(gl.COLOR_ATTACHMENT0, e.Skybox, 0, 0)
+	err = fb.Check()
+	if err != nil {
+		e.Delete()
+		return nil, errors.Wrap(err, "unable to render the environment")
+	}
+	fb.Bind()
+	defer fb.Unbind()
+
+	// the panorama goes onto the cube first, everything else is made from the cube
+	equirectangular.Bind(gl.TEXTURE0)
+	renderCubemap(fb, programs.equirectangular, e.Skybox, 0)
+	e.Skybox.GenerateMipmaps()
+
+	e.Skybox.Bind(gl.TEXTURE0)
+	renderCubemap(fb, programs.irradiance, e.Irradiance, 0)
+
+	// each mip level is rougher than the last, from a mirror to completely rough
+	for level := 0; level < prefilterLevels; level++ {
+		roughness := float32(level) / float32(prefilterLevels-1)
+		State.UseProgram(programs.prefilter)
+		Backend.Uniform1f(Backend.GetUniformLocation(programs.prefilter, "roughness"), roughness)
+		Backend.Uniform1f(Backend.GetUniformLocation(programs.prefilter, "sourceSize"), float32(size))
+		renderCubemap(fb, programs.prefilter, e.Prefiltered, level)
+	}
+
+	fb.AttachTexture(gl.COLOR_ATTACHMENT0, e.BRDF)
+	State.Viewport(0, 0, brdfLUTSize, brdfLUTSize)
+	State.UseProgram(programs.brdf)
+	DrawArrays(gl.TRIANGLE_STRIP, 0, 4)
+	CheckError("NewEnvironment")
+
+	return e, nil
+}
+
+// renderCubemap draws the inside of a cube with the program into every face of a mip level of the
+// cubemap. The cube vao has to be bound
+func renderCubemap(fb *Framebuffer, program uint32, c *Cubemap, level int) {
+	size := int32(c.LevelSize(level))
+	State.Viewport(0, 0, size, size)
+	State.UseProgram(program)
+	Backend.UniformMatrix4fv(Backend.GetUniformLocation(program, "faceProjection"), 1, false, &cubeFaceProjection[0])
+	view := Backend.GetUniformLocation(program, "faceView")
+	for face := 0; face < 6; face++ {
+		fb.AttachCubemapFace(gl.COLOR_ATTACHMENT0, c, face, level)
+		Backend.UniformMatrix4fv(view, 1, false, &cubeFaceViews[face][0])
+		Backend.Clear(gl.COLOR_BUFFER_BIT)
+		DrawArrays(gl.TRIANGLES, 0, 36)
+	}
+}
+
+// Bind binds the maps lighting uses to their texture units
+func (e *Environment) Bind() {
+	e.Irradiance.Bind(gl.TEXTURE0 + IrradianceMapUnit)
+	e.Prefiltered.Bind(gl.TEXTURE0 + PrefilterMapUnit)
+	e.BRDF.Bind(gl.TEXTURE0 + BRDFLUTUnit)
+}
+
+// Delete deletes the maps
+func (e *Environment) Delete() {
+	e.Skybox.Delete()
+	e.Irradiance.Delete()
+	e.Prefiltered.Delete()
+	e.BRDF.Delete()
+}
+
+// iblPrograms are the programs that make the environment maps
+type iblPrograms struct {
+	equirectangular uint32
+	irradiance      uint32
+	prefilter       uint32
+	brdf            uint32
+}
+
+// newIBLPrograms compiles the programs and points their samplers at unit 0
+func newIBLPrograms() (p *iblPrograms, err error) {
+	p = &iblPrograms{}
+	sources := []struct {
+		program          *uint32
+		vertex, fragment string
+		sampler          string
+	}{
+		{&p.equirectangular, cubeFaceVertexShaderSrc, equirectangularFragShaderSrc, "equirectangularMap"},
+		{&p.irradiance, cubeFaceVertexShaderSrc, irradianceFragShaderSrc, "environmentMap"},
+		{&p.prefilter, cubeFaceVertexShaderSrc, prefilterFragShaderSrc, "environmentMap"},
+		{&p.brdf, fullscreenVertexShaderSrc, brdfFragShaderSrc, ""},
+	}
+	for _, s := range sources {
+		*s.program, err = NewProgram(s.vertex, s.fragment)
+		if err != nil {
+			p.delete()
+			return nil, errors.Wrap(err, "unable to compile the environment programs")
+		}
+		if s.sampler != "" {
+			Backend.Uniform1i(Backend.GetUniformLocation(*s.program, s.sampler), 0)
+		}
+	}
+	return p, nil
+}
+
+// delete deletes the programs that were made
+func (p *iblPrograms) delete() {
+	for _, program := range []uint32{p.equirectangular, p.irradiance, p.prefilter, p.brdf} {
+		if program != 0 {
+			DeleteProgram(program)
+		}
+	}
+}
+
+// cubeFaceVertexShaderSrc draws the cube from the inside with the direction to each point as the
+// local position
+var cubeFaceVertexShaderSrc = `
+	#version 410
+
+	uniform mat4 faceProjection;
+	uniform mat4 faceView;
+
+	layout(location = 0) in vec3 vert;
+
+	out vec3 localPosition;
+
+	void main() {
+		localPosition = vert;
+		gl_Position = faceProjection * faceView * vec4(vert, 1.0);
+	}
+` + "\x00"
+
+// equirectangularFragShaderSrc looks up each direction in a panorama
+var equirectangularFragShaderSrc = `
+	#version 410
+
+	const float PI = 3.14159265359;
+
+	uniform sampler2D equirectangularMap;
+
+	in vec3 localPosition;
+
+	out vec4 outputColor;
+
+	void main() {
+		vec3 v = normalize(localPosition);
+		// longitude goes across the image and latitude down it from the top row
+		vec2 uv = vec2(atan(v.z, v.x) / (2.0 * PI) + 0.5, 0.5 - asin(v.y) / PI);
+		outputColor = vec4(texture(equirectangularMap, uv).rgb, 1.0);
+	}
+` + "\x00"
+
+// irradianceFragShaderSrc adds up the light over the hemisphere around each direction, weighted
+// by how directly it hits a surface facing that way
+var irradianceFragShaderSrc = `
+	#version 410
+
+	const float PI = 3.14159265359;
+
+	uniform samplerCube environmentMap;
+
+	in vec3 localPosition;
+
+	out vec4 outputColor;
+
+	void main() {
+		vec3 normal = normalize(localPosition);
+		vec3 up = abs(normal.y) < 0.999 ? vec3(0.0, 1.0, 0.0) : vec3(0.0, 0.0, 1.0);
+		vec3 right = normalize(cross(up, normal));
+		up = cross(normal, right);
+
+		vec3 irradiance = vec3(0.0);
+		float samples = 0.0;
+		const float delta = 0.025;
+		for (float phi = 0.0; phi < 2.0 * PI; phi += delta) {
+			for (float theta = 0.0; theta < 0.5 * PI; theta += delta) {
+				vec3 tangent = vec3(sin(theta) * cos(phi), sin(theta) * sin(phi), cos(theta));
+				vec3 direction = tangent.x * right + tangent.y * up + tangent.z * normal;
+				irradiance += texture(environmentMap, direction).rgb * cos(theta) * sin(theta);
+				samples++;
+			}
+		}
+		outputColor = vec4(PI * irradiance / samples, 1.0);
+	}
+` + "\x00"
+
+// importanceSampleGLSL picks directions around a normal that a GGX surface of some roughness
+// reflects the most, so a few hundred samples are enough
+const importanceSampleGLSL = `
+	const float PI = 3.14159265359;
+
+	// hammersley returns the i'th of n points spread evenly over a square
+	vec2 hammersley(uint i, uint n) {
+		uint bits = i;
+		bits = (bits << 16u) | (bits >> 16u);
+		bits = ((bits & 0x55555555u) << 1u) | ((bits & 0xAAAAAAAAu) >> 1u);
+		bits = ((bits & 0x33333333u) << 2u) | ((bits & 0xCCCCCCCCu) >> 2u);
+		bits = ((bits & 0x0F0F0F0Fu) << 4u) | ((bits & 0xF0F0F0F0u) >> 4u);
+		bits = ((bits & 0x00FF00FFu) << 8u) | ((bits & 0xFF00FF00u) >> 8u);
+		return vec2(float(i) / float(n), float(bits) * 2.3283064365386963e-10);
+	}
+
+	// importanceSampleGGX turns a point on the square into a halfway vector around the normal
+	vec3 importanceSampleGGX(vec2 xi, vec3 normal, float roughness) {
+		float a = roughness * roughness;
+		float phi = 2.0 * PI * xi.x;
+		float cosTheta = sqrt((1.0 - xi.y) / (1.0 + (a * a - 1.0) * xi.y));
+		float sinTheta = sqrt(1.0 - cosTheta * cosTheta);
+		vec3 h = vec3(cos(phi) * sinTheta, sin(phi) * sinTheta, cosTheta);
+
+		vec3 up = abs(normal.z) < 0.999 ? vec3(0.0, 0.0, 1.0) : vec3(1.0, 0.0, 0.0);
+		vec3 tangent = normalize(cross(up, normal));
+		vec3 bitangent = cross(normal, tangent);
+		return normalize(tangent * h.x + bitangent * h.y + normal * h.z);
+	}
+`
+
+// prefilterFragShaderSrc blurs the environment the way a surface of some roughness reflects it.
+// It assumes we look straight at the surface, which is the error the split sum approximation
+// accepts
+var prefilterFragShaderSrc = `
+	#version 410
+` + importanceSampleGLSL + `
+	const uint SAMPLES = 512u;
+
+	uniform samplerCube environmentMap;
+	uniform float roughness;
+	uniform float sourceSize;
+
+	in vec3 localPosition;
+
+	out vec4 outputColor;
+
+	void main() {
+		vec3 N = normalize(localPosition);
+		vec3 V = N;
+
+		vec3 color = vec3(0.0);
+		float weight = 0.0;
+		for (uint i = 0u; i < SAMPLES; i++) {
+			vec3 H = importanceSampleGGX(hammersley(i, SAMPLES), N, roughness);
+			vec3 L = normalize(2.0 * dot(V, H) * H - V);
+			float NdotL = dot(N, L);
+			if (NdotL <= 0.0) {
+				continue;
+			}
+
+			// directions that are sampled less often read from a blurrier mip so bright spots
+			// don't turn into speckles
+			float NdotH = max(dot(N, H), 0.0);
+			float a2 = pow(roughness, 4.0);
+			float d = NdotH * NdotH * (a2 - 1.0) + 1.0;
+			float pdf = a2 / (PI * d * d) / 4.0 + 0.0001;
+			float texelAngle = 4.0 * PI / (6.0 * sourceSize * sourceSize);
+			float sampleAngle = 1.0 / (float(SAMPLES) * pdf + 0.0001);
+			float lod = roughness == 0.0 ? 0.0 : 0.5 * log2(sampleAngle / texelAngle);
+
+			color += textureLod(environmentMap, L, lod).rgb * NdotL;
+			weight += NdotL;
+		}
+		outputColor = vec4(color / weight, 1.0);
+	}
+` + "\x00"
+
+// brdfFragShaderSrc works out the scale and bias to the fresnel reflectance for every angle
+// (across) and roughness (up) of the split sum approximation
+var brdfFragShaderSrc = `
+	#version 410
+` + importanceSampleGLSL + `
+	const uint SAMPLES = 1024u;
+
+	in vec2 fragTexCoord;
+
+	out vec2 outputColor;
+
+	float geometrySchlickGGX(float NdotV, float roughness) {
+		// image based lighting uses a different k than direct lights
+		float k = roughness * roughness / 2.0;
+		return NdotV / (NdotV * (1.0 - k) + k);
+	}
+
+	void main() {
+		float NdotV = max(fragTexCoord.x, 0.001);
+		float roughness = fragTexCoord.y;
+		vec3 V = vec3(sqrt(1.0 - NdotV * NdotV), 0.0, NdotV);
+		vec3 N = vec3(0.0, 0.0, 1.0);
+
+		float scale = 0.0;
+		float bias = 0.0;
+		for (uint i = 0u; i < SAMPLES; i++) {
+			vec3 H = importanceSampleGGX(hammersley(i, SAMPLES), N, roughness);
+			vec3 L = normalize(2.0 * dot(V, H) * H - V);
+			float NdotL = max(L.z, 0.0);
+			if (NdotL <= 0.0) {
+				continue;
+			}
+
+			float NdotH = max(H.z, 0.0);
+			float VdotH = max(dot(V, H), 0.0);
+			float G = geometrySchlickGGX(NdotV, roughness) * geometrySchlickGGX(NdotL, roughness);
+			float visibility = G * VdotH / (NdotH * NdotV);
+			float fresnel = pow(1.0 - VdotH, 5.0);
+			scale += (1.0 - fresnel) * visibility;
+			bias += fresnel * visibility;
+		}
+		outputColor = vec2(scale, bias) / float(SAMPLES);
+	}
+` + "\x00"
+
+// Skybox draws an environment behind everything else
+type Skybox struct {
+	program uint32
+	vao     uint32
+	vbo     uint32
+}
+
+// NewSkybox creates the program for drawing skyboxes and binds it to the frame's camera
+func NewSkybox(frame *FrameUniforms) (s *Skybox, err error) {
+	program, err := NewProgram(skyboxVertexShaderSrc, skyboxFragShaderSrc)
+	if err != nil {
+		return nil, err
+	}
+	err = frame.Bind(program)
+	if err != nil {
+		DeleteProgram(program)
+		return nil, err
+	}
+	Backend.Uniform1i(Backend.GetUniformLocation(program, "skybox"), 0)
+	Label(gl.PROGRAM, program, "skybox")
+
+	s = &Skybox{program: program}
+	s.vao, s.vbo = newCubeVAO()
+	Label(gl.VERTEX_ARRAY, s.vao, "skybox")
+	return s, nil
+}
+
+// Draw draws the cubemap around the camera. Draw it after everything else so it only fills in
+// what is left
+func (s *Skybox) Draw(c *Cubemap) {
+	// the sky is drawn at the far plane so it has to pass the depth test there
+	depthFunc, cullFace := State.depthFunc, State.capabilities[gl.CULL_FACE]
+	State.DepthFunc(gl.LEQUAL)
+	State.Disable(gl.CULL_FACE)
+
+	c.Bind(gl.TEXTURE0)
+	State.UseProgram(s.program)
+	State.BindVertexArray(s.vao)
+	DrawArrays(gl.TRIANGLES, 0, 36)
+
+	State.DepthFunc(depthFunc)
+	if cullFace {
+		State.Enable(gl.CULL_FACE)
+	}
+}
+
+// Delete deletes the program and cube
+func (s *Skybox) Delete() {
+	DeleteVertexArray(s.vao)
+	DeleteBuffer(s.vbo)
+	DeleteProgram(s.program)
+}
+
+// skyboxVertexShaderSrc keeps the cube around the camera by dropping the translation from the
+// view and puts it at the far plane by setting z to w
+var skyboxVertexShaderSrc = `
+	#version 410
+` + CameraGLSL + `
+	layout(location = 0) in vec3 vert;
+
+	out vec3 localPosition;
+
+	void main() {
+		localPosition = vert;
+		vec4 position = projection * mat4(mat3(view)) * vec4(vert, 1.0);
+		gl_Position = position.xyww;
+	}
+` + "\x00"
+
+var skyboxFragShaderSrc = `
+	#version 410
+` + CameraGLSL + TonemapGLSL + `
+	uniform samplerCube skybox;
+
+	in vec3 localPosition;
+
+	out vec4 outputColor;
+
+	void main() {
+		outputColor = vec4(tonemap(textureLod(skybox, localPosition, 0.0).rgb), 1.0);
+	}
+` + "\x00"
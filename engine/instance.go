@@ -0,0 +1,67 @@
+package engine
+
+import (
+	"unsafe"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// The attribute locations an InstanceBuffer puts each instance in. A mat4 takes four locations,
+// one for each column, so the color comes after them. They start after the MeshBuffers ones so
+// both can share a vao
+const (
+	InstanceModelLocation = 4
+	InstanceColorLocation = 8
+)
+
+// Instance is one copy of a mesh drawn from an InstanceBuffer
+type Instance struct {
+	Model mgl32.Mat4
+	Color mgl32.Vec4
+}
+
+// instanceSize is how many bytes each instance takes in the buffer
+const instanceSize = int(unsafe.Sizeof(Instance{}))
+
+// InstanceBuffer holds a model matrix and color for every copy of a mesh so they can all be drawn
+// with one instanced draw. Attach it to the mesh's vao and Update it whenever the instances move
+type InstanceBuffer struct {
+	Buffer *Buffer
+	// Count is how many instances were last uploaded
+	Count int32
+}
+
+// NewInstanceBuffer creates a buffer with room for capacity instances. It grows when more are
+// uploaded
+func NewInstanceBuffer(capacity int) (b *InstanceBuffer) {
+	return &InstanceBuffer{Buffer: NewBuffer(gl.ARRAY_BUFFER, capacity*instanceSize, gl.DYNAMIC_DRAW)}
+}
+
+// Attach points the instance attributes of the vao at the buffer. They step once per instance
+// instead of once per vertex
+func (b *InstanceBuffer) Attach(vao uint32) {
+	State.BindVertexArray(vao)
+	b.Buffer.Bind()
+	for column := 0; column < 4; column++ {
+		location := uint32(InstanceModelLocation + column)
+		Backend.VertexAttribPointer(location, 4, gl.FLOAT, false, int32(instanceSize), column*16)
+		Backend.EnableVertexAttribArray(location)
+		Backend.VertexAttribDivisor(location, 1)
+	}
+	Backend.VertexAttribPointer(InstanceColorLocation, 4, gl.FLOAT, false, int32(instanceSize), 64)
+	Backend.EnableVertexAttribArray(InstanceColorLocation)
+	Backend.VertexAttribDivisor(InstanceColorLocation, 1)
+	CheckError("InstanceBuffer.Attach")
+}
+
+// Update uploads the instances, replacing the ones uploaded before
+func (b *InstanceBuffer) Update(instances []Instance) {
+	b.Count = int32(len(instances))
+	b.Buffer.SetData(instances)
+}
+
+// Delete deletes the buffer
+func (b *InstanceBuffer) Delete() {
+	b.Buffer.Delete()
+}
@@ -0,0 +1,348 @@
+package engine
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// MaxLights is how many lights a program using LightsGLSL can be lit by at once
+const MaxLights = 8
+
+// LightKind is the kind of light
+type LightKind int32
+
+// The kinds of light. The values match the constants in LightsGLSL
+const (
+	// DirectionalLight lights everything from the same direction, like the sun
+	DirectionalLight LightKind = iota
+	// PointLight shines in every direction from a position and fades with distance
+	PointLight
+	// SpotLight shines a cone from a position in a direction
+	SpotLight
+)
+
+// Light is a light in the scene
+type Light struct {
+	Kind LightKind
+	// Position is where point and spot lights are
+	Position mgl32.Vec3
+	// Direction is the way directional and spot lights shine
+	Direction mgl32.Vec3
+	// Color is the color and brightness of the light
+	Color mgl32.Vec3
+	// Ambient is how much of the light reaches everything, even the parts facing away from it
+	Ambient float32
+
+	// Attenuation is the constant, linear and quadratic factors for how point and spot lights
+	// fade with distance: 1 / (constant + linear*d + quadratic*d*d)
+	Attenuation mgl32.Vec3
+	// InnerCone and OuterCone are the angles in degrees from the spot light's direction where the
+	// light starts to fade and where it is gone
+	InnerCone float32
+	OuterCone float32
+
+	// Shadow is the shadow map the light casts shadows with. Lights without one light everything
+	// they reach. Only directional and spot lights can cast shadows
+	Shadow *ShadowMap
+}
+
+// defaultAttenuation fades a light out over about 50 units
+var defaultAttenuation = mgl32.Vec3{1.0, 0.09, 0.032}
+
+// NewDirectionalLight creates a light shining in a direction from infinitely far away
+func NewDirectionalLight(direction, color mgl32.Vec3) Light {
+	return Light{
+		Kind:      DirectionalLight,
+		Direction: direction.Normalize(),
+		Color:     color,
+		Ambient:   0.1,
+	}
+}
+
+// NewPointLight creates a light that shines in every direction from a position
+func NewPointLight(position, color mgl32.Vec3) Light {
+	return Light{
+		Kind:        PointLight,
+		Position:    position,
+		Color:       color,
+		Ambient:     0.05,
+		Attenuation: defaultAttenuation,
+	}
+}
+
+// NewSpotLight creates a light that shines a cone from a position. The light is full strength
+// inside inner degrees of the direction and fades out by outer degrees
+func NewSpotLight(position, direction, color mgl32.Vec3, inner, outer float32) Light {
+	return Light{
+		Kind:        SpotLight,
+		Position:    position,
+		Direction:   direction.Normalize(),
+		Color:       color,
+		Attenuation: defaultAttenuation,
+		InnerCone:   inner,
+		OuterCone:   outer,
+	}
+}
+
+// Material is how a surface reflects light
+type Material struct {
+	// Diffuse is the color of the surface
+	Diffuse mgl32.Vec3
+	// Specular is the color of the highlights
+	Specular mgl32.Vec3
+	// Shininess is how tight the highlights are. Bigger is shinier
+	Shininess float32
+}
+
+// MaterialUniforms uploads materials to a program that uses LightsGLSL. It looks up the uniform
+// locations once so uploading for every draw is cheap. Like the rest of the uniforms the program
+// has to be in use when uploading
+type MaterialUniforms struct {
+	diffuse   int32
+	specular  int32
+	shininess int32
+}
+
+// NewMaterialUniforms looks up the material uniforms in the program
+func NewMaterialUniforms(program uint32) (u *MaterialUniforms) {
+	return &MaterialUniforms{
+		diffuse:   Backend.GetUniformLocation(program, "material.diffuse"),
+		specular:  Backend.GetUniformLocation(program, "material.specular"),
+		shininess: Backend.GetUniformLocation(program, "material.shininess"),
+	}
+}
+
+// Upload sends the material of the next thing drawn to the program
+func (u *MaterialUniforms) Upload(m Material) {
+	Backend.Uniform3f(u.diffuse, m.Diffuse.X(), m.Diffuse.Y(), m.Diffuse.Z())
+	Backend.Uniform3f(u.specular, m.Specular.X(), m.Specular.Y(), m.Specular.Z())
+	Backend.Uniform1f(u.shininess, m.Shininess)
+	CheckError("MaterialUniforms.Upload")
+}
+
+// lightStd140 is a Light laid out like the Light struct in LightsGLSL. Each vec3 shares its 16
+// bytes with the scalar after it
+type lightStd140 struct {
+	Position    mgl32.Vec3
+	Kind        int32
+	Direction   mgl32.Vec3
+	Ambient     float32
+	Color       mgl32.Vec3
+	InnerCone   float32
+	Attenuation mgl32.Vec3
+	OuterCone   float32
+	// Shadow is the index of the light's shadow map or -1 when it has none
+	Shadow int32
+}
+
+// LightsBlock is the Lights uniform block in LightsGLSL
+type LightsBlock struct {
+	Lights [MaxLights]lightStd140
+	Count  int32
+	// Blinn uses Blinn-Phong highlights instead of plain Phong
+	Blinn bool
+	// Environment lights the ambient with the environment maps instead of each light's ambient.
+	// EnvironmentLevels is the last mip level of the prefiltered environment
+	Environment       bool
+	EnvironmentLevels float32
+	// LightSpace and ShadowBias are the matrix and bias of each shadow map
+	LightSpace [MaxShadows]mgl32.Mat4
+	ShadowBias [MaxShadows]float32
+}
+
+// NewLightsBlock fills a lights block with the lights. Only the first MaxLights are used and only
+// the first MaxShadows shadow maps, in the order ShadowMaps returns them
+func NewLightsBlock(lights []Light, blinn bool) (b LightsBlock) {
+	if len(lights) > MaxLights {
+		lights = lights[:MaxLights]
+	}
+
+	b.Count = int32(len(lights))
+	b.Blinn = blinn
+	shadows := 0
+	for i, light := range lights {
+		shadow := int32(-1)
+		if light.Shadow != nil && shadows < MaxShadows {
+			shadow = int32(shadows)
+			b.LightSpace[shadows] = light.Shadow.LightSpace
+			b.ShadowBias[shadows] = light.Shadow.Bias
+			shadows++
+		}
+
+		b.Lights[i] = lightStd140{
+			Position:    light.Position,
+			Kind:        int32(light.Kind),
+			Direction:   light.Direction,
+			Ambient:     light.Ambient,
+			Color:       light.Color,
+			Attenuation: light.Attenuation,
+			// the shader compares cosines so it doesn't have to take any
+			InnerCone: cosDegrees(light.InnerCone),
+			OuterCone: cosDegrees(light.OuterCone),
+			Shadow:    shadow,
+		}
+	}
+	return b
+}
+
+// ShadowMaps returns the shadow maps of the lights in the order NewLightsBlock numbers them. The
+// i'th one has to be bound to texture unit ShadowTextureUnit+i
+func ShadowMaps(lights []Light) (maps []*ShadowMap) {
+	if len(lights) > MaxLights {
+		lights = lights[:MaxLights]
+	}
+	for _, light := range lights {
+		if light.Shadow != nil && len(maps) < MaxShadows {
+			maps = append(maps, light.Shadow)
+		}
+	}
+	return maps
+}
+
+// NormalMatrix returns the matrix that transforms normals into world space for a model matrix.
+// It is the inverse transpose of the model's rotation and scale so normals stay perpendicular to
+// surfaces that are scaled unevenly
+func NormalMatrix(model mgl32.Mat4) mgl32.Mat3 {
+	return model.Mat3().Inv().Transpose()
+}
+
+// cosDegrees returns the cosine of an angle in degrees
+func cosDegrees(degrees float32) float32 {
+	return float32(math.Cos(float64(mgl32.DegToRad(degrees))))
+}
+
+// LightsGLSL declares the Lights uniform block, the material and shadow map uniforms and the
+// functions that shade with them. Add it to a fragment shader after CameraGLSL and call shadeLights
+// with the world space normal and position of the fragment
+const LightsGLSL = `
+	#define MAX_LIGHTS 8
+	#define MAX_SHADOWS 4
+
+	const int DIRECTIONAL_LIGHT = 0;
+	const int POINT_LIGHT = 1;
+	const int SPOT_LIGHT = 2;
+
+	struct Light {
+		vec3 position;
+		int kind;
+		vec3 direction;
+		float ambient;
+		vec3 color;
+		float innerCone;
+		vec3 attenuation;
+		float outerCone;
+		int shadow;
+	};
+
+	layout(std140) uniform Lights {
+		Light lights[MAX_LIGHTS];
+		int numLights;
+		bool blinn;
+		bool environment;
+		float environmentLevels;
+		mat4 lightSpace[MAX_SHADOWS];
+		float shadowBias[MAX_SHADOWS];
+	};
+
+	uniform sampler2DShadow shadowMaps[MAX_SHADOWS];
+
+	// the environment maps, when the environment is on
+	uniform samplerCube irradianceMap;
+	uniform samplerCube prefilterMap;
+	uniform sampler2D brdfLUT;
+
+	struct Material {
+		vec3 diffuse;
+		vec3 specular;
+		float shininess;
+	};
+
+	uniform Material material;
+
+	// shadowFactor returns how much of a light reaches the position, from 0 in shadow to 1 lit. It
+	// averages 9 comparisons around the position (percentage closer filtering) to soften the edges
+	float shadowFactor(int i, vec3 normal, vec3 position, vec3 toLight) {
+		vec4 lightPosition = lightSpace[i] * vec4(position, 1.0);
+		vec3 coord = lightPosition.xyz / lightPosition.w * 0.5 + 0.5;
+		// past the far plane of the light is never in shadow
+		if (coord.z > 1.0) {
+			return 1.0;
+		}
+
+		// surfaces at a steep angle to the light need more bias to not shadow themselves
+		float bias = max(shadowBias[i] * (1.0 - dot(normal, toLight)), shadowBias[i] * 0.1);
+		vec2 texel = 1.0 / vec2(textureSize(shadowMaps[i], 0));
+
+		float lit = 0.0;
+		for (int x = -1; x <= 1; x++) {
+			for (int y = -1; y <= 1; y++) {
+				lit += texture(shadowMaps[i], vec3(coord.xy + vec2(x, y) * texel, coord.z - bias));
+			}
+		}
+		return lit / 9.0;
+	}
+
+	// lightStrength returns how much of the light reaches the position after fading with distance,
+	// the spot light cone and shadows. toLight is set to the direction from the position to the light
+	float lightStrength(Light light, vec3 normal, vec3 position, out vec3 toLight) {
+		toLight = -light.direction;
+		float strength = 1.0;
+		if (light.kind != DIRECTIONAL_LIGHT) {
+			toLight = light.position - position;
+			float d = length(toLight);
+			toLight /= d;
+			strength = 1.0 / (light.attenuation.x + light.attenuation.y * d + light.attenuation.z * d * d);
+		}
+		if (light.kind == SPOT_LIGHT) {
+			// fade from the inner cone to the outer cone (these are cosines so inner > outer)
+			float theta = dot(-toLight, normalize(light.direction));
+			strength *= clamp((theta - light.outerCone) / (light.innerCone - light.outerCone), 0.0, 1.0);
+		}
+		if (light.shadow >= 0) {
+			strength *= shadowFactor(light.shadow, normal, position, toLight);
+		}
+		return strength;
+	}
+
+	// shadeLight returns the light reflected towards the camera from one light
+	vec3 shadeLight(Light light, vec3 normal, vec3 position, vec3 viewDir, vec3 albedo) {
+		vec3 toLight;
+		float strength = lightStrength(light, normal, position, toLight);
+
+		// the environment replaces the ambient from each light
+		vec3 ambient = environment ? vec3(0.0) : light.ambient * light.color * albedo;
+		vec3 diffuse = max(dot(normal, toLight), 0.0) * light.color * albedo;
+
+		float spec = 0.0;
+		if (dot(normal, toLight) > 0.0) {
+			if (blinn) {
+				// Blinn-Phong uses the vector halfway between the light and the camera. It needs
+				// a higher exponent to look like Phong
+				vec3 halfway = normalize(toLight + viewDir);
+				spec = pow(max(dot(normal, halfway), 0.0), material.shininess * 4.0);
+			} else {
+				vec3 reflected = reflect(-toLight, normal);
+				spec = pow(max(dot(viewDir, reflected), 0.0), material.shininess);
+			}
+		}
+		vec3 specular = spec * light.color * material.specular;
+
+		return ambient + strength * (diffuse + specular);
+	}
+
+	// shadeLights returns the light reflected towards the camera from every light
+	vec3 shadeLights(vec3 normal, vec3 position, vec3 albedo) {
+		normal = normalize(normal);
+		vec3 viewDir = normalize(viewPosition - position);
+
+		vec3 color = vec3(0.0);
+		if (environment) {
+			color = texture(irradianceMap, normal).rgb * albedo;
+		}
+		for (int i = 0; i < numLights && i < MAX_LIGHTS; i++) {
+			color += shadeLight(lights[i], normal, position, viewDir, albedo);
+		}
+		return color;
+	}
+`
@@ -0,0 +1,140 @@
+package engine
+
+import (
+	"unsafe"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/pkg/errors"
+)
+
+// lineVertex is one end of a debug line
+type lineVertex struct {
+	Position mgl32.Vec3
+	Color    mgl32.Vec3
+}
+
+// lineVertexSize is how many bytes each end of a line takes in the ring buffer
+const lineVertexSize = int(unsafe.Sizeof(lineVertex{}))
+
+// DebugLines collects colored lines during a frame and draws them all at once. They are rewritten
+// every frame so they are streamed through a RingBuffer
+type DebugLines struct {
+	program        uint32
+	viewProjection int32
+	vao            uint32
+	ring           *RingBuffer
+	vertices       []lineVertex
+}
+
+// NewDebugLines creates an empty set of lines with room for a few thousand a frame before its
+// buffer has to grow
+func NewDebugLines() (d *DebugLines, err error) {
+	program, err := NewProgram(lineVertexShaderSrc, lineFragShaderSrc)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create the debug line program")
+	}
+	Label(gl.PROGRAM, program, "debug lines")
+
+	d = &DebugLines{
+		program:        program,
+		viewProjection: Backend.GetUniformLocation(program, "viewProjection"),
+		vao:            GenVertexArray(),
+		ring:           NewRingBuffer(gl.ARRAY_BUFFER, 4096*2*lineVertexSize, RingFrames),
+	}
+	State.BindVertexArray(d.vao)
+	Backend.EnableVertexAttribArray(0)
+	Backend.EnableVertexAttribArray(1)
+	CheckError("NewDebugLines")
+	return d, nil
+}
+
+// Line adds a line from one point to another
+func (d *DebugLines) Line(from, to, color mgl32.Vec3) {
+	d.vertices = append(d.vertices, lineVertex{from, color}, lineVertex{to, color})
+}
+
+// Axes adds the x, y and z axes of transform in red, green and blue. They are size long before
+// the transform scales them
+func (d *DebugLines) Axes(transform mgl32.Mat4, size float32) {
+	origin := transform.Col(3).Vec3()
+	for i, color := range []mgl32.Vec3{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}} {
+		axis := transform.Col(i).Vec3()
+		d.Line(origin, origin.Add(axis.Mul(size)), color)
+	}
+}
+
+// Box adds the 12 edges of the box
+func (d *DebugLines) Box(b AABB, color mgl32.Vec3) {
+	corners := b.Corners()
+	// corners that differ along exactly one axis are joined by an edge
+	for i := range corners {
+		for axis := 0; axis < 3; axis++ {
+			if j := i | 1<<uint(axis); j != i {
+				d.Line(corners[i], corners[j], color)
+			}
+		}
+	}
+}
+
+// Draw draws every line added since the last Draw from the camera and starts collecting again
+func (d *DebugLines) Draw(camera Camera) (err error) {
+	defer func() { d.vertices = d.vertices[:0] }()
+	if len(d.vertices) == 0 {
+		return nil
+	}
+
+	offset, err := d.ring.Write(d.vertices)
+	if err != nil {
+		return errors.Wrap(err, "unable to stream the debug lines")
+	}
+
+	State.UseProgram(d.program)
+	viewProjection := camera.Projection.Mul4(camera.View)
+	Backend.UniformMatrix4fv(d.viewProjection, 1, false, &viewProjection[0])
+
+	// the lines land somewhere else in the ring every frame so the attributes are pointed at them
+	// each time
+	State.BindVertexArray(d.vao)
+	State.BindBuffer(gl.ARRAY_BUFFER, d.ring.ID)
+	Backend.VertexAttribPointer(0, 3, gl.FLOAT, false, int32(lineVertexSize), offset)
+	Backend.VertexAttribPointer(1, 3, gl.FLOAT, false, int32(lineVertexSize), offset+12)
+	DrawArrays(gl.LINES, 0, int32(len(d.vertices)))
+	d.ring.Next()
+	return nil
+}
+
+// Delete deletes the program and buffers
+func (d *DebugLines) Delete() {
+	d.ring.Delete()
+	DeleteVertexArray(d.vao)
+	DeleteProgram(d.program)
+}
+
+var lineVertexShaderSrc = `
+	#version 410
+
+	uniform mat4 viewProjection;
+
+	layout(location = 0) in vec3 vert;
+	layout(location = 1) in vec3 vertColor;
+
+	out vec3 fragColor;
+
+	void main() {
+		fragColor = vertColor;
+		gl_Position = viewProjection * vec4(vert, 1.0);
+	}
+` + "\x00"
+
+var lineFragShaderSrc = `
+	#version 410
+
+	in vec3 fragColor;
+
+	out vec4 outputColor;
+
+	void main() {
+		outputColor = vec4(fragColor, 1.0);
+	}
+` + "\x00"
@@ -0,0 +1,152 @@
+package engine
+
+import (
+	"io/fs"
+	"sync"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/pkg/errors"
+)
+
+// ErrLoaderClosed is what done is called with for loads that were dropped because the Loader was
+// closed before they started
+var ErrLoaderClosed = errors.New("the loader is closed")
+
+// Loader loads assets in the background. The slow cpu work (reading files, decoding images,
+// parsing meshes) happens on worker goroutines and only the openGL upload is handed to the
+// main thread, which runs a little of it each frame
+type Loader struct {
+	// queue holds the loads no worker has picked up yet. It grows as much as it needs to so Load
+	// never blocks the main thread while the workers are stuck waiting on it
+	mu     sync.Mutex
+	ready  *sync.Cond
+	queue  []loadJob
+	closed bool
+
+	workers sync.WaitGroup
+	pending sync.WaitGroup
+}
+
+// loadJob is a load waiting for a worker
+type loadJob struct {
+	decode func() (upload func(), err error)
+	done   func(err error)
+}
+
+// NewLoader creates a loader with the given number of worker goroutines
+func NewLoader(workers int) (l *Loader) {
+	l = &Loader{}
+	l.ready = sync.NewCond(&l.mu)
+
+	for i := 0; i < workers; i++ {
+		l.workers.Add(1)
+		go func() {
+			defer l.workers.Done()
+			for {
+				job, ok := l.take()
+				if !ok {
+					return
+				}
+				l.run(job)
+			}
+		}()
+	}
+	return l
+}
+
+// Load runs decode on a worker goroutine. decode does the cpu work and returns upload, which
+// finishes the job on the main thread. If decode fails then done is called with the error on
+// the main thread instead. Load never blocks, and once the loader is closed it calls done with
+// ErrLoaderClosed right away
+func (l *Loader) Load(decode func() (upload func(), err error), done func(err error)) {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		done(ErrLoaderClosed)
+		return
+	}
+	l.pending.Add(1)
+	l.queue = append(l.queue, loadJob{decode: decode, done: done})
+	l.mu.Unlock()
+	l.ready.Signal()
+}
+
+// take waits for the next job in the queue. ok is false once the loader is closed
+func (l *Loader) take() (job loadJob, ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for len(l.queue) == 0 && !l.closed {
+		l.ready.Wait()
+	}
+	if len(l.queue) == 0 {
+		return loadJob{}, false
+	}
+	job = l.queue[0]
+	l.queue = l.queue[1:]
+	return job, true
+}
+
+// run decodes the job and queues its upload for the main thread
+func (l *Loader) run(job loadJob) {
+	upload, err := job.decode()
+	DoAsync(func() {
+		defer l.pending.Done()
+		if err == nil {
+			upload()
+		}
+		job.done(err)
+	})
+}
+
+// LoadTexture decodes an image in the background and uploads it as a texture. done is called on
+// the main thread with the texture once it is ready
+func (l *Loader) LoadTexture(fsys fs.FS, file string, done func(t *Texture, err error)) {
+	var t *Texture
+	l.Load(
+		func() (func(), error) {
+			rgba, err := DecodeImage(fsys, file)
+			if err != nil {
+				return nil, err
+			}
+			return func() {
+				t = NewTexture(rgba)
+				Label(gl.TEXTURE, t.ID, file)
+			}, nil
+		},
+		func(err error) { done(t, err) },
+	)
+}
+
+// Wait blocks until everything that has been queued is loaded. It runs the uploads itself so it
+// has to be called on the main thread, for example to load everything up front behind a loading
+// screen
+func (l *Loader) Wait() {
+	// finished is set on the main thread by a call queued behind the last upload
+	finished := false
+	go func() {
+		l.pending.Wait()
+		DoAsync(func() { finished = true })
+	}()
+
+	for !finished {
+		mainThread.wait().run()
+	}
+}
+
+// Close stops the workers. Loads that haven't been started are dropped and their done is called
+// with ErrLoaderClosed, while the ones already being decoded are finished and uploaded before it
+// returns. Like Wait it has to be called on the main thread
+func (l *Loader) Close() {
+	l.mu.Lock()
+	dropped := l.queue
+	l.queue, l.closed = nil, true
+	l.mu.Unlock()
+	l.ready.Broadcast()
+
+	for _, job := range dropped {
+		job.done(ErrLoaderClosed)
+		l.pending.Done()
+	}
+	l.Wait()
+	l.workers.Wait()
+}
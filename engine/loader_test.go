@@ -0,0 +1,89 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestLoaderLoadDoesNotBlock(t *testing.T) {
+	l := NewLoader(2)
+
+	// far more loads than the main thread queue holds, all queued before anything runs the
+	// uploads like a scene loading everything in Init
+	const loads = 4 * 1024
+	uploaded, finished := 0, 0
+	for i := 0; i < loads; i++ {
+		l.Load(
+			func() (func(), error) { return func() { uploaded++ }, nil },
+			func(err error) {
+				if err != nil {
+					t.Errorf("load failed: %v", err)
+				}
+				finished++
+			},
+		)
+	}
+	l.Wait()
+	l.Close()
+
+	if uploaded != loads || finished != loads {
+		t.Errorf("uploaded %d and finished %d loads, want %d", uploaded, finished, loads)
+	}
+}
+
+func TestLoaderReportsDecodeErrors(t *testing.T) {
+	l := NewLoader(1)
+	defer l.Close()
+
+	var got error
+	l.Load(
+		func() (func(), error) { return nil, errors.New("bad image") },
+		func(err error) { got = err },
+	)
+	l.Wait()
+	if got == nil || got.Error() != "bad image" {
+		t.Errorf("done got %v, want the decode error", got)
+	}
+}
+
+func TestLoaderCloseFinishesEveryLoad(t *testing.T) {
+	l := NewLoader(1)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	var results []error
+	done := func(err error) { results = append(results, err) }
+
+	// hold the only worker in the first decode so the rest are still queued when we close
+	l.Load(
+		func() (func(), error) {
+			close(started)
+			<-release
+			return func() {}, nil
+		},
+		done,
+	)
+	<-started
+	l.Load(func() (func(), error) { return func() {}, nil }, func(err error) {
+		// the first dropped load lets the worker go so Close has an upload left to run
+		done(err)
+		close(release)
+	})
+	l.Load(func() (func(), error) { return func() {}, nil }, done)
+	l.Close()
+
+	want := []error{ErrLoaderClosed, ErrLoaderClosed, nil}
+	if len(results) != len(want) {
+		t.Fatalf("done was called %d times, want %d: %v", len(results), len(want), results)
+	}
+	for i := range want {
+		if results[i] != want[i] {
+			t.Errorf("load %d finished with %v, want %v", i, results[i], want[i])
+		}
+	}
+
+	l.Load(func() (func(), error) { return func() {}, nil }, done)
+	if results[len(results)-1] != ErrLoaderClosed {
+		t.Errorf("loading after Close got %v, want ErrLoaderClosed", results[len(results)-1])
+	}
+}
@@ -0,0 +1,107 @@
+package engine
+
+import (
+	"sync"
+	"time"
+)
+
+// mainThreadCall is a function waiting to be run on the main thread. done is closed once it has run
+// if someone is waiting on it
+type mainThreadCall struct {
+	f    func()
+	done chan struct{}
+}
+
+// run runs the call and lets anyone waiting on it know it is finished
+func (c mainThreadCall) run() {
+	c.f()
+	if c.done != nil {
+		close(c.done)
+	}
+}
+
+// mainThreadQueue holds the calls waiting for the main thread. It grows as much as it needs to so
+// queueing a call never blocks, even from the main thread itself
+type mainThreadQueue struct {
+	mu    sync.Mutex
+	ready *sync.Cond
+	calls []mainThreadCall
+}
+
+// newMainThreadQueue creates an empty queue
+func newMainThreadQueue() (q *mainThreadQueue) {
+	q = &mainThreadQueue{}
+	q.ready = sync.NewCond(&q.mu)
+	return q
+}
+
+// push adds a call to the end of the queue
+func (q *mainThreadQueue) push(call mainThreadCall) {
+	q.mu.Lock()
+	q.calls = append(q.calls, call)
+	q.mu.Unlock()
+	q.ready.Signal()
+}
+
+// pop takes the next call off the queue. ok is false if it is empty
+func (q *mainThreadQueue) pop() (call mainThreadCall, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.calls) == 0 {
+		return mainThreadCall{}, false
+	}
+	return q.take(), true
+}
+
+// wait takes the next call off the queue, waiting for one if it is empty
+func (q *mainThreadQueue) wait() (call mainThreadCall) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.calls) == 0 {
+		q.ready.Wait()
+	}
+	return q.take()
+}
+
+// take removes the first call. The lock must be held and the queue can't be empty
+func (q *mainThreadQueue) take() (call mainThreadCall) {
+	call = q.calls[0]
+	q.calls[0] = mainThreadCall{}
+	q.calls = q.calls[1:]
+	return call
+}
+
+// mainThread is the queue of calls waiting for the main thread. openGL can only be used from the
+// thread that owns the context, so other goroutines hand their openGL work to the app through here
+var mainThread = newMainThreadQueue()
+
+// Do runs f on the main thread and waits for it to finish. It is how other goroutines make openGL
+// calls. It must not be called from the main thread itself or it will wait forever
+func Do(f func()) {
+	done := make(chan struct{})
+	mainThread.push(mainThreadCall{f: f, done: done})
+	<-done
+}
+
+// DoAsync queues f to run on the main thread and returns right away. It never blocks so it is safe
+// to call from the main thread too, in which case f runs with the next batch of queued calls
+func DoAsync(f func()) {
+	mainThread.push(mainThreadCall{f: f})
+}
+
+// runMainThread runs queued calls until the queue is empty or the budget is used up. The rest wait
+// for the next frame so a burst of uploads doesn't stall rendering. A budget of 0 runs everything
+func runMainThread(budget time.Duration) {
+	start := time.Now()
+	for {
+		call, ok := mainThread.pop()
+		if !ok {
+			return
+		}
+		call.run()
+
+		if budget > 0 && time.Since(start) >= budget {
+			return
+		}
+	}
+}
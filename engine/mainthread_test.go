@@ -0,0 +1,65 @@
+package engine
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDoAsyncFromTheMainThread(t *testing.T) {
+	// far more calls than would fit in a fixed queue, queued by the thread that runs them
+	const calls = 4 * 1024
+	var order []int
+	for i := 0; i < calls; i++ {
+		i := i
+		DoAsync(func() { order = append(order, i) })
+	}
+	runMainThread(0)
+
+	if len(order) != calls {
+		t.Fatalf("ran %d calls, want %d", len(order), calls)
+	}
+	for i, got := range order {
+		if got != i {
+			t.Fatalf("call %d ran in position %d", got, i)
+		}
+	}
+}
+
+func TestDoWaitsForTheMainThread(t *testing.T) {
+	ran := false
+	returned := make(chan struct{})
+	go func() {
+		Do(func() { ran = true })
+		close(returned)
+	}()
+
+	select {
+	case <-returned:
+		t.Fatalf("Do returned before the main thread ran it")
+	case <-time.After(10 * time.Millisecond):
+	}
+	mainThread.wait().run()
+	<-returned
+	if !ran {
+		t.Errorf("Do returned without running f")
+	}
+}
+
+func TestRunMainThreadBudget(t *testing.T) {
+	ran := 0
+	for i := 0; i < 3; i++ {
+		DoAsync(func() {
+			ran++
+			time.Sleep(2 * time.Millisecond)
+		})
+	}
+	// the first call uses up the budget so the rest wait for the next frame
+	runMainThread(time.Millisecond)
+	if ran != 1 {
+		t.Errorf("ran %d calls within the budget, want 1", ran)
+	}
+	runMainThread(0)
+	if ran != 3 {
+		t.Errorf("ran %d calls in total, want 3", ran)
+	}
+}
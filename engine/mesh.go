@@ -0,0 +1,276 @@
+package engine
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/pkg/errors"
+)
+
+// The layout of the vertices Mesh.Vertices returns, in floats: position, uv, normal and tangent.
+// Use them to map the attributes in a vao
+const (
+	MeshStride         = 12
+	MeshPositionOffset = 0
+	MeshUVOffset       = 3
+	MeshNormalOffset   = 5
+	MeshTangentOffset  = 8
+)
+
+// Mesh is a list of triangles with everything lighting needs at each vertex. Load the positions and
+// uvs and let ComputeNormals and ComputeTangents fill in the rest
+type Mesh struct {
+	Positions []mgl32.Vec3
+	UVs       []mgl32.Vec2
+	Normals   []mgl32.Vec3
+	// Tangents point the way u increases along the surface. W is 1 or -1 and is the handedness of
+	// the bitangent: bitangent = w * cross(normal, tangent). Mirrored uvs flip it
+	Tangents []mgl32.Vec4
+	// Bitangents point the way v increases. Shaders usually rebuild them from the tangent's w
+	Bitangents []mgl32.Vec3
+	// Indices are the triangles, 3 vertices at a time. Without them every 3 vertices are a triangle
+	Indices []uint32
+}
+
+// NewMesh creates a mesh from vertices of 5 floats (x, y, z, u, v) like the textured cubes use.
+// indices can be nil when the vertices are already in triangle order
+func NewMesh(vertices []float32, indices []uint32) (m *Mesh, err error) {
+	if len(vertices)%5 != 0 {
+		return nil, errors.Errorf("mesh has %d floats which isn't a multiple of 5 (x, y, z, u, v)", len(vertices))
+	}
+
+	m = &Mesh{Indices: indices}
+	for i := 0; i < len(vertices); i += 5 {
+		m.Positions = append(m.Positions, mgl32.Vec3{vertices[i], vertices[i+1], vertices[i+2]})
+		m.UVs = append(m.UVs, mgl32.Vec2{vertices[i+3], vertices[i+4]})
+	}
+
+	count := len(m.Positions)
+	if indices != nil {
+		count = len(indices)
+		for _, index := range indices {
+			if int(index) >= len(m.Positions) {
+				return nil, errors.Errorf("mesh index %d is past the last of %d vertices", index, len(m.Positions))
+			}
+		}
+	}
+	if count%3 != 0 {
+		return nil, errors.Errorf("mesh has %d vertices in its triangles which isn't a multiple of 3", count)
+	}
+	return m, nil
+}
+
+// triangles returns the vertex indices of every triangle
+func (m *Mesh) triangles() (triangles [][3]uint32) {
+	if m.Indices == nil {
+		for i := 0; i+2 < len(m.Positions); i += 3 {
+			triangles = append(triangles, [3]uint32{uint32(i), uint32(i + 1), uint32(i + 2)})
+		}
+		return triangles
+	}
+	for i := 0; i+2 < len(m.Indices); i += 3 {
+		triangles = append(triangles, [3]uint32{m.Indices[i], m.Indices[i+1], m.Indices[i+2]})
+	}
+	return triangles
+}
+
+// cornerAngle returns the angle of a triangle at corner i. Weighting by it means a vertex isn't
+// pulled towards whichever side happens to be split into more triangles
+func cornerAngle(p [3]mgl32.Vec3, i int) float32 {
+	a := p[(i+1)%3].Sub(p[i])
+	b := p[(i+2)%3].Sub(p[i])
+	if a.Len() == 0 || b.Len() == 0 {
+		return 0
+	}
+	cos := a.Normalize().Dot(b.Normalize())
+	return float32(math.Acos(float64(mgl32.Clamp(cos, -1, 1))))
+}
+
+// ComputeNormals sets each vertex normal to the average of the triangles using it, weighted by the
+// angle of the triangle at the vertex. Triangles wind counter clockwise seen from the front.
+// Vertices only shared through the indices are smoothed, so give hard edges their own vertices
+func (m *Mesh) ComputeNormals() {
+	m.Normals = make([]mgl32.Vec3, len(m.Positions))
+	for _, tri := range m.triangles() {
+		p := [3]mgl32.Vec3{m.Positions[tri[0]], m.Positions[tri[1]], m.Positions[tri[2]]}
+		face := p[1].Sub(p[0]).Cross(p[2].Sub(p[0]))
+		if face.Len() == 0 {
+			continue
+		}
+		face = face.Normalize()
+		for i, v := range tri {
+			m.Normals[v] = m.Normals[v].Add(face.Mul(cornerAngle(p, i)))
+		}
+	}
+
+	for i, n := range m.Normals {
+		if n.Len() == 0 {
+			// only degenerate triangles use it so any direction will do
+			m.Normals[i] = mgl32.Vec3{0, 1, 0}
+			continue
+		}
+		m.Normals[i] = n.Normalize()
+	}
+}
+
+// ComputeTangents works out the tangent and bitangent at each vertex from the positions and uvs the
+// way MikkTSpace does: each triangle's tangents are projected onto the plane of the vertex normal
+// and averaged by corner angle, then the tangent is made perpendicular to the normal and the sign
+// of the bitangent is kept in its w. Normals are computed first if the mesh has none. Unlike
+// MikkTSpace vertices aren't split where the handedness changes, so give mirrored uvs their own
+// vertices. A mesh without a uv for every vertex gets a tangent perpendicular to each normal
+func (m *Mesh) ComputeTangents() {
+	if len(m.Normals) != len(m.Positions) {
+		m.ComputeNormals()
+	}
+
+	triangles := m.triangles()
+	if len(m.UVs) != len(m.Positions) {
+		// there is no direction to follow so every vertex falls back to perpendicular below
+		triangles = nil
+	}
+
+	tangents := make([]mgl32.Vec3, len(m.Positions))
+	bitangents := make([]mgl32.Vec3, len(m.Positions))
+	for _, tri := range triangles {
+		p := [3]mgl32.Vec3{m.Positions[tri[0]], m.Positions[tri[1]], m.Positions[tri[2]]}
+		uv := [3]mgl32.Vec2{m.UVs[tri[0]], m.UVs[tri[1]], m.UVs[tri[2]]}
+
+		edge1, edge2 := p[1].Sub(p[0]), p[2].Sub(p[0])
+		duv1, duv2 := uv[1].Sub(uv[0]), uv[2].Sub(uv[0])
+		r := duv1.X()*duv2.Y() - duv2.X()*duv1.Y()
+		if r == 0 {
+			// the uvs don't span an area so there is no direction to follow
+			continue
+		}
+
+		// solve edge = du*tangent + dv*bitangent for both edges
+		tangent := edge1.Mul(duv2.Y()).Sub(edge2.Mul(duv1.Y())).Mul(1 / r)
+		bitangent := edge2.Mul(duv1.X()).Sub(edge1.Mul(duv2.X())).Mul(1 / r)
+
+		for i, v := range tri {
+			n := m.Normals[v]
+			angle := cornerAngle(p, i)
+			tangents[v] = tangents[v].Add(projectOnPlane(tangent, n).Mul(angle))
+			bitangents[v] = bitangents[v].Add(projectOnPlane(bitangent, n).Mul(angle))
+		}
+	}
+
+	m.Tangents = make([]mgl32.Vec4, len(m.Positions))
+	m.Bitangents = make([]mgl32.Vec3, len(m.Positions))
+	for i, n := range m.Normals {
+		t := projectOnPlane(tangents[i], n)
+		if t.Len() < 1e-6 {
+			t = perpendicular(n)
+		}
+		t = t.Normalize()
+
+		w := float32(1)
+		if n.Cross(t).Dot(bitangents[i]) < 0 {
+			w = -1
+		}
+		m.Tangents[i] = t.Vec4(w)
+		m.Bitangents[i] = n.Cross(t).Mul(w)
+	}
+}
+
+// projectOnPlane removes the part of v along the unit normal n
+func projectOnPlane(v, n mgl32.Vec3) mgl32.Vec3 {
+	return v.Sub(n.Mul(n.Dot(v)))
+}
+
+// perpendicular returns any unit vector perpendicular to the unit vector n
+func perpendicular(n mgl32.Vec3) mgl32.Vec3 {
+	axis := mgl32.Vec3{1, 0, 0}
+	if math.Abs(float64(n.X())) > 0.9 {
+		axis = mgl32.Vec3{0, 1, 0}
+	}
+	return n.Cross(axis).Normalize()
+}
+
+// Vertices interleaves the mesh into MeshStride floats a vertex ready to load into a vbo. Normals
+// and tangents are computed if they haven't been. Vertices without a uv get (0, 0)
+func (m *Mesh) Vertices() (vertices []float32) {
+	if len(m.Tangents) != len(m.Positions) {
+		m.ComputeTangents()
+	}
+
+	vertices = make([]float32, 0, len(m.Positions)*MeshStride)
+	for i, p := range m.Positions {
+		var uv mgl32.Vec2
+		if i < len(m.UVs) {
+			uv = m.UVs[i]
+		}
+		n, t := m.Normals[i], m.Tangents[i]
+		vertices = append(vertices,
+			p.X(), p.Y(), p.Z(),
+			uv.X(), uv.Y(),
+			n.X(), n.Y(), n.Z(),
+			t.X(), t.Y(), t.Z(), t.W(),
+		)
+	}
+	return vertices
+}
+
+// NewSphereMesh creates a sphere of radius 1 around the origin split into segments around the
+// middle and rings from top to bottom. The uvs wrap around it once. Its normals point straight out
+// so it is smooth even across the seam where the uvs wrap
+func NewSphereMesh(segments, rings int) (m *Mesh) {
+	m = &Mesh{}
+	for r := 0; r <= rings; r++ {
+		theta := math.Pi * float64(r) / float64(rings)
+		for s := 0; s <= segments; s++ {
+			phi := 2 * math.Pi * float64(s) / float64(segments)
+			p := mgl32.Vec3{
+				float32(math.Sin(theta) * math.Cos(phi)),
+				float32(math.Cos(theta)),
+				float32(math.Sin(theta) * math.Sin(phi)),
+			}
+			m.Positions = append(m.Positions, p)
+			m.Normals = append(m.Normals, p)
+			m.UVs = append(m.UVs, mgl32.Vec2{float32(s) / float32(segments), 1 - float32(r)/float32(rings)})
+		}
+	}
+
+	// each quad between two rings is two triangles, except at the poles where one side is a point
+	for r := 0; r < rings; r++ {
+		for s := 0; s < segments; s++ {
+			a := uint32(r*(segments+1) + s)
+			b := a + uint32(segments+1)
+			if r != 0 {
+				m.Indices = append(m.Indices, a, a+1, b)
+			}
+			if r != rings-1 {
+				m.Indices = append(m.Indices, a+1, b+1, b)
+			}
+		}
+	}
+	return m
+}
+
+// NewCubeMesh creates a cube from -1 to 1 on every axis. Each face has its own 4 vertices so the
+// edges are sharp, and the whole texture is stretched over each face
+func NewCubeMesh() (m *Mesh) {
+	// each face's u and v axes are chosen so u x v points out of the cube
+	faces := []struct{ normal, u, v mgl32.Vec3 }{
+		{mgl32.Vec3{1, 0, 0}, mgl32.Vec3{0, 0, -1}, mgl32.Vec3{0, 1, 0}},
+		{mgl32.Vec3{-1, 0, 0}, mgl32.Vec3{0, 0, 1}, mgl32.Vec3{0, 1, 0}},
+		{mgl32.Vec3{0, 1, 0}, mgl32.Vec3{1, 0, 0}, mgl32.Vec3{0, 0, -1}},
+		{mgl32.Vec3{0, -1, 0}, mgl32.Vec3{1, 0, 0}, mgl32.Vec3{0, 0, 1}},
+		{mgl32.Vec3{0, 0, 1}, mgl32.Vec3{1, 0, 0}, mgl32.Vec3{0, 1, 0}},
+		{mgl32.Vec3{0, 0, -1}, mgl32.Vec3{-1, 0, 0}, mgl32.Vec3{0, 1, 0}},
+	}
+	corners := []mgl32.Vec2{{0, 0}, {1, 0}, {1, 1}, {0, 1}}
+
+	m = &Mesh{}
+	for _, f := range faces {
+		first := uint32(len(m.Positions))
+		for _, c := range corners {
+			m.Positions = append(m.Positions, f.normal.Add(f.u.Mul(2*c.X()-1)).Add(f.v.Mul(2*c.Y()-1)))
+			m.Normals = append(m.Normals, f.normal)
+			m.UVs = append(m.UVs, c)
+		}
+		m.Indices = append(m.Indices, first, first+1, first+2, first+2, first+3, first)
+	}
+	return m
+}
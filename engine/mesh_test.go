@@ -0,0 +1,160 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// closeVec3 reports whether two vectors are the same within float error
+func closeVec3(a, b mgl32.Vec3) bool {
+	return a.ApproxEqualThreshold(b, 1e-5)
+}
+
+// quad is a unit square in the xy plane facing +z with the uvs following x and y
+func quad() *Mesh {
+	return &Mesh{
+		Positions: []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {1, 1, 0}, {0, 1, 0}},
+		UVs:       []mgl32.Vec2{{0, 0}, {1, 0}, {1, 1}, {0, 1}},
+		Indices:   []uint32{0, 1, 2, 2, 3, 0},
+	}
+}
+
+func TestComputeNormalsWinding(t *testing.T) {
+	m := quad()
+	m.ComputeNormals()
+	for i, n := range m.Normals {
+		if !closeVec3(n, mgl32.Vec3{0, 0, 1}) {
+			t.Errorf("normal %d of a counter clockwise quad is %v, want +z", i, n)
+		}
+	}
+
+	// clockwise triangles face the other way
+	m.Indices = []uint32{0, 2, 1, 2, 0, 3}
+	m.ComputeNormals()
+	for i, n := range m.Normals {
+		if !closeVec3(n, mgl32.Vec3{0, 0, -1}) {
+			t.Errorf("normal %d of a clockwise quad is %v, want -z", i, n)
+		}
+	}
+}
+
+func TestComputeNormalsWeightsByAngle(t *testing.T) {
+	// a cube sharing its 8 corners. Each face is split into two triangles so a corner is in one
+	// or two triangles of a face, but always gets 90 degrees of it, which points it straight out
+	// of the corner
+	m := &Mesh{}
+	for i := 0; i < 8; i++ {
+		m.Positions = append(m.Positions, mgl32.Vec3{float32(i&1)*2 - 1, float32(i>>1&1)*2 - 1, float32(i>>2&1)*2 - 1})
+	}
+	m.Indices = []uint32{
+		1, 3, 7, 7, 5, 1, // +x
+		0, 4, 6, 6, 2, 0, // -x
+		2, 6, 7, 7, 3, 2, // +y
+		0, 1, 5, 5, 4, 0, // -y
+		4, 5, 7, 7, 6, 4, // +z
+		0, 2, 3, 3, 1, 0, // -z
+	}
+	m.ComputeNormals()
+	for i, n := range m.Normals {
+		if want := m.Positions[i].Normalize(); !closeVec3(n, want) {
+			t.Errorf("normal at corner %v is %v, want %v", m.Positions[i], n, want)
+		}
+	}
+}
+
+func TestComputeNormalsDegenerate(t *testing.T) {
+	m := &Mesh{Positions: []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {2, 0, 0}}}
+	m.ComputeNormals()
+	for i, n := range m.Normals {
+		if n != (mgl32.Vec3{0, 1, 0}) {
+			t.Errorf("normal %d of a flat triangle is %v, want the fallback +y", i, n)
+		}
+	}
+}
+
+func TestComputeTangentsCubeFaces(t *testing.T) {
+	m := NewCubeMesh()
+	m.ComputeTangents()
+
+	// NewCubeMesh lays u and v along these axes on each face, in order
+	faces := []struct{ u, v mgl32.Vec3 }{
+		{mgl32.Vec3{0, 0, -1}, mgl32.Vec3{0, 1, 0}},
+		{mgl32.Vec3{0, 0, 1}, mgl32.Vec3{0, 1, 0}},
+		{mgl32.Vec3{1, 0, 0}, mgl32.Vec3{0, 0, -1}},
+		{mgl32.Vec3{1, 0, 0}, mgl32.Vec3{0, 0, 1}},
+		{mgl32.Vec3{1, 0, 0}, mgl32.Vec3{0, 1, 0}},
+		{mgl32.Vec3{-1, 0, 0}, mgl32.Vec3{0, 1, 0}},
+	}
+	for i := range m.Positions {
+		face := faces[i/4]
+		tangent, n := m.Tangents[i], m.Normals[i]
+		if !closeVec3(tangent.Vec3(), face.u) || !closeVec3(m.Bitangents[i], face.v) {
+			t.Errorf("face %d vertex %d has tangent %v and bitangent %v, want %v and %v", i/4, i, tangent, m.Bitangents[i], face.u, face.v)
+		}
+		// the uvs aren't mirrored so the bitangent is on the right hand side
+		if tangent.W() != 1 {
+			t.Errorf("face %d vertex %d has handedness %v, want 1", i/4, i, tangent.W())
+		}
+		if !closeVec3(n.Cross(tangent.Vec3()).Mul(tangent.W()), m.Bitangents[i]) {
+			t.Errorf("face %d vertex %d bitangent %v doesn't match w·(n×t)", i/4, i, m.Bitangents[i])
+		}
+	}
+}
+
+func TestComputeTangentsMirroredUVs(t *testing.T) {
+	m := quad()
+	// flip u so it runs along -x while v still runs along +y
+	for i, uv := range m.UVs {
+		m.UVs[i] = mgl32.Vec2{1 - uv.X(), uv.Y()}
+	}
+	m.ComputeTangents()
+
+	for i, tangent := range m.Tangents {
+		if !closeVec3(tangent.Vec3(), mgl32.Vec3{-1, 0, 0}) || tangent.W() != -1 {
+			t.Errorf("tangent %d of the mirrored quad is %v, want (-1, 0, 0) with handedness -1", i, tangent)
+		}
+		if !closeVec3(m.Bitangents[i], mgl32.Vec3{0, 1, 0}) {
+			t.Errorf("bitangent %d of the mirrored quad is %v, want +y", i, m.Bitangents[i])
+		}
+	}
+}
+
+func TestComputeTangentsWithoutUVs(t *testing.T) {
+	m := quad()
+	m.UVs = nil
+	m.ComputeTangents()
+
+	for i, tangent := range m.Tangents {
+		if length := tangent.Vec3().Len(); length < 0.999 || length > 1.001 {
+			t.Errorf("tangent %d is %v, want unit length", i, tangent)
+		}
+		if dot := tangent.Vec3().Dot(m.Normals[i]); dot > 1e-5 || dot < -1e-5 {
+			t.Errorf("tangent %d is %v which isn't perpendicular to the normal %v", i, tangent, m.Normals[i])
+		}
+	}
+
+	vertices := m.Vertices()
+	if len(vertices) != 4*MeshStride {
+		t.Fatalf("got %d floats, want %d", len(vertices), 4*MeshStride)
+	}
+	for i := range m.Positions {
+		if u, v := vertices[i*MeshStride+MeshUVOffset], vertices[i*MeshStride+MeshUVOffset+1]; u != 0 || v != 0 {
+			t.Errorf("vertex %d has uv (%v, %v), want (0, 0)", i, u, v)
+		}
+	}
+}
+
+func TestMeshVerticesLayout(t *testing.T) {
+	m := quad()
+	vertices := m.Vertices()
+
+	// the third corner: position, uv, normal and tangent in order
+	v := vertices[2*MeshStride : 3*MeshStride]
+	want := []float32{1, 1, 0, 1, 1, 0, 0, 1, 1, 0, 0, 1}
+	for i := range want {
+		if d := v[i] - want[i]; d > 1e-5 || d < -1e-5 {
+			t.Fatalf("vertex is %v, want %v", v, want)
+		}
+	}
+}
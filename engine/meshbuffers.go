@@ -0,0 +1,116 @@
+package engine
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// The attribute locations MeshBuffers puts each part of a vertex in. Shaders drawing them declare
+// their inputs with layout(location = ...) to match
+const (
+	MeshPositionLocation = 0
+	MeshUVLocation       = 1
+	MeshNormalLocation   = 2
+	MeshTangentLocation  = 3
+)
+
+// MeshBuffers is a mesh loaded into a vao and its buffers, ready to draw
+type MeshBuffers struct {
+	VAO uint32
+	VBO uint32
+	// EBO is 0 when the mesh has no indices
+	EBO uint32
+	// Count is how many vertices (or indices) are drawn
+	Count int32
+
+	// bounds and sphere are around the mesh's positions
+	bounds AABB
+	sphere Sphere
+	// mesh is kept so rays can be tested against its triangles
+	mesh *Mesh
+}
+
+// NewMeshBuffers loads the mesh into a new vao. It has to be called on the main thread
+func NewMeshBuffers(m *Mesh) (b *MeshBuffers) {
+	vertices := m.Vertices()
+	b = &MeshBuffers{
+		VAO:    GenVertexArray(),
+		VBO:    GenBuffer(),
+		Count:  int32(len(m.Positions)),
+		bounds: m.Bounds(),
+		sphere: m.BoundingSphere(),
+		mesh:   m,
+	}
+
+	State.BindVertexArray(b.VAO)
+	State.BindBuffer(gl.ARRAY_BUFFER, b.VBO)
+	Backend.BufferData(gl.ARRAY_BUFFER, 4*len(vertices), vertices, gl.STATIC_DRAW)
+	if m.Indices != nil {
+		b.EBO = GenBuffer()
+		b.Count = int32(len(m.Indices))
+		State.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, b.EBO)
+		Backend.BufferData(gl.ELEMENT_ARRAY_BUFFER, 4*len(m.Indices), m.Indices, gl.STATIC_DRAW)
+	}
+
+	attributes := []struct {
+		location uint32
+		size     int32
+		offset   int
+	}{
+		{MeshPositionLocation, 3, MeshPositionOffset},
+		{MeshUVLocation, 2, MeshUVOffset},
+		{MeshNormalLocation, 3, MeshNormalOffset},
+		{MeshTangentLocation, 4, MeshTangentOffset},
+	}
+	for _, a := range attributes {
+		Backend.VertexAttribPointer(a.location, a.size, gl.FLOAT, false, MeshStride*4, a.offset*4)
+		Backend.EnableVertexAttribArray(a.location)
+	}
+	CheckError("NewMeshBuffers")
+
+	return b
+}
+
+// Draw draws the mesh's triangles with whatever program is in use
+func (b *MeshBuffers) Draw() {
+	State.BindVertexArray(b.VAO)
+	if b.EBO != 0 {
+		DrawElements(gl.TRIANGLES, b.Count, gl.UNSIGNED_INT, 0)
+	} else {
+		DrawArrays(gl.TRIANGLES, 0, b.Count)
+	}
+}
+
+// Bounds returns the box around the mesh
+func (b *MeshBuffers) Bounds() AABB {
+	return b.bounds
+}
+
+// BoundingSphere returns the sphere around the mesh
+func (b *MeshBuffers) BoundingSphere() Sphere {
+	return b.sphere
+}
+
+// IntersectRay returns the closest of the mesh's triangles the ray hits, see Mesh.IntersectRay
+func (b *MeshBuffers) IntersectRay(r Ray) (t float32, triangle int, ok bool) {
+	return b.mesh.IntersectRay(r)
+}
+
+// DrawInstanced draws instances copies of the mesh's triangles in one call. Attach an
+// InstanceBuffer to the vao to place each one
+func (b *MeshBuffers) DrawInstanced(instances int32) {
+	State.BindVertexArray(b.VAO)
+	if b.EBO != 0 {
+		DrawElementsInstanced(gl.TRIANGLES, b.Count, gl.UNSIGNED_INT, 0, instances)
+	} else {
+		DrawArraysInstanced(gl.TRIANGLES, 0, b.Count, instances)
+	}
+}
+
+// Delete deletes the vao and buffers
+func (b *MeshBuffers) Delete() {
+	DeleteVertexArray(b.VAO)
+	DeleteBuffer(b.VBO)
+	if b.EBO != 0 {
+		DeleteBuffer(b.EBO)
+	}
+}
@@ -0,0 +1,149 @@
+package engine
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/pkg/errors"
+)
+
+// MaxSamples returns the most samples per pixel the driver supports for multisampled framebuffers
+func MaxSamples() int {
+	var samples int32
+	Backend.GetIntegerv(gl.MAX_SAMPLES, &samples)
+	return int(samples)
+}
+
+// MaxTextureSamples returns the most samples per pixel the driver supports for multisampled color
+// and depth textures, which is what a Multisample renders into. It can be less than MaxSamples
+func MaxTextureSamples() int {
+	var color, depth int32
+	Backend.GetIntegerv(gl.MAX_COLOR_TEXTURE_SAMPLES, &color)
+	Backend.GetIntegerv(gl.MAX_DEPTH_TEXTURE_SAMPLES, &depth)
+	if depth < color {
+		return int(depth)
+	}
+	return int(color)
+}
+
+// CheckSamples returns an error if the driver can't multisample with that many samples, either in
+// a framebuffer or in the textures a Multisample renders into. 0 is no multisampling and is always
+// fine
+func CheckSamples(samples int) (err error) {
+	if samples < 0 {
+		return errors.Errorf("%d samples is negative", samples)
+	}
+	if most := MaxSamples(); samples > most {
+		return errors.Errorf("%d samples is more than the %d the driver supports", samples, most)
+	}
+	if most := MaxTextureSamples(); samples > most {
+		return errors.Errorf("%d samples is more than the %d the driver supports for textures", samples, most)
+	}
+	return nil
+}
+
+// WindowSamples returns how many samples each pixel of the window has. Drivers can give us fewer
+// than Config.Samples asked for
+func WindowSamples() int {
+	State.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	var samples int32
+	Backend.GetIntegerv(gl.SAMPLES, &samples)
+	return int(samples)
+}
+
+// SetMultisample turns multisampling of whatever we draw on or off so we can compare the two. The
+// window only has samples to use if it was created with Config.Samples
+func SetMultisample(enabled bool) {
+	if enabled {
+		State.Enable(gl.MULTISAMPLE)
+	} else {
+		State.Disable(gl.MULTISAMPLE)
+	}
+}
+
+// NewMultisampleTexture creates an empty multisampled texture to render into (gl.RGBA8,
+// gl.RGBA16F, gl.DEPTH_COMPONENT24, ...). Shaders can't filter it, resolve it into a normal
+// texture with a Multisample first
+func NewMultisampleTexture(width, height, samples int, internalFormat uint32) (t *Texture) {
+	t = &Texture{
+		Width:   width,
+		Height:  height,
+		Samples: samples,
+	}
+
+	Backend.GenTextures(1, &t.ID)
+	Resources.Track(ResourceTexture, t.ID)
+	t.Bind(gl.TEXTURE0)
+	// fixed sample locations keeps the samples in the same place in every pixel, which
+	// framebuffers mixing textures and other attachments need
+	Backend.TexImage2DMultisample(gl.TEXTURE_2D_MULTISAMPLE, int32(samples), internalFormat, int32(width), int32(height), true)
+	CheckError("NewMultisampleTexture")
+
+	return t
+}
+
+// Multisample is a framebuffer with multisampled color and depth. Render into it like any other
+// framebuffer and then Resolve it, which averages the samples of each pixel into a normal one
+type Multisample struct {
+	Framebuffer *Framebuffer
+	Color       *Texture
+	Depth       *Texture
+	Samples     int
+}
+
+// NewMultisample creates a multisampled framebuffer width by height pixels with samples samples
+// per pixel. colorFormat is how the color is stored (gl.RGBA8, gl.RGBA16F, ...)
+func NewMultisample(width, height, samples int, colorFormat uint32) (m *Multisample, err error) {
+	err = CheckSamples(samples)
+	if err != nil {
+		return nil, err
+	}
+	if samples == 0 {
+		return nil, errors.New("a multisampled framebuffer needs at least 1 sample")
+	}
+
+	m = &Multisample{
+		Framebuffer: NewFramebuffer(width, height),
+		Color:       NewMultisampleTexture(width, height, samples, colorFormat),
+		Depth:       NewMultisampleTexture(width, height, samples, gl.DEPTH_COMPONENT24),
+		Samples:     samples,
+	}
+	m.Framebuffer.AttachTexture(gl.COLOR_ATTACHMENT0, m.Color)
+	m.Framebuffer.AttachTexture(gl.DEPTH_ATTACHMENT, m.Depth)
+	err = m.Framebuffer.Check()
+	State.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	if err != nil {
+		m.Delete()
+		return nil, errors.Wrapf(err, "unable to create a framebuffer with %d samples", samples)
+	}
+	return m, nil
+}
+
+// Bind starts rendering into the multisampled framebuffer
+func (m *Multisample) Bind() {
+	m.Framebuffer.Bind()
+}
+
+// Resolve stops rendering into the multisampled framebuffer and averages it into target, which has
+// to be the same size. A nil target resolves into the window. mask is which attachments to resolve
+// (gl.COLOR_BUFFER_BIT, gl.DEPTH_BUFFER_BIT or both), the target needs to have them too
+func (m *Multisample) Resolve(target *Framebuffer, mask uint32) {
+	m.Framebuffer.Unbind()
+
+	var destination uint32
+	if target != nil {
+		destination = target.ID
+	}
+	State.BindFramebuffer(gl.READ_FRAMEBUFFER, m.Framebuffer.ID)
+	State.BindFramebuffer(gl.DRAW_FRAMEBUFFER, destination)
+	width, height := int32(m.Framebuffer.Width), int32(m.Framebuffer.Height)
+	// depth can only be copied with nearest, which is all a resolve of the same size needs anyway
+	Backend.BlitFramebuffer(0, 0, width, height, 0, 0, width, height, mask, gl.NEAREST)
+	State.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	CheckError("Multisample.Resolve")
+}
+
+// Delete deletes the framebuffer and its textures
+func (m *Multisample) Delete() {
+	m.Framebuffer.Delete()
+	m.Color.Delete()
+	m.Depth.Delete()
+}
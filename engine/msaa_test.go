@@ -0,0 +1,34 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+func TestCheckSamplesTextureLimits(t *testing.T) {
+	fake := useFakeGL(t)
+
+	if err := CheckSamples(8); err != nil {
+		t.Fatalf("8 samples within every limit failed: %v", err)
+	}
+	// drivers can allow more samples in renderbuffers than in multisampled textures
+	for _, limit := range []uint32{gl.MAX_COLOR_TEXTURE_SAMPLES, gl.MAX_DEPTH_TEXTURE_SAMPLES} {
+		fake.Integers[gl.MAX_COLOR_TEXTURE_SAMPLES] = 8
+		fake.Integers[gl.MAX_DEPTH_TEXTURE_SAMPLES] = 8
+		fake.Integers[limit] = 4
+
+		if err := CheckSamples(4); err != nil {
+			t.Errorf("4 samples with a limit of 4 failed: %v", err)
+		}
+		err := CheckSamples(8)
+		if err == nil || !strings.Contains(err.Error(), "the 4 the driver supports for textures") {
+			t.Errorf("8 samples with a limit of 4 gave %v", err)
+		}
+		if m, err := NewMultisample(64, 64, 8, gl.RGBA8); err == nil {
+			m.Delete()
+			t.Errorf("made a multisampled framebuffer with more samples than its textures can have")
+		}
+	}
+}
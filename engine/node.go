@@ -0,0 +1,319 @@
+package engine
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/pkg/errors"
+)
+
+// Node is one thing in a scene graph. It is placed relative to its parent with a position,
+// rotation and scale, so moving a node moves everything attached to it. The world matrices are
+// cached and only rebuilt after the node or something above it moves
+type Node struct {
+	Name string
+	// Draw draws the node's mesh once its world matrix has been uploaded by Render. Nodes without
+	// one only group their children
+	Draw func()
+	// Mesh and Material are what a Renderer draws the node with when it is submitted
+	Mesh     Drawable
+	Material *RenderMaterial
+
+	position mgl32.Vec3
+	rotation mgl32.Quat
+	scale    mgl32.Vec3
+
+	parent   *Node
+	children []*Node
+
+	// local and world are only rebuilt when they are dirty
+	local      mgl32.Mat4
+	world      mgl32.Mat4
+	localDirty bool
+	worldDirty bool
+}
+
+// NewNode creates a node at the origin with no rotation and a scale of 1
+func NewNode(name string) (n *Node) {
+	return &Node{
+		Name:       name,
+		rotation:   mgl32.QuatIdent(),
+		scale:      mgl32.Vec3{1, 1, 1},
+		local:      mgl32.Ident4(),
+		world:      mgl32.Ident4(),
+		localDirty: true,
+		worldDirty: true,
+	}
+}
+
+// Position returns where the node is relative to its parent
+func (n *Node) Position() mgl32.Vec3 {
+	return n.position
+}
+
+// SetPosition moves the node to a position relative to its parent
+func (n *Node) SetPosition(position mgl32.Vec3) {
+	n.position = position
+	n.moved()
+}
+
+// Translate moves the node by offset in its parent's space
+func (n *Node) Translate(offset mgl32.Vec3) {
+	n.SetPosition(n.position.Add(offset))
+}
+
+// Rotation returns how the node is rotated relative to its parent
+func (n *Node) Rotation() mgl32.Quat {
+	return n.rotation
+}
+
+// SetRotation sets how the node is rotated relative to its parent
+func (n *Node) SetRotation(rotation mgl32.Quat) {
+	n.rotation = rotation.Normalize()
+	n.moved()
+}
+
+// Rotate turns the node by rotation on top of how it is already rotated
+func (n *Node) Rotate(rotation mgl32.Quat) {
+	n.SetRotation(rotation.Mul(n.rotation))
+}
+
+// Scale returns how much the node is scaled along each of its axes
+func (n *Node) Scale() mgl32.Vec3 {
+	return n.scale
+}
+
+// SetScale sets how much the node is scaled along each of its axes
+func (n *Node) SetScale(scale mgl32.Vec3) {
+	n.scale = scale
+	n.moved()
+}
+
+// Local returns the matrix that places the node in its parent's space: scale, then rotate, then
+// move
+func (n *Node) Local() mgl32.Mat4 {
+	if n.localDirty {
+		translate := mgl32.Translate3D(n.position.X(), n.position.Y(), n.position.Z())
+		scale := mgl32.Scale3D(n.scale.X(), n.scale.Y(), n.scale.Z())
+		n.local = translate.Mul4(n.rotation.Mat4()).Mul4(scale)
+		n.localDirty = false
+	}
+	return n.local
+}
+
+// World returns the matrix that places the node in the world, which is every local matrix from
+// the root down to it
+func (n *Node) World() mgl32.Mat4 {
+	if n.worldDirty {
+		if n.parent != nil {
+			n.world = n.parent.World().Mul4(n.Local())
+		} else {
+			n.world = n.Local()
+		}
+		n.worldDirty = false
+	}
+	return n.world
+}
+
+// WorldPosition returns where the node is in the world
+func (n *Node) WorldPosition() mgl32.Vec3 {
+	return n.World().Col(3).Vec3()
+}
+
+// moved marks the local matrix as dirty along with the world matrix of the node and everything
+// below it
+func (n *Node) moved() {
+	n.localDirty = true
+	n.invalidateWorld()
+}
+
+// invalidateWorld marks the world matrix of the node and everything below it as dirty. A node that
+// is already dirty has dirty children too so we can stop there
+func (n *Node) invalidateWorld() {
+	if n.worldDirty {
+		return
+	}
+	n.worldDirty = true
+	for _, child := range n.children {
+		child.invalidateWorld()
+	}
+}
+
+// WorldBounds returns the box around the node's mesh in the world. ok is false when the node has no
+// mesh or its mesh doesn't know its bounds
+func (n *Node) WorldBounds() (b AABB, ok bool) {
+	bounded, ok := n.Mesh.(Bounded)
+	if !ok {
+		return b, false
+	}
+	return bounded.Bounds().Transform(n.World()), true
+}
+
+// TreeBounds returns the box in the world around the meshes of the node and everything under it.
+// ok is false when none of them have bounds
+func (n *Node) TreeBounds() (b AABB, ok bool) {
+	b = NewAABB(nil)
+	n.Walk(func(node *Node) bool {
+		if bounds, bounded := node.WorldBounds(); bounded {
+			b = b.Union(bounds)
+			ok = true
+		}
+		return true
+	})
+	return b, ok
+}
+
+// Parent returns the node this one is attached to or nil for a root
+func (n *Node) Parent() *Node {
+	return n.parent
+}
+
+// Children returns the nodes attached to this one. The slice belongs to the node so don't change it
+func (n *Node) Children() []*Node {
+	return n.children
+}
+
+// Attach attaches child to the node, taking it off whatever it was attached to before. The
+// child keeps its local transform so it moves along with its new parent
+func (n *Node) Attach(child *Node) (err error) {
+	for ancestor := n; ancestor != nil; ancestor = ancestor.parent {
+		if ancestor == child {
+			return errors.Errorf("unable to attach %q to %q: it would be its own ancestor", child.Name, n.Name)
+		}
+	}
+
+	child.Detach()
+	child.parent = n
+	n.children = append(n.children, child)
+	child.worldDirty = false
+	child.invalidateWorld()
+	return nil
+}
+
+// Detach takes the node off its parent, making it the root of its own graph
+func (n *Node) Detach() {
+	if n.parent == nil {
+		return
+	}
+	siblings := n.parent.children
+	for i, sibling := range siblings {
+		if sibling == n {
+			n.parent.children = append(siblings[:i], siblings[i+1:]...)
+			break
+		}
+	}
+	n.parent = nil
+	n.worldDirty = false
+	n.invalidateWorld()
+}
+
+// Reparent attaches the node to parent (nil detaches it) while keeping it where it is in the
+// world, so only its local transform changes. Shear that can't be split back into a position,
+// rotation and scale is lost. A parent scaled to nothing on an axis can't be undone so the node
+// is left where it is with an error
+func (n *Node) Reparent(parent *Node) (err error) {
+	world := n.World()
+	if parent == nil {
+		n.Detach()
+	} else {
+		parentWorld := parent.World()
+		if parentWorld.Det() == 0 {
+			return errors.Errorf("unable to reparent %q to %q: it is scaled to nothing", n.Name, parent.Name)
+		}
+		err = parent.Attach(n)
+		if err != nil {
+			return err
+		}
+		world = parentWorld.Inv().Mul4(world)
+	}
+	n.setLocal(world)
+	return nil
+}
+
+// setLocal splits a local matrix back into the position, rotation and scale that make it. A
+// matrix scaled to nothing on an axis has no rotation left to find so the node keeps its own
+func (n *Node) setLocal(local mgl32.Mat4) {
+	scale := mgl32.Vec3{local.Col(0).Vec3().Len(), local.Col(1).Vec3().Len(), local.Col(2).Vec3().Len()}
+	n.position = local.Col(3).Vec3()
+	if scale.X() == 0 || scale.Y() == 0 || scale.Z() == 0 {
+		n.scale = scale
+		n.moved()
+		return
+	}
+
+	rotation := mgl32.Mat4FromCols(
+		local.Col(0).Mul(1/scale.X()),
+		local.Col(1).Mul(1/scale.Y()),
+		local.Col(2).Mul(1/scale.Z()),
+		mgl32.Vec4{0, 0, 0, 1},
+	)
+
+	// a mirrored matrix has a negative determinant, put the flip back into the scale
+	if rotation.Det() < 0 {
+		scale[0] = -scale[0]
+		rotation = mgl32.Mat4FromCols(rotation.Col(0).Mul(-1), rotation.Col(1), rotation.Col(2), rotation.Col(3))
+	}
+
+	n.rotation = mgl32.Mat4ToQuat(rotation).Normalize()
+	n.scale = scale
+	n.moved()
+}
+
+// Find returns the first node named name in the graph under this one (including itself) or nil
+func (n *Node) Find(name string) *Node {
+	if n.Name == name {
+		return n
+	}
+	for _, child := range n.children {
+		if found := child.Find(name); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// Walk calls visit for the node and everything under it, parents before their children. Returning
+// false from visit skips the node's children
+func (n *Node) Walk(visit func(n *Node) bool) {
+	if !visit(n) {
+		return
+	}
+	for _, child := range n.children {
+		child.Walk(visit)
+	}
+}
+
+// Render draws the node and everything under it, uploading each node's world matrix to the
+// model uniforms before calling its Draw. The program the uniforms belong to has to be in use
+func (n *Node) Render(u *ModelUniforms) {
+	n.Walk(func(node *Node) bool {
+		if node.Draw != nil {
+			u.Upload(node.World())
+			node.Draw()
+		}
+		return true
+	})
+}
+
+// ModelUniforms are the locations of a program's model and normalMatrix uniforms. Programs
+// without a normalMatrix only get the model
+type ModelUniforms struct {
+	model        int32
+	normalMatrix int32
+}
+
+// NewModelUniforms looks up the model and normalMatrix uniforms in the program
+func NewModelUniforms(program uint32) (u *ModelUniforms) {
+	return &ModelUniforms{
+		model:        Backend.GetUniformLocation(program, "model"),
+		normalMatrix: Backend.GetUniformLocation(program, "normalMatrix"),
+	}
+}
+
+// Upload sends a model matrix and the normal matrix that goes with it to the program in use
+func (u *ModelUniforms) Upload(model mgl32.Mat4) {
+	Backend.UniformMatrix4fv(u.model, 1, false, &model[0])
+	if u.normalMatrix >= 0 {
+		normal := NormalMatrix(model)
+		Backend.UniformMatrix3fv(u.normalMatrix, 1, false, &normal[0])
+	}
+	CheckError("ModelUniforms.Upload")
+}
@@ -0,0 +1,177 @@
+package engine
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// hasNaN reports whether any element of the matrix is NaN
+func hasNaN(m mgl32.Mat4) bool {
+	for _, v := range m {
+		if math.IsNaN(float64(v)) {
+			return true
+		}
+	}
+	return false
+}
+
+// closeMat4 reports whether every element of two matrices is the same within float error. Unlike
+// ApproxEqualThreshold it doesn't get stricter for elements near 0
+func closeMat4(a, b mgl32.Mat4) bool {
+	for i := range a {
+		if math.Abs(float64(a[i]-b[i])) > 1e-4 {
+			return false
+		}
+	}
+	return true
+}
+
+// nearVec3 reports whether two points are within float error of each other
+func nearVec3(a, b mgl32.Vec3) bool {
+	return a.Sub(b).Len() < 1e-5
+}
+
+// chain attaches each node to the one before it and returns them
+func chain(t *testing.T, names ...string) (nodes []*Node) {
+	t.Helper()
+	for i, name := range names {
+		nodes = append(nodes, NewNode(name))
+		if i > 0 {
+			if err := nodes[i-1].Attach(nodes[i]); err != nil {
+				t.Fatalf("unable to attach %s: %v", name, err)
+			}
+		}
+	}
+	return nodes
+}
+
+func TestNodeWorldFollowsParent(t *testing.T) {
+	nodes := chain(t, "root", "arm", "hand")
+	root, arm, hand := nodes[0], nodes[1], nodes[2]
+	arm.SetPosition(mgl32.Vec3{0, 2, 0})
+	hand.SetPosition(mgl32.Vec3{1, 0, 0})
+
+	if got := hand.WorldPosition(); !nearVec3(got, mgl32.Vec3{1, 2, 0}) {
+		t.Errorf("hand is at %v, want (1, 2, 0)", got)
+	}
+
+	// the hand's cached world matrix has to be rebuilt when anything above it moves
+	root.SetPosition(mgl32.Vec3{5, 0, 0})
+	if got := hand.WorldPosition(); !nearVec3(got, mgl32.Vec3{6, 2, 0}) {
+		t.Errorf("hand is at %v after moving the root, want (6, 2, 0)", got)
+	}
+	arm.SetRotation(mgl32.QuatRotate(mgl32.DegToRad(90), mgl32.Vec3{0, 0, 1}))
+	if got := hand.WorldPosition(); !nearVec3(got, mgl32.Vec3{5, 3, 0}) {
+		t.Errorf("hand is at %v after turning the arm, want (5, 3, 0)", got)
+	}
+
+	// the arm is rebuilt on its own while the hand is still dirty, then the root moves again.
+	// Invalidating stops at the arm's clean matrix only if the hand is already dirty
+	arm.SetPosition(mgl32.Vec3{0, 4, 0})
+	if got := arm.WorldPosition(); !nearVec3(got, mgl32.Vec3{5, 4, 0}) {
+		t.Errorf("arm is at %v, want (5, 4, 0)", got)
+	}
+	root.SetPosition(mgl32.Vec3{0, 0, 0})
+	if got := hand.WorldPosition(); !nearVec3(got, mgl32.Vec3{0, 5, 0}) {
+		t.Errorf("hand is at %v after moving the arm and the root, want (0, 5, 0)", got)
+	}
+
+	// moving a child leaves its parent alone
+	hand.SetScale(mgl32.Vec3{3, 3, 3})
+	if got := arm.WorldPosition(); !nearVec3(got, mgl32.Vec3{0, 4, 0}) {
+		t.Errorf("arm is at %v after scaling the hand, want (0, 4, 0)", got)
+	}
+}
+
+func TestNodeAttachRefusesCycles(t *testing.T) {
+	nodes := chain(t, "a", "b", "c")
+	a, b, c := nodes[0], nodes[1], nodes[2]
+
+	if err := c.Attach(a); err == nil {
+		t.Errorf("attached a under its own grandchild")
+	}
+	if err := a.Attach(a); err == nil {
+		t.Errorf("attached a to itself")
+	}
+	if err := c.Reparent(a); err != nil {
+		t.Errorf("unable to move c up to a: %v", err)
+	}
+	if err := c.Reparent(c); err == nil {
+		t.Errorf("reparented c to itself")
+	}
+
+	// the failed attaches left the graph alone
+	if a.Parent() != nil || b.Parent() != a || c.Parent() != a {
+		t.Errorf("parents are %v, %v and %v, want nil, a and a", a.Parent(), b.Parent(), c.Parent())
+	}
+	if len(a.Children()) != 2 || len(b.Children()) != 0 || len(c.Children()) != 0 {
+		t.Errorf("a, b and c have %d, %d and %d children, want 2, 0 and 0", len(a.Children()), len(b.Children()), len(c.Children()))
+	}
+}
+
+func TestNodeReparentKeepsWorld(t *testing.T) {
+	parents := []struct {
+		name     string
+		position mgl32.Vec3
+		rotation mgl32.Quat
+		scale    mgl32.Vec3
+	}{
+		{"moved", mgl32.Vec3{3, -1, 2}, mgl32.QuatIdent(), mgl32.Vec3{1, 1, 1}},
+		{"turned and scaled", mgl32.Vec3{1, 2, 3}, mgl32.QuatRotate(0.7, mgl32.Vec3{1, 1, 0}.Normalize()), mgl32.Vec3{2, 2, 2}},
+		{"mirrored", mgl32.Vec3{0, 1, 0}, mgl32.QuatRotate(1.2, mgl32.Vec3{0, 1, 0}), mgl32.Vec3{-1, 1, 1}},
+		{"mirrored twice", mgl32.Vec3{0, 0, -4}, mgl32.QuatRotate(0.3, mgl32.Vec3{0, 0, 1}), mgl32.Vec3{-2, -2, 2}},
+	}
+	for _, p := range parents {
+		parent, child := NewNode("parent"), NewNode("child")
+		parent.SetPosition(p.position)
+		parent.SetRotation(p.rotation)
+		parent.SetScale(p.scale)
+		child.SetPosition(mgl32.Vec3{1, 2, 3})
+		child.SetRotation(mgl32.QuatRotate(0.5, mgl32.Vec3{0, 1, 0}))
+		child.SetScale(mgl32.Vec3{1, 2, 0.5})
+		want := child.World()
+
+		if err := child.Reparent(parent); err != nil {
+			t.Fatalf("%s: unable to reparent: %v", p.name, err)
+		}
+		if got := child.World(); !closeMat4(got, want) {
+			t.Errorf("%s: world is %v after reparenting, want %v", p.name, got, want)
+		}
+
+		// and back out again
+		if err := child.Reparent(nil); err != nil {
+			t.Fatalf("%s: unable to detach: %v", p.name, err)
+		}
+		if got := child.World(); !closeMat4(got, want) || child.Parent() != nil {
+			t.Errorf("%s: world is %v after detaching, want %v", p.name, got, want)
+		}
+	}
+}
+
+func TestNodeReparentZeroScale(t *testing.T) {
+	flat, child := NewNode("flat"), NewNode("child")
+	flat.SetScale(mgl32.Vec3{0, 1, 1})
+	child.SetPosition(mgl32.Vec3{1, 2, 3})
+
+	// nothing under a flat parent can keep its place in the world
+	if err := child.Reparent(flat); err == nil {
+		t.Errorf("reparented under a parent scaled to nothing")
+	}
+	if child.Parent() != nil || len(flat.Children()) != 0 {
+		t.Errorf("the failed reparent attached the child")
+	}
+
+	// a child squashed by its parent can still be taken out of it
+	if err := flat.Attach(child); err != nil {
+		t.Fatalf("unable to attach: %v", err)
+	}
+	want := child.World()
+	if err := child.Reparent(nil); err != nil {
+		t.Fatalf("unable to detach: %v", err)
+	}
+	if got := child.World(); hasNaN(got) || !closeMat4(got, want) {
+		t.Errorf("world is %v after detaching from a flat parent, want %v", got, want)
+	}
+}
@@ -0,0 +1,128 @@
+package engine
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// The texture units NormalMapGLSL samples from
+const (
+	DiffuseMapUnit = 0
+	NormalMapUnit  = 1
+)
+
+// NormalMappedMaterial is a Material with a diffuse texture that colors the surface and a normal
+// map that bends its normals for detail the geometry doesn't have. A nil NormalMap lights the
+// surface with the mesh normals
+type NormalMappedMaterial struct {
+	Material
+	DiffuseMap *Texture
+	NormalMap  *Texture
+}
+
+// NormalMapUniforms uploads normal mapped materials to a program that uses LightsGLSL and
+// NormalMapGLSL. The program has to be in use when uploading
+type NormalMapUniforms struct {
+	*MaterialUniforms
+	useNormalMap int32
+}
+
+// NewNormalMapUniforms looks up the material uniforms in the program and points its samplers at
+// DiffuseMapUnit and NormalMapUnit. The program has to be in use
+func NewNormalMapUniforms(program uint32) (u *NormalMapUniforms) {
+	Backend.Uniform1i(Backend.GetUniformLocation(program, "texSampler"), DiffuseMapUnit)
+	Backend.Uniform1i(Backend.GetUniformLocation(program, "normalSampler"), NormalMapUnit)
+	CheckError("NewNormalMapUniforms")
+
+	return &NormalMapUniforms{
+		MaterialUniforms: NewMaterialUniforms(program),
+		useNormalMap:     Backend.GetUniformLocation(program, "useNormalMap"),
+	}
+}
+
+// Upload sends the material of the next thing drawn to the program and binds its textures
+func (u *NormalMapUniforms) Upload(m NormalMappedMaterial) {
+	u.MaterialUniforms.Upload(m.Material)
+	if m.DiffuseMap != nil {
+		m.DiffuseMap.Bind(gl.TEXTURE0 + DiffuseMapUnit)
+	}
+
+	use := int32(0)
+	if m.NormalMap != nil {
+		m.NormalMap.Bind(gl.TEXTURE0 + NormalMapUnit)
+		use = 1
+	}
+	Backend.Uniform1i(u.useNormalMap, use)
+	CheckError("NormalMapUniforms.Upload")
+}
+
+// NormalMapFromHeight makes a tangent space normal map from an image, treating its brightness as
+// height. It is a cheap way to get bumps out of a texture that has no normal map of its own.
+// Bigger strengths make steeper bumps. Like DecodeImage it is safe to call from any goroutine
+func NormalMapFromHeight(rgba *image.RGBA, strength float32) (normals *image.RGBA) {
+	bounds := rgba.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	// textures repeat so wrap around the edges instead of flattening them
+	heightAt := func(x, y int) float32 {
+		x = (x%width + width) % width
+		y = (y%height + height) % height
+		c := rgba.RGBAAt(bounds.Min.X+x, bounds.Min.Y+y)
+		return (0.299*float32(c.R) + 0.587*float32(c.G) + 0.114*float32(c.B)) / 255
+	}
+
+	normals = image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			// sobel filter for the slope in u (x) and v (y). Rows are uploaded bottom up so y
+			// already increases with v
+			du := (heightAt(x+1, y-1) + 2*heightAt(x+1, y) + heightAt(x+1, y+1)) -
+				(heightAt(x-1, y-1) + 2*heightAt(x-1, y) + heightAt(x-1, y+1))
+			dv := (heightAt(x-1, y+1) + 2*heightAt(x, y+1) + heightAt(x+1, y+1)) -
+				(heightAt(x-1, y-1) + 2*heightAt(x, y-1) + heightAt(x+1, y-1))
+
+			nx, ny, nz := -du*strength, -dv*strength, float32(1)
+			length := float32(math.Sqrt(float64(nx*nx + ny*ny + nz*nz)))
+			normals.SetRGBA(x, y, color.RGBA{
+				R: uint8((nx/length*0.5 + 0.5) * 255),
+				G: uint8((ny/length*0.5 + 0.5) * 255),
+				B: uint8((nz/length*0.5 + 0.5) * 255),
+				A: 255,
+			})
+		}
+	}
+	return normals
+}
+
+// NormalMapGLSL declares the diffuse and normal map samplers and perturbNormal, which bends a
+// normal by the normal map. Add it to a fragment shader after LightsGLSL and pass it the world
+// space normal and tangent (with the bitangent sign in w) from a Mesh
+const NormalMapGLSL = `
+	uniform sampler2D texSampler;
+	uniform sampler2D normalSampler;
+	uniform bool useNormalMap;
+
+	// perturbNormalScaled returns the world space normal at texCoord with the bumps of the normal
+	// map made steeper or shallower by scale
+	vec3 perturbNormalScaled(vec3 normal, vec4 tangent, vec2 texCoord, float scale) {
+		vec3 n = normalize(normal);
+		if (!useNormalMap) {
+			return n;
+		}
+
+		// interpolating bends the tangent off the surface so straighten it out again
+		vec3 t = normalize(tangent.xyz - n * dot(n, tangent.xyz));
+		vec3 b = tangent.w * cross(n, t);
+		vec3 mapped = texture(normalSampler, texCoord).rgb * 2.0 - 1.0;
+		mapped.xy *= scale;
+		return normalize(mat3(t, b, n) * mapped);
+	}
+
+	// perturbNormal returns the world space normal at texCoord
+	vec3 perturbNormal(vec3 normal, vec4 tangent, vec2 texCoord) {
+		return perturbNormalScaled(normal, tangent, texCoord, 1.0);
+	}
+`
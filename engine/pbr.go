@@ -0,0 +1,303 @@
+package engine
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// The texture units PBRGLSL samples from. The base color and normal maps share their units with
+// NormalMapGLSL since PBRGLSL uses its samplers
+const (
+	BaseColorMapUnit         = DiffuseMapUnit
+	PBRNormalMapUnit         = NormalMapUnit
+	MetallicRoughnessMapUnit = 2
+	OcclusionMapUnit         = 3
+	EmissiveMapUnit          = 4
+)
+
+// PBRMaterial is a physically based material following the glTF metallic-roughness model. Each
+// factor is multiplied with its map, and a nil map counts as white, so a material can be all
+// factors, all maps or a mix
+type PBRMaterial struct {
+	// BaseColor is the color of the surface, or the color of the reflections for metals
+	BaseColor mgl32.Vec4
+	// Metallic is 0 for plastic, stone, wood, ... and 1 for metal
+	Metallic float32
+	// Roughness is 0 for a mirror and 1 for a completely rough surface
+	Roughness float32
+	// Emissive is the light the surface gives off by itself
+	Emissive mgl32.Vec3
+	// OcclusionStrength is how much of the occlusion map darkens the ambient light
+	OcclusionStrength float32
+	// NormalScale makes the normal map bumps steeper or shallower
+	NormalScale float32
+
+	// BaseColorMap and EmissiveMap hold colors so they belong in NewSRGBTexture textures. The rest
+	// hold linear data and belong in NewTexture textures
+	BaseColorMap *Texture
+	// MetallicRoughnessMap has roughness in green and metallic in blue
+	MetallicRoughnessMap *Texture
+	// OcclusionMap has how much ambient light reaches the surface in red
+	OcclusionMap *Texture
+	EmissiveMap  *Texture
+	NormalMap    *Texture
+}
+
+// NewPBRMaterial creates a material with the glTF defaults: a white rough metal with no textures
+func NewPBRMaterial() (m PBRMaterial) {
+	return PBRMaterial{
+		BaseColor:         mgl32.Vec4{1, 1, 1, 1},
+		Metallic:          1,
+		Roughness:         1,
+		OcclusionStrength: 1,
+		NormalScale:       1,
+	}
+}
+
+// PBRUniforms uploads PBR materials to a program that uses PBRGLSL. The program has to be in use
+// when uploading
+type PBRUniforms struct {
+	baseColor         int32
+	metallic          int32
+	roughness         int32
+	emissive          int32
+	occlusionStrength int32
+	normalScale       int32
+
+	hasBaseColorMap         int32
+	hasMetallicRoughnessMap int32
+	hasOcclusionMap         int32
+	hasEmissiveMap          int32
+	useNormalMap            int32
+}
+
+// NewPBRUniforms looks up the material uniforms in the program and points its samplers at their
+// texture units. The program has to be in use
+func NewPBRUniforms(program uint32) (u *PBRUniforms) {
+	samplers := map[string]int32{
+		"texSampler":           BaseColorMapUnit,
+		"normalSampler":        PBRNormalMapUnit,
+		"metallicRoughnessMap": MetallicRoughnessMapUnit,
+		"occlusionMap":         OcclusionMapUnit,
+		"emissiveMap":          EmissiveMapUnit,
+	}
+	for name, unit := range samplers {
+		Backend.Uniform1i(Backend.GetUniformLocation(program, name), unit)
+	}
+	CheckError("NewPBRUniforms")
+
+	return &PBRUniforms{
+		baseColor:         Backend.GetUniformLocation(program, "pbrMaterial.baseColor"),
+		metallic:          Backend.GetUniformLocation(program, "pbrMaterial.metallic"),
+		roughness:         Backend.GetUniformLocation(program, "pbrMaterial.roughness"),
+		emissive:          Backend.GetUniformLocation(program, "pbrMaterial.emissive"),
+		occlusionStrength: Backend.GetUniformLocation(program, "pbrMaterial.occlusionStrength"),
+		normalScale:       Backend.GetUniformLocation(program, "pbrMaterial.normalScale"),
+
+		hasBaseColorMap:         Backend.GetUniformLocation(program, "hasBaseColorMap"),
+		hasMetallicRoughnessMap: Backend.GetUniformLocation(program, "hasMetallicRoughnessMap"),
+		hasOcclusionMap:         Backend.GetUniformLocation(program, "hasOcclusionMap"),
+		hasEmissiveMap:          Backend.GetUniformLocation(program, "hasEmissiveMap"),
+		useNormalMap:            Backend.GetUniformLocation(program, "useNormalMap"),
+	}
+}
+
+// Upload sends the material of the next thing drawn to the program and binds its textures
+func (u *PBRUniforms) Upload(m PBRMaterial) {
+	Backend.Uniform3f(u.emissive, m.Emissive.X(), m.Emissive.Y(), m.Emissive.Z())
+	Backend.Uniform1f(u.metallic, m.Metallic)
+	Backend.Uniform1f(u.roughness, m.Roughness)
+	Backend.Uniform1f(u.occlusionStrength, m.OcclusionStrength)
+	Backend.Uniform1f(u.normalScale, m.NormalScale)
+	Backend.Uniform4f(u.baseColor, m.BaseColor.X(), m.BaseColor.Y(), m.BaseColor.Z(), m.BaseColor.W())
+
+	bindMap(m.BaseColorMap, BaseColorMapUnit, u.hasBaseColorMap)
+	bindMap(m.MetallicRoughnessMap, MetallicRoughnessMapUnit, u.hasMetallicRoughnessMap)
+	bindMap(m.OcclusionMap, OcclusionMapUnit, u.hasOcclusionMap)
+	bindMap(m.EmissiveMap, EmissiveMapUnit, u.hasEmissiveMap)
+	bindMap(m.NormalMap, PBRNormalMapUnit, u.useNormalMap)
+	CheckError("PBRUniforms.Upload")
+}
+
+// bindMap binds a material's texture to its unit and tells the shader whether it has one
+func bindMap(t *Texture, unit uint32, has int32) {
+	if t == nil {
+		Backend.Uniform1i(has, 0)
+		return
+	}
+	t.Bind(gl.TEXTURE0 + unit)
+	Backend.Uniform1i(has, 1)
+}
+
+// PBRGLSL declares the PBR material uniforms and shadePBR, which lights a surface with the Lights
+// block using the Cook-Torrance BRDF. Add it to a fragment shader after CameraGLSL, LightsGLSL and
+// NormalMapGLSL and pass what shadePBR returns through tonemap from TonemapGLSL. All the lighting
+// happens in linear space
+const PBRGLSL = `
+	const float PI = 3.14159265359;
+
+	struct PBRMaterial {
+		vec4 baseColor;
+		float metallic;
+		float roughness;
+		vec3 emissive;
+		float occlusionStrength;
+		float normalScale;
+	};
+
+	uniform PBRMaterial pbrMaterial;
+	uniform sampler2D metallicRoughnessMap;
+	uniform sampler2D occlusionMap;
+	uniform sampler2D emissiveMap;
+	uniform bool hasBaseColorMap;
+	uniform bool hasMetallicRoughnessMap;
+	uniform bool hasOcclusionMap;
+	uniform bool hasEmissiveMap;
+
+	// PBRSurface is the material at one point after the maps have been applied
+	struct PBRSurface {
+		vec3 baseColor;
+		float alpha;
+		float metallic;
+		float roughness;
+		float occlusion;
+		vec3 emissive;
+		vec3 normal;
+	};
+
+	// pbrSurface samples the material's maps at texCoord and combines them with its factors
+	PBRSurface pbrSurface(vec3 normal, vec4 tangent, vec2 texCoord) {
+		PBRSurface s;
+		vec4 baseColor = pbrMaterial.baseColor;
+		if (hasBaseColorMap) {
+			baseColor *= texture(texSampler, texCoord);
+		}
+		s.baseColor = baseColor.rgb;
+		s.alpha = baseColor.a;
+
+		s.metallic = pbrMaterial.metallic;
+		s.roughness = pbrMaterial.roughness;
+		if (hasMetallicRoughnessMap) {
+			vec4 mr = texture(metallicRoughnessMap, texCoord);
+			s.roughness *= mr.g;
+			s.metallic *= mr.b;
+		}
+		// a perfectly smooth surface makes the highlight infinitely small and bright
+		s.roughness = clamp(s.roughness, 0.04, 1.0);
+
+		s.occlusion = 1.0;
+		if (hasOcclusionMap) {
+			s.occlusion = 1.0 + pbrMaterial.occlusionStrength * (texture(occlusionMap, texCoord).r - 1.0);
+		}
+
+		s.emissive = pbrMaterial.emissive;
+		if (hasEmissiveMap) {
+			s.emissive *= texture(emissiveMap, texCoord).rgb;
+		}
+
+		s.normal = perturbNormalScaled(normal, tangent, texCoord, pbrMaterial.normalScale);
+		return s;
+	}
+
+	// distributionGGX is how many microfacets face halfway between the light and the camera
+	float distributionGGX(float NdotH, float roughness) {
+		float a = roughness * roughness;
+		float a2 = a * a;
+		float d = NdotH * NdotH * (a2 - 1.0) + 1.0;
+		return a2 / (PI * d * d);
+	}
+
+	// geometrySmith is how many microfacets aren't hidden by others from the light or the camera
+	float geometrySmith(float NdotV, float NdotL, float roughness) {
+		float r = roughness + 1.0;
+		float k = r * r / 8.0;
+		float viewG = NdotV / (NdotV * (1.0 - k) + k);
+		float lightG = NdotL / (NdotL * (1.0 - k) + k);
+		return viewG * lightG;
+	}
+
+	// fresnelSchlick is how much light reflects instead of going into the surface. Everything
+	// reflects more at grazing angles
+	vec3 fresnelSchlick(float cosTheta, vec3 F0) {
+		return F0 + (1.0 - F0) * pow(clamp(1.0 - cosTheta, 0.0, 1.0), 5.0);
+	}
+
+	// fresnelSchlickRoughness is fresnelSchlick for light from every direction at once. Rough
+	// surfaces don't get as bright at the edges
+	vec3 fresnelSchlickRoughness(float cosTheta, vec3 F0, float roughness) {
+		return F0 + (max(vec3(1.0 - roughness), F0) - F0) * pow(clamp(1.0 - cosTheta, 0.0, 1.0), 5.0);
+	}
+
+	// environmentAmbient returns the light reflected from the environment with the split sum
+	// approximation, or nothing when the environment is off
+	vec3 environmentAmbient(PBRSurface s, vec3 N, vec3 V, float NdotV, vec3 F0) {
+		if (!environment) {
+			return vec3(0.0);
+		}
+
+		vec3 F = fresnelSchlickRoughness(NdotV, F0, s.roughness);
+		vec3 diffuse = (1.0 - F) * (1.0 - s.metallic) * texture(irradianceMap, N).rgb * s.baseColor;
+
+		// rougher surfaces reflect blurrier mip levels of the environment
+		vec3 R = reflect(-V, N);
+		vec3 prefiltered = textureLod(prefilterMap, R, s.roughness * environmentLevels).rgb;
+		vec2 brdf = texture(brdfLUT, vec2(NdotV, s.roughness)).rg;
+		vec3 specular = prefiltered * (F0 * brdf.x + brdf.y);
+
+		return (diffuse + specular) * s.occlusion;
+	}
+
+	// shadePBR returns the light reflected towards the camera from every light plus what the
+	// surface gives off itself
+	vec3 shadePBR(PBRSurface s, vec3 position) {
+		vec3 N = s.normal;
+		vec3 V = normalize(viewPosition - position);
+		float NdotV = max(dot(N, V), 1e-4);
+
+		// non metals reflect about 4% of the light head on, metals reflect their color
+		vec3 F0 = mix(vec3(0.04), s.baseColor, s.metallic);
+		vec3 diffuseColor = s.baseColor * (1.0 - s.metallic);
+
+		vec3 color = environmentAmbient(s, N, V, NdotV, F0);
+		for (int i = 0; i < numLights && i < MAX_LIGHTS; i++) {
+			Light light = lights[i];
+			if (!environment) {
+				color += light.ambient * light.color * s.baseColor * s.occlusion;
+			}
+
+			vec3 L;
+			float strength = lightStrength(light, N, position, L);
+			float NdotL = dot(N, L);
+			if (NdotL <= 0.0 || strength <= 0.0) {
+				continue;
+			}
+
+			vec3 H = normalize(V + L);
+			float D = distributionGGX(max(dot(N, H), 0.0), s.roughness);
+			float G = geometrySmith(NdotV, NdotL, s.roughness);
+			vec3 F = fresnelSchlick(max(dot(H, V), 0.0), F0);
+
+			vec3 specular = D * G * F / (4.0 * NdotV * NdotL + 1e-4);
+			// whatever isn't reflected goes into the surface and comes back out as diffuse
+			vec3 diffuse = (1.0 - F) * diffuseColor / PI;
+			color += (diffuse + specular) * light.color * strength * NdotL;
+		}
+		return color + s.emissive;
+	}
+`
+
+// TonemapGLSL declares tonemap, which turns linear light of any brightness into a color the screen
+// can show. Add it to a fragment shader after CameraGLSL, which has the exposure
+const TonemapGLSL = `
+	// tonemap scales the color by the exposure, squeezes it into 0-1 with the ACES filmic curve
+	// and encodes it as sRGB for the screen. With linearOutput set the post processing does the
+	// squeezing and encoding so it stops after the exposure
+	vec3 tonemap(vec3 color) {
+		color *= exposure;
+		if (linearOutput) {
+			return color;
+		}
+		color = clamp((color * (2.51 * color + 0.03)) / (color * (2.43 * color + 0.59) + 0.14), 0.0, 1.0);
+		return pow(color, vec3(1.0 / 2.2));
+	}
+`
@@ -0,0 +1,236 @@
+package engine
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/pkg/errors"
+)
+
+// Pickable is anything whose triangles a ray can hit, like MeshBuffers. The ray is in the mesh's
+// own space
+type Pickable interface {
+	IntersectRay(r Ray) (t float32, triangle int, ok bool)
+}
+
+// PickHit is what a ray hit in a scene graph
+type PickHit struct {
+	Node *Node
+	// Distance is how far along the ray the hit is
+	Distance float32
+	// Triangle is the index of the triangle that was hit in the node's mesh
+	Triangle int
+	// Point is where the hit is in the world
+	Point mgl32.Vec3
+}
+
+// Pick returns the closest node under this one (including it) whose mesh the ray hits. Meshes
+// that aren't Pickable are skipped. The ray is checked against the box around each mesh first so
+// only the ones it comes near have their triangles tested
+func (n *Node) Pick(r Ray) (hit PickHit, ok bool) {
+	n.Walk(func(node *Node) bool {
+		pickable, isPickable := node.Mesh.(Pickable)
+		if !isPickable {
+			return true
+		}
+		if bounds, bounded := node.WorldBounds(); bounded {
+			enter, near := r.IntersectAABB(bounds)
+			if !near || (ok && enter > hit.Distance) {
+				return true
+			}
+		}
+
+		// test the triangles where they are in the mesh instead of moving every one of them
+		t, triangle, found := pickable.IntersectRay(r.Transform(node.World().Inv()))
+		if found && (!ok || t < hit.Distance) {
+			hit = PickHit{Node: node, Distance: t, Triangle: triangle, Point: r.At(t)}
+			ok = true
+		}
+		return true
+	})
+	return hit, ok
+}
+
+// Picker finds the node under the cursor by casting a ray from the camera into a scene graph
+type Picker struct {
+	Root *Node
+	// Camera is what the scene was last rendered from
+	Camera Camera
+	// Width and Height are the size of the window in the same units as cursor positions
+	Width  int
+	Height int
+}
+
+// Pick returns the closest node under the cursor position (x, y)
+func (p *Picker) Pick(x, y float64) (hit PickHit, ok bool) {
+	return p.Root.Pick(p.Camera.ScreenRay(x, y, p.Width, p.Height))
+}
+
+// IDBuffer picks the node under a pixel exactly by drawing every node's id into an integer
+// texture and reading the pixel back. It costs a pass of its own and a stall while we wait for the
+// GPU, so only Render it when something is being picked
+type IDBuffer struct {
+	Framebuffer *Framebuffer
+	IDs         *Texture
+	Depth       *Texture
+
+	program        uint32
+	model          int32
+	viewProjection int32
+	id             int32
+	// nodes are what each id was drawn for. Ids start at 1 so 0 is nothing
+	nodes []*Node
+}
+
+// NewIDBuffer creates an id buffer width by height pixels, usually the size of the framebuffer
+func NewIDBuffer(width, height int) (b *IDBuffer, err error) {
+	program, err := NewProgram(idVertexShaderSrc, idFragShaderSrc)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create the id program")
+	}
+	Label(gl.PROGRAM, program, "ids")
+
+	b = &IDBuffer{
+		program:        program,
+		model:          Backend.GetUniformLocation(program, "model"),
+		viewProjection: Backend.GetUniformLocation(program, "viewProjection"),
+		id:             Backend.GetUniformLocation(program, "id"),
+	}
+	err = b.Resize(width, height)
+	if err != nil {
+		b.Delete()
+		return nil, err
+	}
+	return b, nil
+}
+
+// Resize recreates the framebuffer for a new size
+func (b *IDBuffer) Resize(width, height int) (err error) {
+	b.deleteTargets()
+
+	b.Framebuffer = NewFramebuffer(width, height)
+	b.IDs = newIDTexture(width, height)
+	b.Depth = NewDepthTexture(width, height)
+	b.Framebuffer.AttachTexture(gl.COLOR_ATTACHMENT0, b.IDs)
+	b.Framebuffer.AttachTexture(gl.DEPTH_ATTACHMENT, b.Depth)
+	err = b.Framebuffer.Check()
+	State.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	if err != nil {
+		return errors.Wrap(err, "unable to create the id framebuffer")
+	}
+	return nil
+}
+
+// newIDTexture creates an empty texture of one unsigned int per pixel. Integers can't be blended
+// between so it is never filtered
+func newIDTexture(width, height int) (t *Texture) {
+	t = &Texture{
+		Width:  width,
+		Height: height,
+	}
+
+	Backend.GenTextures(1, &t.ID)
+	Resources.Track(ResourceTexture, t.ID)
+	State.BindTexture(gl.TEXTURE0, gl.TEXTURE_2D, t.ID)
+	Backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+	Backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+	Backend.TexImage2D(gl.TEXTURE_2D, 0, gl.R32UI, int32(width), int32(height), gl.RED_INTEGER, gl.UNSIGNED_INT, nil)
+	CheckError("newIDTexture")
+
+	return t
+}
+
+// Render draws the id of every node under root (including it) that has a Mesh from the camera
+func (b *IDBuffer) Render(root *Node, camera Camera) {
+	b.nodes = b.nodes[:0]
+	b.Framebuffer.Bind()
+	// an integer buffer can't be cleared with glClear's float color
+	Backend.ClearBufferuiv(gl.COLOR, 0, []uint32{0, 0, 0, 0})
+	State.DepthMask(true)
+	Backend.Clear(gl.DEPTH_BUFFER_BIT)
+
+	State.UseProgram(b.program)
+	viewProjection := camera.Projection.Mul4(camera.View)
+	Backend.UniformMatrix4fv(b.viewProjection, 1, false, &viewProjection[0])
+	root.Walk(func(n *Node) bool {
+		if n.Mesh == nil {
+			return true
+		}
+		b.nodes = append(b.nodes, n)
+		model := n.World()
+		Backend.UniformMatrix4fv(b.model, 1, false, &model[0])
+		Backend.Uniform1ui(b.id, uint32(len(b.nodes)))
+		n.Mesh.Draw()
+		return true
+	})
+	CheckError("IDBuffer.Render")
+	b.Framebuffer.Unbind()
+}
+
+// Pick returns the node drawn at the cursor position (x, y) by the last Render. width and height
+// are the size of the window in the same units as the cursor position, which can differ from the
+// size of the id buffer on high dpi screens
+func (b *IDBuffer) Pick(x, y float64, width, height int) (n *Node, ok bool) {
+	// the texture's rows start at the bottom
+	px := int32(x * float64(b.Framebuffer.Width) / float64(width))
+	py := int32(b.Framebuffer.Height) - 1 - int32(y*float64(b.Framebuffer.Height)/float64(height))
+	if px < 0 || py < 0 || px >= int32(b.Framebuffer.Width) || py >= int32(b.Framebuffer.Height) {
+		return nil, false
+	}
+
+	id := []uint32{0}
+	State.BindFramebuffer(gl.READ_FRAMEBUFFER, b.Framebuffer.ID)
+	Backend.ReadBuffer(gl.COLOR_ATTACHMENT0)
+	Backend.ReadPixels(px, py, 1, 1, gl.RED_INTEGER, gl.UNSIGNED_INT, id)
+	State.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	CheckError("IDBuffer.Pick")
+
+	if id[0] == 0 || int(id[0]) > len(b.nodes) {
+		return nil, false
+	}
+	return b.nodes[id[0]-1], true
+}
+
+// Delete deletes the program and the framebuffer
+func (b *IDBuffer) Delete() {
+	b.deleteTargets()
+	DeleteProgram(b.program)
+}
+
+// deleteTargets deletes the framebuffer and its textures if they have been made
+func (b *IDBuffer) deleteTargets() {
+	if b.Framebuffer != nil {
+		b.Framebuffer.Delete()
+	}
+	for _, t := range []*Texture{b.IDs, b.Depth} {
+		if t != nil {
+			t.Delete()
+		}
+	}
+	b.Framebuffer, b.IDs, b.Depth = nil, nil, nil
+}
+
+// the positions are where engine.MeshBuffers puts them
+var idVertexShaderSrc = `
+	#version 410
+
+	uniform mat4 model;
+	uniform mat4 viewProjection;
+
+	layout(location = 0) in vec3 vert;
+
+	void main() {
+		gl_Position = viewProjection * model * vec4(vert, 1.0);
+	}
+` + "\x00"
+
+var idFragShaderSrc = `
+	#version 410
+
+	uniform uint id;
+
+	out uint outputID;
+
+	void main() {
+		outputID = id;
+	}
+` + "\x00"
@@ -0,0 +1,363 @@
+package engine
+
+import (
+	"sort"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/pkg/errors"
+)
+
+// PostProcess renders the scene into a texture and then runs it through a chain of full screen
+// effects on its way to the window. Call Begin before drawing the scene and End after it
+type PostProcess struct {
+	// Effects run in order. Only the enabled ones are drawn
+	Effects []*Effect
+	Width   int
+	Height  int
+	// Samples is how many samples per pixel the scene is rendered with. Change it with SetSamples
+	Samples int
+
+	// multisample is where the scene is drawn when it has samples. It is resolved into scene
+	multisample *Multisample
+	// the scene is drawn into scene and then ping pongs between the targets, one effect at a time
+	scene      *Framebuffer
+	sceneColor *Texture
+	sceneDepth *Texture
+	targets    [2]*Framebuffer
+	colors     [2]*Texture
+
+	// copy draws the scene straight to the window when no effects are enabled
+	copy *Effect
+	vao  uint32
+}
+
+// NewPostProcess creates the framebuffers for a window width by height pixels. The scene is
+// stored as half floats so it can be brighter than 1 until an effect tonemaps it
+func NewPostProcess(width, height int) (p *PostProcess, err error) {
+	p = &PostProcess{vao: GenVertexArray()}
+	p.copy, err = NewEffect("copy", copyFragShaderSrc)
+	if err != nil {
+		DeleteVertexArray(p.vao)
+		return nil, err
+	}
+	err = p.Resize(width, height)
+	if err != nil {
+		p.Delete()
+		return nil, err
+	}
+	return p, nil
+}
+
+// Resize recreates the framebuffers and the effects' own ones for a new window size. If the new
+// framebuffers can't be made the old ones are kept
+func (p *PostProcess) Resize(width, height int) (err error) {
+	return p.resize(width, height, p.Samples)
+}
+
+// resize makes the framebuffers for the size and samples and only swaps them in when they are all
+// complete
+func (p *PostProcess) resize(width, height, samples int) (err error) {
+	next := &PostProcess{Width: width, Height: height, Samples: samples}
+	err = next.createTargets()
+	State.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	if err != nil {
+		next.deleteTargets()
+		return err
+	}
+
+	p.deleteTargets()
+	p.Width, p.Height, p.Samples = width, height, samples
+	p.multisample, p.scene, p.sceneColor, p.sceneDepth = next.multisample, next.scene, next.sceneColor, next.sceneDepth
+	p.targets, p.colors = next.targets, next.colors
+
+	for _, e := range p.Effects {
+		if e.resize == nil {
+			continue
+		}
+		err = e.resize(width, height)
+		if err != nil {
+			return errors.Wrapf(err, "unable to resize the %s effect", e.Name)
+		}
+	}
+	return nil
+}
+
+// createTargets makes the scene and effect framebuffers for the post process's size and samples
+func (p *PostProcess) createTargets() (err error) {
+	p.scene = NewFramebuffer(p.Width, p.Height)
+	p.sceneColor = NewRenderTexture(p.Width, p.Height, gl.RGBA16F, gl.RGBA)
+	p.sceneDepth = NewDepthTexture(p.Width, p.Height)
+	p.scene.AttachTexture(gl.COLOR_ATTACHMENT0, p.sceneColor)
+	p.scene.AttachTexture(gl.DEPTH_ATTACHMENT, p.sceneDepth)
+	err = p.scene.Check()
+	if err != nil {
+		return errors.Wrap(err, "unable to create the scene framebuffer")
+	}
+	if p.Samples > 0 {
+		p.multisample, err = NewMultisample(p.Width, p.Height, p.Samples, gl.RGBA16F)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i := range p.targets {
+		p.targets[i] = NewFramebuffer(p.Width, p.Height)
+		p.colors[i] = NewRenderTexture(p.Width, p.Height, gl.RGBA16F, gl.RGBA)
+		p.targets[i].AttachTexture(gl.COLOR_ATTACHMENT0, p.colors[i])
+		err = p.targets[i].Check()
+		if err != nil {
+			return errors.Wrap(err, "unable to create the effect framebuffer")
+		}
+	}
+	return nil
+}
+
+// SetSamples changes how many samples per pixel the scene is rendered with. 0 turns
+// multisampling off. Samples is left alone if the new framebuffers can't be made
+func (p *PostProcess) SetSamples(samples int) (err error) {
+	err = CheckSamples(samples)
+	if err != nil {
+		return err
+	}
+	return p.resize(p.Width, p.Height, samples)
+}
+
+// Add adds effects to the end of the chain. They are resized along with the post process
+func (p *PostProcess) Add(effects ...*Effect) (err error) {
+	for _, e := range effects {
+		if e.resize != nil {
+			err = e.resize(p.Width, p.Height)
+			if err != nil {
+				return errors.Wrapf(err, "unable to size the %s effect", e.Name)
+			}
+		}
+		p.Effects = append(p.Effects, e)
+	}
+	return nil
+}
+
+// Effect returns the first effect with the name or nil if there isn't one
+func (p *PostProcess) Effect(name string) *Effect {
+	i := p.index(name)
+	if i < 0 {
+		return nil
+	}
+	return p.Effects[i]
+}
+
+// Move moves the effect with the name to index in the chain, shifting the ones in between
+func (p *PostProcess) Move(name string, index int) (err error) {
+	i := p.index(name)
+	if i < 0 {
+		return errors.Errorf("there is no %s effect", name)
+	}
+	if index < 0 || index >= len(p.Effects) {
+		return errors.Errorf("index %d is outside of the %d effects", index, len(p.Effects))
+	}
+	e := p.Effects[i]
+	p.Effects = append(p.Effects[:i], p.Effects[i+1:]...)
+	p.Effects = append(p.Effects[:index], append([]*Effect{e}, p.Effects[index:]...)...)
+	return nil
+}
+
+// Remove takes the effect with the name out of the chain and returns it so it can be added back
+// or deleted
+func (p *PostProcess) Remove(name string) *Effect {
+	i := p.index(name)
+	if i < 0 {
+		return nil
+	}
+	e := p.Effects[i]
+	p.Effects = append(p.Effects[:i], p.Effects[i+1:]...)
+	return e
+}
+
+// index returns where the effect with the name is in the chain or -1
+func (p *PostProcess) index(name string) int {
+	for i, e := range p.Effects {
+		if e.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Begin starts rendering the scene into the post process and clears it
+func (p *PostProcess) Begin() {
+	if p.multisample != nil {
+		p.multisample.Bind()
+	} else {
+		p.scene.Bind()
+	}
+	State.DepthMask(true)
+	Backend.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+}
+
+// End runs the scene through the enabled effects and draws the last one into the window
+func (p *PostProcess) End() {
+	if p.multisample != nil {
+		// the effects read single samples so average them first
+		p.multisample.Resolve(p.scene, gl.COLOR_BUFFER_BIT|gl.DEPTH_BUFFER_BIT)
+	} else {
+		p.scene.Unbind()
+	}
+
+	var enabled []*Effect
+	for _, e := range p.Effects {
+		if e.Enabled {
+			enabled = append(enabled, e)
+		}
+	}
+	if len(enabled) == 0 {
+		enabled = []*Effect{p.copy}
+	}
+
+	// the effects are flat quads so put depth testing back the way it was after
+	depthTest := State.capabilities[gl.DEPTH_TEST]
+	State.Disable(gl.DEPTH_TEST)
+	State.BindVertexArray(p.vao)
+	source := p.sceneColor
+	for i, e := range enabled {
+		if e.prepare != nil {
+			e.prepare(p, source)
+		}
+
+		// the last effect goes to the window, the rest into whichever target isn't being read
+		last := i == len(enabled)-1
+		if !last {
+			p.targets[i%2].Bind()
+		}
+		e.draw(p, source)
+		if !last {
+			p.targets[i%2].Unbind()
+			source = p.colors[i%2]
+		}
+	}
+	if depthTest {
+		State.Enable(gl.DEPTH_TEST)
+	}
+}
+
+// Delete deletes the framebuffers and every effect in the chain
+func (p *PostProcess) Delete() {
+	p.deleteTargets()
+	for _, e := range p.Effects {
+		e.Delete()
+	}
+	p.Effects = nil
+	p.copy.Delete()
+	DeleteVertexArray(p.vao)
+}
+
+// deleteTargets deletes the framebuffers and their textures if they have been made
+func (p *PostProcess) deleteTargets() {
+	for _, f := range []*Framebuffer{p.scene, p.targets[0], p.targets[1]} {
+		if f != nil {
+			f.Delete()
+		}
+	}
+	for _, t := range []*Texture{p.sceneColor, p.sceneDepth, p.colors[0], p.colors[1]} {
+		if t != nil {
+			t.Delete()
+		}
+	}
+	if p.multisample != nil {
+		p.multisample.Delete()
+	}
+	p.scene, p.sceneColor, p.sceneDepth, p.multisample = nil, nil, nil, nil
+	p.targets = [2]*Framebuffer{}
+	p.colors = [2]*Texture{}
+}
+
+// EffectGLSL declares what every effect's fragment shader gets: the scene sampler with the output
+// of the effect before it, texelSize (1 / the size of the scene) and fragTexCoord across the
+// screen. Add it after the #version line and write the result to outputColor
+const EffectGLSL = `
+	uniform sampler2D scene;
+	uniform vec2 texelSize;
+
+	in vec2 fragTexCoord;
+
+	out vec4 outputColor;
+`
+
+// Effect is one full screen pass of a PostProcess
+type Effect struct {
+	Name    string
+	Enabled bool
+	Program uint32
+	// Params are uploaded to the float uniforms with the same names before every draw so they can
+	// be changed at runtime
+	Params map[string]float32
+	// Inputs are extra textures bound to the sampler uniforms with the same names
+	Inputs map[string]*Texture
+
+	// prepare does any passes the effect needs before its own (bloom's blur)
+	prepare func(p *PostProcess, source *Texture)
+	// resize recreates any framebuffers the effect has for a new size
+	resize func(width, height int) error
+	// cleanup deletes whatever the effect made besides its program
+	cleanup func()
+}
+
+// NewEffect creates an enabled effect from a fragment shader that reads the scene with EffectGLSL.
+// It is drawn over the whole screen with fullscreenVertexShaderSrc
+func NewEffect(name, fragSrc string) (e *Effect, err error) {
+	program, err := NewProgram(fullscreenVertexShaderSrc, fragSrc)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to create the %s effect", name)
+	}
+	Label(gl.PROGRAM, program, name)
+
+	return &Effect{
+		Name:    name,
+		Enabled: true,
+		Program: program,
+		Params:  map[string]float32{},
+		Inputs:  map[string]*Texture{},
+	}, nil
+}
+
+// draw draws the effect over the viewport reading source as the scene
+func (e *Effect) draw(p *PostProcess, source *Texture) {
+	State.UseProgram(e.Program)
+	source.Bind(gl.TEXTURE0)
+	Backend.Uniform1i(Backend.GetUniformLocation(e.Program, "scene"), 0)
+	Backend.Uniform2f(Backend.GetUniformLocation(e.Program, "texelSize"), 1/float32(p.Width), 1/float32(p.Height))
+
+	for name, value := range e.Params {
+		Backend.Uniform1f(Backend.GetUniformLocation(e.Program, name), value)
+	}
+
+	// sort the inputs so they land on the same units every frame
+	names := make([]string, 0, len(e.Inputs))
+	for name := range e.Inputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		e.Inputs[name].Bind(gl.TEXTURE1 + uint32(i))
+		Backend.Uniform1i(Backend.GetUniformLocation(e.Program, name), int32(1+i))
+	}
+
+	DrawArrays(gl.TRIANGLE_STRIP, 0, 4)
+	CheckError("Effect.draw")
+}
+
+// Delete deletes the program and anything else the effect made. Textures passed in as Inputs
+// belong to whoever made them
+func (e *Effect) Delete() {
+	if e.cleanup != nil {
+		e.cleanup()
+	}
+	DeleteProgram(e.Program)
+}
+
+// copyFragShaderSrc passes the scene through untouched
+var copyFragShaderSrc = `
+	#version 410
+` + EffectGLSL + `
+	void main() {
+		outputColor = texture(scene, fragTexCoord);
+	}
+` + "\x00"
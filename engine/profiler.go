@@ -0,0 +1,277 @@
+package engine
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// profileSamples is how many frames the profiler averages over
+const profileSamples = 60
+
+// rolling is a rolling average over the last profileSamples values
+type rolling struct {
+	samples [profileSamples]float64
+	next    int
+	count   int
+	sum     float64
+}
+
+// add adds a sample, dropping the oldest one once the window is full
+func (r *rolling) add(v float64) {
+	r.sum += v - r.samples[r.next]
+	r.samples[r.next] = v
+	r.next = (r.next + 1) % profileSamples
+	if r.count < profileSamples {
+		r.count++
+	}
+}
+
+// average returns the average of the samples
+func (r *rolling) average() float64 {
+	if r.count == 0 {
+		return 0
+	}
+	return r.sum / float64(r.count)
+}
+
+// profileScope is a named section of the frame we time
+type profileScope struct {
+	name  string
+	depth int
+	cpu   rolling
+	gpu   rolling
+	start time.Time
+
+	// queries are double buffered. One records this frame while the other holds last frame's
+	// result, so reading it back doesn't wait on the gpu
+	queries [2]uint32
+	pending [2]bool
+	timing  bool
+}
+
+// ScopeStats is the averaged timings of a scope in milliseconds. GPU is 0 for scopes that are
+// nested inside another one since timer queries can't overlap
+type ScopeStats struct {
+	Name  string
+	Depth int
+	CPU   float64
+	GPU   float64
+}
+
+// ProfileStats is a summary of the last frames
+type ProfileStats struct {
+	FPS float64
+	// Frame is the average time between frames in milliseconds
+	Frame float64
+	// CPU and GPU are the total time in milliseconds of the top level scopes
+	CPU float64
+	GPU float64
+
+	Scopes []ScopeStats
+
+	// the counts for the last frame
+	DrawCalls  int
+	Triangles  int
+	StateCalls int
+	StateSaved int
+}
+
+// String formats the stats as a single log line
+func (s ProfileStats) String() string {
+	line := fmt.Sprintf("%.0f fps | frame %.2f ms | cpu %.2f ms | gpu %.2f ms | %d draws %d triangles | %d state changes (%d skipped)",
+		s.FPS, s.Frame, s.CPU, s.GPU, s.DrawCalls, s.Triangles, s.StateCalls, s.StateSaved)
+
+	scopes := make([]string, len(s.Scopes))
+	for i, scope := range s.Scopes {
+		scopes[i] = fmt.Sprintf("%s%s %.2f/%.2f", strings.Repeat(">", scope.Depth), scope.Name, scope.CPU, scope.GPU)
+	}
+	if len(scopes) > 0 {
+		line += " | " + strings.Join(scopes, " ")
+	}
+	return line
+}
+
+// Profiler times named scopes of each frame on the cpu and (with timer queries) on the gpu and
+// keeps rolling averages of them. It is safe to call with a nil profiler so scopes can be left in
+// code that doesn't always profile. Only one gpu query can run at a time so only the outermost
+// scope is timed on the gpu
+type Profiler struct {
+	// LogInterval is how often the stats are logged. 0 never logs
+	LogInterval time.Duration
+
+	scopes     map[string]*profileScope
+	order      []*profileScope
+	stack      []*profileScope
+	gpuTiming  bool
+	frame      int
+	frameStart time.Time
+	frameTime  rolling
+	lastLog    time.Time
+	last       ProfileStats
+}
+
+// NewProfiler creates a profiler that logs its stats every interval
+func NewProfiler(interval time.Duration) (p *Profiler) {
+	return &Profiler{
+		LogInterval: interval,
+		scopes:      map[string]*profileScope{},
+	}
+}
+
+// BeginFrame starts timing a frame
+func (p *Profiler) BeginFrame() {
+	if p == nil {
+		return
+	}
+
+	now := time.Now()
+	if !p.frameStart.IsZero() {
+		p.frameTime.add(now.Sub(p.frameStart).Seconds() * 1000)
+	}
+	p.frameStart = now
+	if p.lastLog.IsZero() {
+		p.lastLog = now
+	}
+}
+
+// EndFrame finishes the frame. It collects last frame's gpu timings and logs the stats if it is
+// time to. It has to be called after the State and Draws counters have moved on to the next frame
+func (p *Profiler) EndFrame() {
+	if p == nil {
+		return
+	}
+
+	// the slot the next frame records into holds the results from the frame before this one
+	p.frame++
+	slot := p.frame % 2
+	for _, scope := range p.order {
+		if !scope.pending[slot] {
+			continue
+		}
+
+		var available int32
+		Backend.GetQueryObjectiv(scope.queries[slot], gl.QUERY_RESULT_AVAILABLE, &available)
+		if available == gl.FALSE {
+			// the gpu is more than a frame behind, skip this one rather than wait for it
+			continue
+		}
+
+		var elapsed uint64
+		Backend.GetQueryObjectui64v(scope.queries[slot], gl.QUERY_RESULT, &elapsed)
+		scope.gpu.add(float64(elapsed) / 1e6)
+		scope.pending[slot] = false
+	}
+
+	p.last = p.stats()
+	if p.LogInterval > 0 && time.Since(p.lastLog) >= p.LogInterval {
+		p.lastLog = time.Now()
+		log.Print(p.last)
+	}
+}
+
+// Begin starts timing a scope. Every Begin needs a matching End
+func (p *Profiler) Begin(name string) {
+	if p == nil {
+		return
+	}
+
+	scope, ok := p.scopes[name]
+	if !ok {
+		scope = &profileScope{name: name, depth: len(p.stack)}
+		p.scopes[name] = scope
+		p.order = append(p.order, scope)
+	}
+	p.stack = append(p.stack, scope)
+	scope.start = time.Now()
+
+	// time it on the gpu unless an outer scope already is or last frame's query in this slot
+	// still hasn't come back
+	slot := p.frame % 2
+	scope.timing = !p.gpuTiming && !scope.pending[slot]
+	if !scope.timing {
+		return
+	}
+	if scope.queries[slot] == 0 {
+		Backend.GenQueries(1, &scope.queries[slot])
+		Resources.Track(ResourceQuery, scope.queries[slot])
+	}
+	Backend.BeginQuery(gl.TIME_ELAPSED, scope.queries[slot])
+	p.gpuTiming = true
+}
+
+// End finishes timing the scope started by the last Begin
+func (p *Profiler) End() {
+	if p == nil || len(p.stack) == 0 {
+		return
+	}
+
+	scope := p.stack[len(p.stack)-1]
+	p.stack = p.stack[:len(p.stack)-1]
+	scope.cpu.add(time.Since(scope.start).Seconds() * 1000)
+
+	if scope.timing {
+		Backend.EndQuery(gl.TIME_ELAPSED)
+		scope.pending[p.frame%2] = true
+		scope.timing = false
+		p.gpuTiming = false
+	}
+}
+
+// Stats returns the stats as of the last frame
+func (p *Profiler) Stats() ProfileStats {
+	if p == nil {
+		return ProfileStats{}
+	}
+	return p.last
+}
+
+// stats builds the stats from the rolling averages and the counters for the last frame
+func (p *Profiler) stats() (s ProfileStats) {
+	s.Frame = p.frameTime.average()
+	if s.Frame > 0 {
+		s.FPS = 1000 / s.Frame
+	}
+
+	for _, scope := range p.order {
+		stats := ScopeStats{
+			Name:  scope.name,
+			Depth: scope.depth,
+			CPU:   scope.cpu.average(),
+			GPU:   scope.gpu.average(),
+		}
+		if scope.depth == 0 {
+			s.CPU += stats.CPU
+			s.GPU += stats.GPU
+		}
+		s.Scopes = append(s.Scopes, stats)
+	}
+
+	s.DrawCalls = Draws.LastFrame.Calls
+	s.Triangles = Draws.LastFrame.Triangles
+	s.StateCalls = State.LastFrame.Calls
+	s.StateSaved = State.LastFrame.Saved
+	return s
+}
+
+// Delete deletes the profiler's queries
+func (p *Profiler) Delete() {
+	if p == nil {
+		return
+	}
+
+	for _, scope := range p.order {
+		for i, query := range scope.queries {
+			if query == 0 {
+				continue
+			}
+			Backend.DeleteQueries(1, &scope.queries[i])
+			Resources.Untrack(ResourceQuery, query)
+		}
+	}
+	p.scopes = map[string]*profileScope{}
+	p.order = nil
+}
@@ -0,0 +1,62 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/pkg/errors"
+)
+
+// NewProgram compiles the vertex and fragment shader sources (null terminated GLSL) and links
+// them into a program
+func NewProgram(vertexShaderSrc, fragShaderSrc string) (program uint32, err error) {
+	vertexShader, err := compileShader(vertexShaderSrc, gl.VERTEX_SHADER)
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to compile vertex shader")
+	}
+	defer Backend.DeleteShader(vertexShader)
+
+	fragmentShader, err := compileShader(fragShaderSrc, gl.FRAGMENT_SHADER)
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to compile fragment shader source")
+	}
+	defer Backend.DeleteShader(fragmentShader)
+
+	program = Backend.CreateProgram()
+	Resources.Track(ResourceProgram, program)
+	Backend.AttachShader(program, vertexShader)
+	Backend.AttachShader(program, fragmentShader)
+	Backend.LinkProgram(program)
+	CheckError("glLinkProgram")
+
+	var status int32
+	Backend.GetProgramiv(program, gl.LINK_STATUS, &status)
+	if status == gl.FALSE {
+		l := Backend.GetProgramInfoLog(program)
+		DeleteProgram(program)
+		return 0, fmt.Errorf("failed to link program: %v", l)
+	}
+
+	State.UseProgram(program)
+	return program, nil
+}
+
+// compileShader will take the GLSL raw source and compile it to a shader
+func compileShader(source string, shaderType uint32) (shader uint32, err error) {
+	// initialize a shader for whatever type we are creating
+	shader = Backend.CreateShader(shaderType)
+
+	// point the shader at the source and try to compile the GLSL into machine code
+	Backend.ShaderSource(shader, source)
+	Backend.CompileShader(shader)
+
+	// error handling
+	var status int32
+	Backend.GetShaderiv(shader, gl.COMPILE_STATUS, &status)
+	if status == gl.FALSE {
+		l := Backend.GetShaderInfoLog(shader)
+		Backend.DeleteShader(shader)
+		return 0, errors.New(l)
+	}
+	return shader, nil
+}
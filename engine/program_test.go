@@ -0,0 +1,63 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+func TestNewProgram(t *testing.T) {
+	fake := useFakeGL(t)
+
+	program, err := NewProgram("vertex\x00", "fragment\x00")
+	if err != nil {
+		t.Fatalf("unable to create the program: %v", err)
+	}
+
+	expectCalls(t, fake,
+		"CreateShader", "ShaderSource", "CompileShader", "GetShaderiv",
+		"CreateShader", "ShaderSource", "CompileShader", "GetShaderiv",
+		"CreateProgram", "AttachShader", "AttachShader", "LinkProgram", "GetProgramiv",
+		"UseProgram", "DeleteShader", "DeleteShader",
+	)
+
+	shaders := fake.Find("CreateShader")
+	if shaders[0].Args[0] != uint32(gl.VERTEX_SHADER) || shaders[1].Args[0] != uint32(gl.FRAGMENT_SHADER) {
+		t.Errorf("created shaders %v, want the vertex shader then the fragment shader", shaders)
+	}
+	if source := fake.Find("ShaderSource")[1].Args[1]; source != "fragment\x00" {
+		t.Errorf("the fragment shader was given %q", source)
+	}
+	for i, attach := range fake.Find("AttachShader") {
+		if attach.Args[0] != program {
+			t.Errorf("shader %d was attached to %v, want program %d", i, attach.Args[0], program)
+		}
+	}
+	if fake.Program != program {
+		t.Errorf("program %d is in use, want %d", fake.Program, program)
+	}
+	if live := Resources.Live(); len(live) != 1 || live[0] != (Resource{Kind: ResourceProgram, ID: program}) {
+		t.Errorf("live resources are %v, want just program %d", live, program)
+	}
+}
+
+func TestNewProgramLinkFails(t *testing.T) {
+	fake := useFakeGL(t)
+	fake.LinkFails = "undefined varying"
+
+	program, err := NewProgram("vertex\x00", "fragment\x00")
+	if err == nil || !strings.Contains(err.Error(), "undefined varying") {
+		t.Fatalf("got program %d and error %v, want the link log", program, err)
+	}
+
+	expectCalls(t, fake,
+		"CreateShader", "ShaderSource", "CompileShader", "GetShaderiv",
+		"CreateShader", "ShaderSource", "CompileShader", "GetShaderiv",
+		"CreateProgram", "AttachShader", "AttachShader", "LinkProgram", "GetProgramiv",
+		"GetProgramInfoLog", "DeleteProgram", "DeleteShader", "DeleteShader",
+	)
+	if live := Resources.Live(); len(live) != 0 {
+		t.Errorf("the failed program left %v alive", live)
+	}
+}
@@ -0,0 +1,117 @@
+package engine
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// rayEpsilon is how close to parallel a ray and a triangle can be before we call it a miss
+const rayEpsilon = 1e-7
+
+// Ray is a half line starting at Origin going along Direction. Points along it are Origin +
+// t·Direction for t >= 0, so t is a distance when Direction is unit length
+type Ray struct {
+	Origin    mgl32.Vec3
+	Direction mgl32.Vec3
+}
+
+// At returns the point t along the ray
+func (r Ray) At(t float32) mgl32.Vec3 {
+	return r.Origin.Add(r.Direction.Mul(t))
+}
+
+// Transform returns the ray moved by m. The direction isn't normalized again so t means the same
+// point on both rays, which lets hits found in a mesh's own space be compared in the world
+func (r Ray) Transform(m mgl32.Mat4) Ray {
+	return Ray{
+		Origin:    m.Mul4x1(r.Origin.Vec4(1)).Vec3(),
+		Direction: m.Mul4x1(r.Direction.Vec4(0)).Vec3(),
+	}
+}
+
+// ScreenRay returns the ray from the camera through the point (x, y) of a window width by height.
+// x and y are from the top left like cursor positions and the size has to be in the same units,
+// which for glfw is Window.GetSize rather than the framebuffer size. The ray starts on the near
+// plane and its direction is unit length
+func (c Camera) ScreenRay(x, y float64, width, height int) Ray {
+	// back from the window into normalized device coordinates, which go up instead of down
+	ndcX := float32(2*x/float64(width) - 1)
+	ndcY := float32(1 - 2*y/float64(height))
+
+	// undo the projection and view for the points under the cursor on the near and far planes
+	inverse := c.Projection.Mul4(c.View).Inv()
+	near := mgl32.TransformCoordinate(mgl32.Vec3{ndcX, ndcY, -1}, inverse)
+	far := mgl32.TransformCoordinate(mgl32.Vec3{ndcX, ndcY, 1}, inverse)
+	return Ray{Origin: near, Direction: far.Sub(near).Normalize()}
+}
+
+// IntersectAABB returns how far along the ray it enters the box (0 when it starts inside it). It
+// clips the ray against the pair of planes on each axis and misses when the three ranges it is
+// left with don't overlap
+func (r Ray) IntersectAABB(b AABB) (t float32, ok bool) {
+	near, far := 0.0, math.Inf(1)
+	for axis := 0; axis < 3; axis++ {
+		origin, direction := float64(r.Origin[axis]), float64(r.Direction[axis])
+		low, high := float64(b.Min[axis]), float64(b.Max[axis])
+		if direction == 0 {
+			// parallel to the planes so it is between them the whole way or never
+			if origin < low || origin > high {
+				return 0, false
+			}
+			continue
+		}
+
+		enter, exit := (low-origin)/direction, (high-origin)/direction
+		if enter > exit {
+			enter, exit = exit, enter
+		}
+		near, far = math.Max(near, enter), math.Min(far, exit)
+		if near > far {
+			return 0, false
+		}
+	}
+	return float32(near), true
+}
+
+// IntersectTriangle returns how far along the ray it hits the triangle a, b, c from either side.
+// u and v are the barycentric weights of b and c at the hit. This is Möller and Trumbore's test,
+// which solves for t, u and v at once without finding the triangle's plane first
+func (r Ray) IntersectTriangle(a, b, c mgl32.Vec3) (t, u, v float32, ok bool) {
+	ab, ac := b.Sub(a), c.Sub(a)
+	p := r.Direction.Cross(ac)
+	determinant := ab.Dot(p)
+	if float32(math.Abs(float64(determinant))) < rayEpsilon {
+		return 0, 0, 0, false
+	}
+	inverse := 1 / determinant
+
+	toOrigin := r.Origin.Sub(a)
+	u = toOrigin.Dot(p) * inverse
+	if u < 0 || u > 1 {
+		return 0, 0, 0, false
+	}
+	q := toOrigin.Cross(ab)
+	v = r.Direction.Dot(q) * inverse
+	if v < 0 || u+v > 1 {
+		return 0, 0, 0, false
+	}
+	t = ac.Dot(q) * inverse
+	if t < 0 {
+		return 0, 0, 0, false
+	}
+	return t, u, v, true
+}
+
+// IntersectRay returns the closest of the mesh's triangles the ray hits. triangle is its index, so
+// its vertices are Indices[3*triangle:3*triangle+3] (or the positions starting at 3*triangle
+// without indices)
+func (m *Mesh) IntersectRay(r Ray) (t float32, triangle int, ok bool) {
+	for i, corners := range m.triangles() {
+		hit, _, _, found := r.IntersectTriangle(m.Positions[corners[0]], m.Positions[corners[1]], m.Positions[corners[2]])
+		if found && (!ok || hit < t) {
+			t, triangle, ok = hit, i, true
+		}
+	}
+	return t, triangle, ok
+}
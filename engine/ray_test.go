@@ -0,0 +1,179 @@
+package engine
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// nearly reports whether two floats are the same within float error
+func nearly(a, b float32) bool {
+	return math.Abs(float64(a-b)) < 1e-5
+}
+
+func TestRayIntersectAABB(t *testing.T) {
+	box := AABB{Min: mgl32.Vec3{-1, -1, -1}, Max: mgl32.Vec3{1, 1, 1}}
+
+	cases := []struct {
+		name string
+		ray  Ray
+		t    float32
+		ok   bool
+	}{
+		{"straight on", Ray{mgl32.Vec3{0, 0, 5}, mgl32.Vec3{0, 0, -1}}, 4, true},
+		{"at an angle", Ray{mgl32.Vec3{-3, 0, 3}, mgl32.Vec3{1, 0, -1}.Normalize()}, 2 * math.Sqrt2, true},
+		{"beside it", Ray{mgl32.Vec3{3, 0, 5}, mgl32.Vec3{0, 0, -1}}, 0, false},
+		{"past a corner", Ray{mgl32.Vec3{-3, 0, 0.5}, mgl32.Vec3{1, 0, 1}.Normalize()}, 0, false},
+		{"pointing away", Ray{mgl32.Vec3{0, 0, 5}, mgl32.Vec3{0, 0, 1}}, 0, false},
+		// starting inside the box hits it straight away
+		{"inside", Ray{mgl32.Vec3{0.5, 0, 0}, mgl32.Vec3{0, 1, 0}}, 0, true},
+		// parallel to the x and z slabs, so only y decides
+		{"parallel inside the slabs", Ray{mgl32.Vec3{0.5, -5, 0.5}, mgl32.Vec3{0, 1, 0}}, 4, true},
+		{"parallel outside a slab", Ray{mgl32.Vec3{1.5, -5, 0.5}, mgl32.Vec3{0, 1, 0}}, 0, false},
+		{"parallel on a face", Ray{mgl32.Vec3{1, -5, 0}, mgl32.Vec3{0, 1, 0}}, 4, true},
+	}
+	for _, c := range cases {
+		got, ok := c.ray.IntersectAABB(box)
+		if ok != c.ok || (ok && !nearly(got, c.t)) {
+			t.Errorf("%s: got %v, %v, want %v, %v", c.name, got, ok, c.t, c.ok)
+		}
+	}
+}
+
+func TestRayIntersectTriangle(t *testing.T) {
+	a, b, c := mgl32.Vec3{-1, -1, 0}, mgl32.Vec3{1, -1, 0}, mgl32.Vec3{0, 1, 0}
+
+	cases := []struct {
+		name    string
+		ray     Ray
+		t, u, v float32
+		ok      bool
+	}{
+		// (0, 0) is a + 0.25·(b - a) + 0.5·(c - a)
+		{"from the front", Ray{mgl32.Vec3{0, 0, 5}, mgl32.Vec3{0, 0, -1}}, 5, 0.25, 0.5, true},
+		// the triangles are double sided
+		{"from behind", Ray{mgl32.Vec3{0, 0, -5}, mgl32.Vec3{0, 0, 1}}, 5, 0.25, 0.5, true},
+		{"on a corner", Ray{mgl32.Vec3{1, -1, 2}, mgl32.Vec3{0, 0, -1}}, 2, 1, 0, true},
+		{"outside an edge", Ray{mgl32.Vec3{1, 1, 5}, mgl32.Vec3{0, 0, -1}}, 0, 0, 0, false},
+		{"below it", Ray{mgl32.Vec3{0, -1.5, 5}, mgl32.Vec3{0, 0, -1}}, 0, 0, 0, false},
+		// the triangle is behind where the ray starts
+		{"behind the ray", Ray{mgl32.Vec3{0, 0, -5}, mgl32.Vec3{0, 0, -1}}, 0, 0, 0, false},
+		{"parallel above it", Ray{mgl32.Vec3{-5, 0, 1}, mgl32.Vec3{1, 0, 0}}, 0, 0, 0, false},
+		{"parallel in its plane", Ray{mgl32.Vec3{-5, 0, 0}, mgl32.Vec3{1, 0, 0}}, 0, 0, 0, false},
+	}
+	for _, tc := range cases {
+		gotT, u, v, ok := tc.ray.IntersectTriangle(a, b, c)
+		if ok != tc.ok {
+			t.Errorf("%s: hit is %v, want %v", tc.name, ok, tc.ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if !nearly(gotT, tc.t) || !nearly(u, tc.u) || !nearly(v, tc.v) {
+			t.Errorf("%s: got t %v u %v v %v, want t %v u %v v %v", tc.name, gotT, u, v, tc.t, tc.u, tc.v)
+		}
+		// the barycentric weights and t name the same point
+		weighted := a.Mul(1 - u - v).Add(b.Mul(u)).Add(c.Mul(v))
+		if !weighted.ApproxEqualThreshold(tc.ray.At(gotT), 1e-5) {
+			t.Errorf("%s: hit %v along the ray but %v from the weights", tc.name, tc.ray.At(gotT), weighted)
+		}
+	}
+}
+
+func TestRayTransformKeepsDistances(t *testing.T) {
+	r := Ray{Origin: mgl32.Vec3{1, 2, 3}, Direction: mgl32.Vec3{0, 0, -1}}
+	m := mgl32.Translate3D(5, 0, 0).Mul4(mgl32.Scale3D(2, 2, 2))
+
+	moved := r.Transform(m)
+	for _, along := range []float32{0, 1, 2.5} {
+		want := m.Mul4x1(r.At(along).Vec4(1)).Vec3()
+		if got := moved.At(along); !got.ApproxEqualThreshold(want, 1e-5) {
+			t.Errorf("%v along the moved ray is %v, want %v", along, got, want)
+		}
+	}
+}
+
+func TestMeshIntersectRay(t *testing.T) {
+	m := NewCubeMesh()
+
+	// the +z face is the fifth, so triangles 8 and 9
+	_, triangle, ok := m.IntersectRay(Ray{mgl32.Vec3{0.2, 0.3, 5}, mgl32.Vec3{0, 0, -1}})
+	if !ok || (triangle != 8 && triangle != 9) {
+		t.Errorf("hit triangle %d (%v), want one on the +z face", triangle, ok)
+	}
+
+	// from inside the closest hit is the face in front, not the one behind
+	distance, triangle, ok := m.IntersectRay(Ray{mgl32.Vec3{0, 0, 0.5}, mgl32.Vec3{1, 0, 0}})
+	if !ok || !nearly(distance, 1) || triangle/2 != 0 {
+		t.Errorf("from inside hit triangle %d at %v (%v), want the +x face at 1", triangle, distance, ok)
+	}
+
+	if _, _, ok := m.IntersectRay(Ray{mgl32.Vec3{3, 3, 5}, mgl32.Vec3{0, 0, -1}}); ok {
+		t.Errorf("a ray beside the cube hit it")
+	}
+}
+
+func TestCameraScreenRay(t *testing.T) {
+	eye, target := mgl32.Vec3{1, 2, 3}, mgl32.Vec3{-2, 0, -4}
+	camera := Camera{
+		Position:   eye,
+		View:       mgl32.LookAtV(eye, target, mgl32.Vec3{0, 1, 0}),
+		Projection: mgl32.Perspective(mgl32.DegToRad(60), 800.0/600.0, 0.5, 100),
+	}
+	forward := target.Sub(eye).Normalize()
+
+	// the middle of the window looks straight ahead from the near plane
+	r := camera.ScreenRay(400, 300, 800, 600)
+	if !r.Direction.ApproxEqualThreshold(forward, 1e-4) {
+		t.Errorf("centre ray goes %v, want the camera's forward %v", r.Direction, forward)
+	}
+	if want := eye.Add(forward.Mul(0.5)); !r.Origin.ApproxEqualThreshold(want, 1e-4) {
+		t.Errorf("centre ray starts at %v, want %v on the near plane", r.Origin, want)
+	}
+	if length := r.Direction.Len(); !nearly(length, 1) {
+		t.Errorf("direction is %v long, want 1", length)
+	}
+
+	// the top left corner is up and to the left in the camera's view, 30 degrees up
+	corner := camera.ScreenRay(0, 0, 800, 600)
+	view := camera.View.Mul4x1(corner.Direction.Vec4(0)).Vec3()
+	if view.X() >= 0 || view.Y() <= 0 || view.Z() >= 0 {
+		t.Errorf("top left ray goes %v in view space, want up, left and forward", view)
+	}
+	if up := math.Atan2(float64(view.Y()), float64(-view.Z())); math.Abs(up-math.Pi/6) > 1e-4 {
+		t.Errorf("top left ray is %v degrees up, want 30", up*180/math.Pi)
+	}
+}
+
+func TestNodePickClosest(t *testing.T) {
+	useFakeGL(t)
+
+	mesh := NewMeshBuffers(NewCubeMesh())
+	defer mesh.Delete()
+
+	root := NewNode("root")
+	near, far, aside := NewNode("near"), NewNode("far"), NewNode("aside")
+	for _, n := range []*Node{far, near, aside} {
+		n.Mesh = mesh
+		if err := root.Attach(n); err != nil {
+			t.Fatalf("unable to attach %s: %v", n.Name, err)
+		}
+	}
+	near.SetPosition(mgl32.Vec3{0, 0, -5})
+	far.SetPosition(mgl32.Vec3{0, 0, -10})
+	aside.SetPosition(mgl32.Vec3{5, 0, -2})
+
+	hit, ok := root.Pick(Ray{mgl32.Vec3{0, 0, 0}, mgl32.Vec3{0, 0, -1}})
+	if !ok || hit.Node != near {
+		t.Fatalf("picked %+v (%v), want the near cube", hit, ok)
+	}
+	if !nearly(hit.Distance, 4) || !hit.Point.ApproxEqualThreshold(mgl32.Vec3{0, 0, -4}, 1e-5) {
+		t.Errorf("hit at %v (%v along the ray), want (0, 0, -4) 4 along", hit.Point, hit.Distance)
+	}
+
+	if hit, ok := root.Pick(Ray{mgl32.Vec3{0, 5, 0}, mgl32.Vec3{0, 0, -1}}); ok {
+		t.Errorf("a ray over every cube picked %s", hit.Node.Name)
+	}
+}
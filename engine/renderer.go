@@ -0,0 +1,278 @@
+package engine
+
+import (
+	"sort"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/pkg/errors"
+)
+
+// Drawable is anything that can draw itself with the program in use, like MeshBuffers
+type Drawable interface {
+	Draw()
+}
+
+// Camera is where a frame is rendered from
+type Camera struct {
+	View       mgl32.Mat4
+	Projection mgl32.Mat4
+	Position   mgl32.Vec3
+}
+
+// RenderMaterial is how the items in a render queue are shaded. Share one between every item that
+// looks the same so the renderer can draw them together without setting it up again
+type RenderMaterial struct {
+	Name    string
+	Program uint32
+	// Textures are bound to texture units in order, Textures[0] on gl.TEXTURE0 and so on
+	Textures []*Texture
+	// Bind uploads the material's uniforms. It is called with the program in use before the
+	// first item drawn with the material
+	Bind func()
+	// Transparent items are blended over everything else and drawn furthest first
+	Transparent bool
+}
+
+// RenderItem is one thing to draw: a mesh shaded with a material at a place in the world
+type RenderItem struct {
+	Mesh      Drawable
+	Material  *RenderMaterial
+	Transform mgl32.Mat4
+
+	// depth is how far in front of the camera the item is
+	depth float32
+}
+
+// RenderStats counts what the renderer did in its last Render
+type RenderStats struct {
+	// Items were submitted, Drawn of them were on screen and Culled weren't
+	Items       int
+	Drawn       int
+	Culled      int
+	Transparent int
+	// Programs and Materials are how many times the program and material had to change
+	Programs  int
+	Materials int
+}
+
+// Renderer collects the items to draw in a frame and draws them in the order that changes the
+// least state: grouped by program, material and texture with opaque items front to back, so the
+// depth test throws away hidden pixels early, and transparent items after them back to front so
+// they blend over what is behind them. Items whose mesh is Bounded are skipped when they are
+// outside the camera's frustum
+type Renderer struct {
+	// Cull skips items the camera can't see. It is on by default
+	Cull bool
+	// Frame is sent the camera each Render and bound to each program the first time it is used.
+	// It can be nil for programs that don't use the Camera and Lights blocks
+	Frame *FrameUniforms
+	// Stats are the counts from the last Render
+	Stats RenderStats
+
+	items []RenderItem
+	// materials numbers each material by when it was first submitted so they sort the same
+	// way every frame. nextMaterial only goes up so forgotten numbers aren't given out again
+	materials    map[*RenderMaterial]int
+	nextMaterial int
+	// models are the model uniforms of each program drawn with so far
+	models map[uint32]*ModelUniforms
+
+	// what the last item drawn was drawn with
+	program  uint32
+	material *RenderMaterial
+	uniforms *ModelUniforms
+}
+
+// NewRenderer creates an empty renderer
+func NewRenderer(frame *FrameUniforms) (r *Renderer) {
+	return &Renderer{
+		Cull:      true,
+		Frame:     frame,
+		materials: map[*RenderMaterial]int{},
+		models:    map[uint32]*ModelUniforms{},
+	}
+}
+
+// Submit adds a mesh to draw this frame with the material at the transform
+func (r *Renderer) Submit(mesh Drawable, material *RenderMaterial, transform mgl32.Mat4) {
+	if _, ok := r.materials[material]; !ok {
+		r.materials[material] = r.nextMaterial
+		r.nextMaterial++
+	}
+	r.items = append(r.items, RenderItem{Mesh: mesh, Material: material, Transform: transform})
+}
+
+// SubmitNode submits every node under root (including it) that has a Mesh and Material at its
+// world transform
+func (r *Renderer) SubmitNode(root *Node) {
+	root.Walk(func(n *Node) bool {
+		if n.Mesh != nil && n.Material != nil {
+			r.Submit(n.Mesh, n.Material, n.World())
+		}
+		return true
+	})
+}
+
+// Render sorts and draws everything submitted since the last Render from the camera and empties
+// the queue. Clear the framebuffer first
+func (r *Renderer) Render(camera Camera) (err error) {
+	defer func() { r.items = r.items[:0] }()
+
+	if r.Frame != nil {
+		err = r.Frame.SetCamera(camera.View, camera.Projection, camera.Position)
+		if err != nil {
+			return errors.Wrap(err, "unable to set the camera")
+		}
+	}
+
+	frustum := NewFrustum(camera.Projection.Mul4(camera.View))
+	culled := 0
+	var opaque, transparent []RenderItem
+	for _, item := range r.items {
+		if r.Cull && !visible(item, frustum) {
+			culled++
+			continue
+		}
+		position := camera.View.Mul4x1(item.Transform.Col(3))
+		// the camera looks down -z
+		item.depth = -position.Z()
+		if item.Material.Transparent {
+			transparent = append(transparent, item)
+		} else {
+			opaque = append(opaque, item)
+		}
+	}
+	sort.SliceStable(opaque, func(i, j int) bool {
+		a, b := opaque[i], opaque[j]
+		if a.Material != b.Material {
+			return r.stateLess(a.Material, b.Material)
+		}
+		return a.depth < b.depth
+	})
+	sort.SliceStable(transparent, func(i, j int) bool {
+		a, b := transparent[i], transparent[j]
+		if a.depth != b.depth {
+			return a.depth > b.depth
+		}
+		return r.stateLess(a.Material, b.Material)
+	})
+
+	r.Stats = RenderStats{
+		Items:       len(r.items),
+		Drawn:       len(r.items) - culled,
+		Culled:      culled,
+		Transparent: len(transparent),
+	}
+	r.program, r.material, r.uniforms = 0, nil, nil
+	err = r.draw(opaque)
+	if err != nil {
+		return err
+	}
+
+	if len(transparent) > 0 {
+		// transparent items don't write depth so they don't hide the ones behind them. Blending
+		// and depth writes are put back the way they were after
+		blend, src, dst, depthMask := State.capabilities[gl.BLEND], State.blendSrc, State.blendDst, State.depthMask
+		State.Enable(gl.BLEND)
+		State.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+		State.DepthMask(false)
+		err = r.draw(transparent)
+		State.DepthMask(depthMask)
+		State.BlendFunc(src, dst)
+		if !blend {
+			State.Disable(gl.BLEND)
+		}
+	}
+	return err
+}
+
+// visible reports whether any of the item might be inside the frustum. The sphere is quicker to
+// test so only items it can't rule out have their box tested. Items without bounds are always
+// visible
+func visible(item RenderItem, frustum Frustum) bool {
+	bounded, ok := item.Mesh.(Bounded)
+	if !ok {
+		return true
+	}
+	if !frustum.IntersectsSphere(bounded.BoundingSphere().Transform(item.Transform)) {
+		return false
+	}
+	return frustum.IntersectsAABB(bounded.Bounds().Transform(item.Transform))
+}
+
+// stateLess orders materials by program, then by their first texture and then by when they were
+// first submitted, so items that share state end up next to each other
+func (r *Renderer) stateLess(a, b *RenderMaterial) bool {
+	if a.Program != b.Program {
+		return a.Program < b.Program
+	}
+	if ta, tb := firstTexture(a), firstTexture(b); ta != tb {
+		return ta < tb
+	}
+	return r.materials[a] < r.materials[b]
+}
+
+// firstTexture returns the id of the material's first texture or 0 if it has none
+func firstTexture(m *RenderMaterial) uint32 {
+	if len(m.Textures) == 0 {
+		return 0
+	}
+	return m.Textures[0].ID
+}
+
+// draw draws the items in order, only changing the program and material when they differ from
+// the item drawn before
+func (r *Renderer) draw(items []RenderItem) (err error) {
+	for _, item := range items {
+		if item.Material.Program != r.program {
+			r.program = item.Material.Program
+			r.uniforms, err = r.modelUniforms(r.program)
+			if err != nil {
+				return err
+			}
+			State.UseProgram(r.program)
+			r.Stats.Programs++
+			r.material = nil
+		}
+		if item.Material != r.material {
+			r.material = item.Material
+			for unit, t := range r.material.Textures {
+				t.Bind(gl.TEXTURE0 + uint32(unit))
+			}
+			if r.material.Bind != nil {
+				r.material.Bind()
+			}
+			r.Stats.Materials++
+		}
+
+		r.uniforms.Upload(item.Transform)
+		item.Mesh.Draw()
+	}
+	return nil
+}
+
+// modelUniforms returns the model uniforms of the program, binding the frame blocks to it the
+// first time it is seen
+func (r *Renderer) modelUniforms(program uint32) (u *ModelUniforms, err error) {
+	u, ok := r.models[program]
+	if ok {
+		return u, nil
+	}
+	if r.Frame != nil {
+		err = r.Frame.Bind(program)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to bind the frame uniforms to program %d", program)
+		}
+	}
+	u = NewModelUniforms(program)
+	r.models[program] = u
+	return u, nil
+}
+
+// Forget drops everything the renderer remembers about a material and its program. Call it when
+// they are deleted so new ones that reuse the ids are set up again
+func (r *Renderer) Forget(material *RenderMaterial) {
+	delete(r.materials, material)
+	delete(r.models, material.Program)
+}
@@ -0,0 +1,100 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// namedMesh is a mesh that adds its name to drawn each time it is drawn
+type namedMesh struct {
+	name  string
+	drawn *[]string
+}
+
+func (m *namedMesh) Draw() { *m.drawn = append(*m.drawn, m.name) }
+
+func TestRendererSortOrder(t *testing.T) {
+	useFakeGL(t)
+
+	// the camera looks down -z from the origin so an item's depth is how far down -z it is
+	camera := Camera{View: mgl32.Ident4(), Projection: mgl32.Perspective(mgl32.DegToRad(90), 1, 0.1, 100)}
+	at := func(depth float32) mgl32.Mat4 { return mgl32.Translate3D(0, 0, -depth) }
+	var drawn []string
+	mesh := func(name string) Drawable { return &namedMesh{name: name, drawn: &drawn} }
+
+	brick, wood := &Texture{ID: 5}, &Texture{ID: 3}
+	old := &RenderMaterial{Name: "old", Program: 1, Textures: []*Texture{wood}}
+	planks := &RenderMaterial{Name: "planks", Program: 1, Textures: []*Texture{wood}}
+	bricks := &RenderMaterial{Name: "bricks", Program: 1, Textures: []*Texture{brick}}
+	lit := &RenderMaterial{Name: "lit", Program: 2, Textures: []*Texture{wood}}
+	glass := &RenderMaterial{Name: "glass", Program: 1, Transparent: true}
+	smoke := &RenderMaterial{Name: "smoke", Program: 2, Transparent: true}
+
+	r := NewRenderer(nil)
+	r.Cull = false
+	r.Submit(mesh("old"), old, at(1))
+	r.Submit(mesh("planks"), planks, at(1))
+	if err := r.Render(camera); err != nil {
+		t.Fatalf("unable to render: %v", err)
+	}
+	// a material made after old is forgotten still sorts after planks, which was submitted first
+	r.Forget(old)
+	fresh := &RenderMaterial{Name: "fresh", Program: 1, Textures: []*Texture{wood}}
+
+	drawn = nil
+	r.Submit(mesh("fresh 1"), fresh, at(1))
+	r.Submit(mesh("lit 9"), lit, at(9))
+	r.Submit(mesh("glass 3"), glass, at(3))
+	r.Submit(mesh("bricks 4"), bricks, at(4))
+	r.Submit(mesh("smoke 8"), smoke, at(8))
+	r.Submit(mesh("lit 2"), lit, at(2))
+	r.Submit(mesh("fresh 3"), fresh, at(3))
+	r.Submit(mesh("glass 5"), glass, at(5))
+	r.Submit(mesh("planks 6"), planks, at(6))
+	if err := r.Render(camera); err != nil {
+		t.Fatalf("unable to render: %v", err)
+	}
+
+	// opaque items by program, then texture, then material and front to back within a material.
+	// Transparent items after them from back to front
+	want := []string{
+		"planks 6", "fresh 1", "fresh 3", "bricks 4", "lit 2", "lit 9",
+		"smoke 8", "glass 5", "glass 3",
+	}
+	if !reflect.DeepEqual(drawn, want) {
+		t.Errorf("drew %v, want %v", drawn, want)
+	}
+	if r.Stats.Programs != 3 || r.Stats.Materials != 6 || r.Stats.Transparent != 3 {
+		t.Errorf("stats are %+v, want 3 programs, 6 materials and 3 transparent items", r.Stats)
+	}
+}
+
+func TestRendererRestoresBlending(t *testing.T) {
+	useFakeGL(t)
+
+	camera := Camera{View: mgl32.Ident4(), Projection: mgl32.Perspective(mgl32.DegToRad(90), 1, 0.1, 100)}
+	glass := &RenderMaterial{Program: 1, Transparent: true}
+	render := func() {
+		r := NewRenderer(nil)
+		r.Submit(&unboundedMesh{}, glass, mgl32.Translate3D(0, 0, -2))
+		if err := r.Render(camera); err != nil {
+			t.Fatalf("unable to render: %v", err)
+		}
+	}
+
+	render()
+	if State.capabilities[gl.BLEND] || !State.depthMask {
+		t.Errorf("blending is %v and depth writes are %v after rendering, want off and on", State.capabilities[gl.BLEND], State.depthMask)
+	}
+
+	// a caller blending already keeps blending with its own function
+	State.Enable(gl.BLEND)
+	State.BlendFunc(gl.ONE, gl.ONE)
+	render()
+	if !State.capabilities[gl.BLEND] || State.blendSrc != gl.ONE || State.blendDst != gl.ONE {
+		t.Errorf("blending is %v with %d, %d after rendering, want it left on with one, one", State.capabilities[gl.BLEND], State.blendSrc, State.blendDst)
+	}
+}
@@ -0,0 +1,151 @@
+package engine
+
+import (
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sort"
+)
+
+// ResourceKind is the kind of openGL object a resource is
+type ResourceKind string
+
+// The kinds of openGL objects we track
+const (
+	ResourceBuffer      ResourceKind = "buffer"
+	ResourceVertexArray ResourceKind = "vertex array"
+	ResourceTexture     ResourceKind = "texture"
+	ResourceProgram     ResourceKind = "program"
+	ResourceQuery       ResourceKind = "query"
+	ResourceFramebuffer ResourceKind = "framebuffer"
+)
+
+// Resource is a live openGL object
+type Resource struct {
+	Kind ResourceKind
+	ID   uint32
+	// Stack is where the object was created. It is only captured in debug mode
+	Stack string
+}
+
+// String describes the resource, for example "texture 3"
+func (r Resource) String() string {
+	return fmt.Sprintf("%s %d", r.Kind, r.ID)
+}
+
+// resourceKey identifies a resource. Names are only unique within a kind
+type resourceKey struct {
+	kind ResourceKind
+	id   uint32
+}
+
+// ResourceRegistry keeps track of every openGL object that has been created and not deleted yet
+// so we can find the ones that leak. Like the rest of openGL it should only be used from the
+// main thread
+type ResourceRegistry struct {
+	// Traces captures a stack trace for every object that is created. It is turned on in debug
+	// mode since it is too slow to leave on
+	Traces bool
+
+	live map[resourceKey]Resource
+}
+
+// Resources is the registry for the app's openGL context
+var Resources = NewResourceRegistry()
+
+// NewResourceRegistry creates an empty registry
+func NewResourceRegistry() (r *ResourceRegistry) {
+	return &ResourceRegistry{
+		live: map[resourceKey]Resource{},
+	}
+}
+
+// Track records that an object was created
+func (r *ResourceRegistry) Track(kind ResourceKind, id uint32) {
+	res := Resource{Kind: kind, ID: id}
+	if r.Traces {
+		res.Stack = string(debug.Stack())
+	}
+	r.live[resourceKey{kind: kind, id: id}] = res
+}
+
+// Untrack records that an object was deleted
+func (r *ResourceRegistry) Untrack(kind ResourceKind, id uint32) {
+	delete(r.live, resourceKey{kind: kind, id: id})
+}
+
+// Live returns every object that hasn't been deleted yet sorted by kind and then id
+func (r *ResourceRegistry) Live() (live []Resource) {
+	for _, res := range r.live {
+		live = append(live, res)
+	}
+	sort.Slice(live, func(i, j int) bool {
+		if live[i].Kind != live[j].Kind {
+			return live[i].Kind < live[j].Kind
+		}
+		return live[i].ID < live[j].ID
+	})
+	return live
+}
+
+// Counts returns how many objects of each kind are alive
+func (r *ResourceRegistry) Counts() (counts map[ResourceKind]int) {
+	counts = map[ResourceKind]int{}
+	for key := range r.live {
+		counts[key.kind]++
+	}
+	return counts
+}
+
+// Report logs every object that hasn't been deleted. The app calls it after Shutdown so anything
+// still alive then has leaked
+func (r *ResourceRegistry) Report() {
+	live := r.Live()
+	if len(live) == 0 {
+		return
+	}
+
+	log.Printf("%d openGL objects were never deleted:", len(live))
+	for _, res := range live {
+		if res.Stack == "" {
+			log.Printf("  %s", res)
+			continue
+		}
+		log.Printf("  %s created at:\n%s", res, res.Stack)
+	}
+}
+
+// GenBuffer creates a buffer and tracks it
+func GenBuffer() (buffer uint32) {
+	Backend.GenBuffers(1, &buffer)
+	Resources.Track(ResourceBuffer, buffer)
+	return buffer
+}
+
+// DeleteBuffer deletes a buffer made with GenBuffer
+func DeleteBuffer(buffer uint32) {
+	Backend.DeleteBuffers(1, &buffer)
+	State.forgetBuffer(buffer)
+	Resources.Untrack(ResourceBuffer, buffer)
+}
+
+// GenVertexArray creates a vertex array and tracks it
+func GenVertexArray() (vao uint32) {
+	Backend.GenVertexArrays(1, &vao)
+	Resources.Track(ResourceVertexArray, vao)
+	return vao
+}
+
+// DeleteVertexArray deletes a vertex array made with GenVertexArray
+func DeleteVertexArray(vao uint32) {
+	Backend.DeleteVertexArrays(1, &vao)
+	State.forgetVertexArray(vao)
+	Resources.Untrack(ResourceVertexArray, vao)
+}
+
+// DeleteProgram deletes a program made with NewProgram
+func DeleteProgram(program uint32) {
+	// a program that is in use stays bound until another one replaces it so the cache is still right
+	Backend.DeleteProgram(program)
+	Resources.Untrack(ResourceProgram, program)
+}
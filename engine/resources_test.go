@@ -0,0 +1,66 @@
+package engine
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResourcesTrackObjects(t *testing.T) {
+	useFakeGL(t)
+
+	buffer := GenBuffer()
+	vao := GenVertexArray()
+	program, err := NewProgram("vertex\x00", "fragment\x00")
+	if err != nil {
+		t.Fatalf("unable to create the program: %v", err)
+	}
+	other := GenBuffer()
+
+	want := []Resource{
+		{Kind: ResourceBuffer, ID: buffer},
+		{Kind: ResourceBuffer, ID: other},
+		{Kind: ResourceProgram, ID: program},
+		{Kind: ResourceVertexArray, ID: vao},
+	}
+	if live := Resources.Live(); !reflect.DeepEqual(live, want) {
+		t.Errorf("live resources are %v, want %v", live, want)
+	}
+	counts := map[ResourceKind]int{ResourceBuffer: 2, ResourceProgram: 1, ResourceVertexArray: 1}
+	if got := Resources.Counts(); !reflect.DeepEqual(got, counts) {
+		t.Errorf("counts are %v, want %v", got, counts)
+	}
+
+	DeleteBuffer(buffer)
+	DeleteVertexArray(vao)
+	DeleteProgram(program)
+	if live := Resources.Live(); len(live) != 1 || live[0].ID != other {
+		t.Errorf("after deleting the rest %v are live, want just buffer %d", live, other)
+	}
+}
+
+func TestResourcesKindsDontCollide(t *testing.T) {
+	r := NewResourceRegistry()
+	r.Track(ResourceBuffer, 1)
+	r.Track(ResourceTexture, 1)
+	r.Untrack(ResourceBuffer, 1)
+
+	if live := r.Live(); len(live) != 1 || live[0].Kind != ResourceTexture {
+		t.Errorf("live resources are %v, want just texture 1", live)
+	}
+}
+
+func TestResourcesTraces(t *testing.T) {
+	r := NewResourceRegistry()
+	r.Track(ResourceQuery, 1)
+	r.Traces = true
+	r.Track(ResourceQuery, 2)
+
+	live := r.Live()
+	if live[0].Stack != "" {
+		t.Errorf("a trace was captured with Traces off")
+	}
+	if !strings.Contains(live[1].Stack, "TestResourcesTraces") {
+		t.Errorf("the trace doesn't show where the object was made:\n%s", live[1].Stack)
+	}
+}
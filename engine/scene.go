@@ -0,0 +1,72 @@
+package engine
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+)
+
+// Scene is a demo that can be run by name from the launcher
+type Scene struct {
+	// Name is what the scene is run by on the command line
+	Name string
+	// Title is shown in the window's title bar
+	Title string
+	// Width and Height are the window size the scene was designed for
+	Width  int
+	Height int
+
+	// Flags are the scene's own options, nil when it has none. The launcher parses the arguments
+	// that come after the scene's name with them before Setup is called
+	Flags *flag.FlagSet
+
+	// Setup sets the hooks on the app that make up the scene
+	Setup func(a *App)
+}
+
+// scenes are all of the registered scenes by name
+var scenes = map[string]Scene{}
+
+// Register adds a scene so it can be run by name. It is meant to be called from a package's init
+func Register(scene Scene) {
+	if _, ok := scenes[scene.Name]; ok {
+		panic(fmt.Sprintf("scene %s is already registered", scene.Name))
+	}
+	scenes[scene.Name] = scene
+}
+
+// LookupScene returns the scene registered under the name
+func LookupScene(name string) (scene Scene, ok bool) {
+	scene, ok = scenes[name]
+	return scene, ok
+}
+
+// Scenes returns every registered scene sorted by name
+func Scenes() []Scene {
+	list := make([]Scene, 0, len(scenes))
+	for _, scene := range scenes {
+		list = append(list, scene)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// NewSceneApp creates an app running the scene. The config's title, width and height are
+// filled in from the scene if they aren't set
+func NewSceneApp(scene Scene, config Config) (a *App) {
+	if config.Title == "" {
+		config.Title = scene.Title
+	}
+	if config.Width == 0 {
+		config.Width = scene.Width
+	}
+	if config.Height == 0 {
+		config.Height = scene.Height
+	}
+
+	a = NewApp(config)
+	scene.Setup(a)
+	return a
+}
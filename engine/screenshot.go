@@ -0,0 +1,43 @@
+package engine
+
+import (
+	"image"
+	"image/png"
+	"os"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/pkg/errors"
+)
+
+// Screenshot reads back the frame that was just rendered and saves it as a png. It has to be called
+// after rendering and before the buffers are swapped
+func (a *App) Screenshot(file string) (err error) {
+	width, height := a.Window.GetFramebufferSize()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	gl.PixelStorei(gl.PACK_ALIGNMENT, 1)
+	gl.ReadPixels(0, 0, int32(width), int32(height), gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(img.Pix))
+
+	// openGL's rows start at the bottom of the screen and images start at the top so flip it
+	stride := img.Stride
+	row := make([]byte, stride)
+	for y := 0; y < height/2; y++ {
+		top := img.Pix[y*stride : (y+1)*stride]
+		bottom := img.Pix[(height-1-y)*stride : (height-y)*stride]
+		copy(row, top)
+		copy(top, bottom)
+		copy(bottom, row)
+	}
+
+	f, err := os.Create(file)
+	if err != nil {
+		return errors.Wrap(err, "unable to create screenshot file")
+	}
+	defer f.Close()
+
+	err = png.Encode(f, img)
+	if err != nil {
+		return errors.Wrap(err, "unable to encode screenshot")
+	}
+	return nil
+}
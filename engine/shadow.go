@@ -0,0 +1,199 @@
+package engine
+
+import (
+	"math"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// MaxShadows is how many shadow casting lights a program using LightsGLSL can have at once
+const MaxShadows = 4
+
+// ShadowTextureUnit is the first texture unit shadow maps are bound to. They use the units after
+// it up to MaxShadows so programs can keep using the low units for their own textures
+const ShadowTextureUnit = 8
+
+// ShadowMap is the depth of the scene as seen from a light. Anything further from the light than
+// the depth stored in the map is in shadow
+type ShadowMap struct {
+	Framebuffer *Framebuffer
+	Depth       *Texture
+	// Size is the width and height of the map in texels. Bigger maps give sharper shadows
+	Size int
+	// Bias pushes the depth we compare against towards the light so surfaces don't shadow
+	// themselves (shadow acne). Too much makes shadows detach from what casts them
+	Bias float32
+	// LightSpace transforms world positions into the light's clip space. Update sets it
+	LightSpace mgl32.Mat4
+}
+
+// NewShadowMap creates a square shadow map size texels across
+func NewShadowMap(size int) (s *ShadowMap, err error) {
+	s = &ShadowMap{
+		Framebuffer: NewFramebuffer(size, size),
+		Depth:       NewDepthTexture(size, size),
+		Size:        size,
+		Bias:        0.005,
+		LightSpace:  mgl32.Ident4(),
+	}
+
+	// there is no color to draw so tell the framebuffer not to expect any
+	s.Framebuffer.AttachTexture(gl.DEPTH_ATTACHMENT, s.Depth)
+	Backend.DrawBuffer(gl.NONE)
+	Backend.ReadBuffer(gl.NONE)
+	err = s.Framebuffer.Check()
+	// it was never bound with Bind so there is no viewport to go back to
+	State.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	if err != nil {
+		s.Delete()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Update points the shadow map at what the light sees. Directional lights cover a box radius
+// units around center and spot lights cover their cone out to radius units from the light
+func (s *ShadowMap) Update(light Light, center mgl32.Vec3, radius float32) {
+	direction := light.Direction.Normalize()
+
+	// any up works as long as it isn't the way the light is facing
+	up := mgl32.Vec3{0, 1, 0}
+	if math.Abs(float64(direction.Dot(up))) > 0.99 {
+		up = mgl32.Vec3{0, 0, 1}
+	}
+
+	switch light.Kind {
+	case SpotLight:
+		view := mgl32.LookAtV(light.Position, light.Position.Add(direction), up)
+		projection := mgl32.Perspective(mgl32.DegToRad(2*light.OuterCone), 1, 0.1, radius)
+		s.LightSpace = projection.Mul4(view)
+	default:
+		// back the light away from the center so everything in the box is in front of it
+		eye := center.Sub(direction.Mul(2 * radius))
+		view := mgl32.LookAtV(eye, center, up)
+		projection := mgl32.Ortho(-radius, radius, -radius, radius, radius, 3*radius)
+		s.LightSpace = projection.Mul4(view)
+	}
+}
+
+// Begin starts the shadow pass. It renders into the shadow map with the program (usually one made
+// with NewShadowProgram) and uploads the light space matrix to its lightSpace uniform. Draw
+// everything that casts a shadow and then call End
+func (s *ShadowMap) Begin(program uint32) {
+	s.Framebuffer.Bind()
+	State.DepthMask(true)
+	Backend.Clear(gl.DEPTH_BUFFER_BIT)
+
+	State.UseProgram(program)
+	Backend.UniformMatrix4fv(Backend.GetUniformLocation(program, "lightSpace"), 1, false, &s.LightSpace[0])
+	CheckError("ShadowMap.Begin")
+}
+
+// End finishes the shadow pass and goes back to rendering into the window
+func (s *ShadowMap) End() {
+	s.Framebuffer.Unbind()
+}
+
+// Delete deletes the framebuffer and depth texture
+func (s *ShadowMap) Delete() {
+	s.Framebuffer.Delete()
+	s.Depth.Delete()
+}
+
+// shadowVertexShaderSrc only transforms positions into light space. It expects the position in
+// attribute 0 so it can share vaos with other programs
+var shadowVertexShaderSrc = `
+	#version 410
+
+	uniform mat4 lightSpace;
+	uniform mat4 model;
+
+	layout(location = 0) in vec3 vert;
+
+	void main() {
+		gl_Position = lightSpace * model * vec4(vert, 1.0);
+	}
+` + "\x00"
+
+// shadowFragShaderSrc writes nothing, the depth is written for us
+var shadowFragShaderSrc = `
+	#version 410
+
+	void main() {
+	}
+` + "\x00"
+
+// NewShadowProgram creates the program for drawing into shadow maps. The vaos drawn with it need
+// the position in attribute 0 and the model matrix goes in the model uniform
+func NewShadowProgram() (program uint32, err error) {
+	program, err = NewProgram(shadowVertexShaderSrc, shadowFragShaderSrc)
+	if err != nil {
+		return 0, err
+	}
+	Label(gl.PROGRAM, program, "shadow")
+	return program, nil
+}
+
+// shadowDebugFragShaderSrc shows the depth as grey, black is close to the light
+var shadowDebugFragShaderSrc = `
+	#version 410
+
+	uniform sampler2D depthMap;
+
+	in vec2 fragTexCoord;
+
+	out vec4 outputColor;
+
+	void main() {
+		float depth = texture(depthMap, fragTexCoord).r;
+		outputColor = vec4(vec3(depth), 1.0);
+	}
+` + "\x00"
+
+// ShadowDebugView draws a shadow map on the screen so we can see what the light sees
+type ShadowDebugView struct {
+	program uint32
+	vao     uint32
+}
+
+// NewShadowDebugView creates the program for drawing shadow maps
+func NewShadowDebugView() (v *ShadowDebugView, err error) {
+	program, err := NewProgram(fullscreenVertexShaderSrc, shadowDebugFragShaderSrc)
+	if err != nil {
+		return nil, err
+	}
+	Backend.Uniform1i(Backend.GetUniformLocation(program, "depthMap"), 0)
+	Label(gl.PROGRAM, program, "shadow debug")
+
+	// the core profile needs a vao bound to draw even when there is no vertex data
+	return &ShadowDebugView{program: program, vao: GenVertexArray()}, nil
+}
+
+// Draw draws the shadow map into a rectangle of the window in pixels
+func (v *ShadowDebugView) Draw(s *ShadowMap, x, y, width, height int32) {
+	px, py, pwidth, pheight := State.ViewportRect()
+	depthTest := State.capabilities[gl.DEPTH_TEST]
+	State.Viewport(x, y, width, height)
+	State.Disable(gl.DEPTH_TEST)
+
+	// the map compares depths by default, we want to see them so turn that off while we draw
+	s.Depth.Bind(gl.TEXTURE0)
+	Backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_COMPARE_MODE, gl.NONE)
+
+	State.UseProgram(v.program)
+	State.BindVertexArray(v.vao)
+	DrawArrays(gl.TRIANGLE_STRIP, 0, 4)
+
+	Backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_COMPARE_MODE, gl.COMPARE_REF_TO_TEXTURE)
+	if depthTest {
+		State.Enable(gl.DEPTH_TEST)
+	}
+	State.Viewport(px, py, pwidth, pheight)
+}
+
+// Delete deletes the program and vao
+func (v *ShadowDebugView) Delete() {
+	DeleteVertexArray(v.vao)
+	DeleteProgram(v.program)
+}
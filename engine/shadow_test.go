@@ -0,0 +1,56 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+func TestNewShadowMapKeepsViewport(t *testing.T) {
+	fake := useFakeGL(t)
+	State.Viewport(0, 0, 800, 600)
+
+	s, err := NewShadowMap(1024)
+	if err != nil {
+		t.Fatalf("unable to create the shadow map: %v", err)
+	}
+	defer s.Delete()
+
+	if viewports := fake.Find("Viewport"); len(viewports) != 1 {
+		t.Errorf("creating the shadow map set the viewport: %v", viewports[1:])
+	}
+	if x, y, w, h := State.ViewportRect(); x != 0 || y != 0 || w != 800 || h != 600 {
+		t.Errorf("viewport is (%d, %d, %d, %d) after creating the shadow map", x, y, w, h)
+	}
+	if fake.Framebuffers[gl.DRAW_FRAMEBUFFER] != 0 || fake.Framebuffers[gl.READ_FRAMEBUFFER] != 0 {
+		t.Errorf("the shadow map is still bound: %v", fake.Framebuffers)
+	}
+}
+
+func TestShadowMapPassRestoresViewport(t *testing.T) {
+	fake := useFakeGL(t)
+	State.Viewport(0, 0, 800, 600)
+
+	s, err := NewShadowMap(1024)
+	if err != nil {
+		t.Fatalf("unable to create the shadow map: %v", err)
+	}
+	defer s.Delete()
+
+	fake.Reset()
+	s.Begin(1)
+	if x, y, w, h := State.ViewportRect(); x != 0 || y != 0 || w != 1024 || h != 1024 {
+		t.Errorf("viewport is (%d, %d, %d, %d) in the shadow pass, want the map's size", x, y, w, h)
+	}
+	if fake.Framebuffers[gl.DRAW_FRAMEBUFFER] != s.Framebuffer.ID {
+		t.Errorf("rendering into framebuffer %d, want the shadow map", fake.Framebuffers[gl.DRAW_FRAMEBUFFER])
+	}
+
+	s.End()
+	if x, y, w, h := State.ViewportRect(); x != 0 || y != 0 || w != 800 || h != 600 {
+		t.Errorf("viewport is (%d, %d, %d, %d) after the shadow pass, want the window's", x, y, w, h)
+	}
+	if fake.Framebuffers[gl.DRAW_FRAMEBUFFER] != 0 {
+		t.Errorf("still rendering into framebuffer %d after the shadow pass", fake.Framebuffers[gl.DRAW_FRAMEBUFFER])
+	}
+}
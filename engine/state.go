@@ -0,0 +1,299 @@
+package engine
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// StateStats counts the openGL state calls that went through the cache
+type StateStats struct {
+	// Calls is how many calls actually made it to openGL
+	Calls int
+	// Saved is how many calls were skipped because the state was already set
+	Saved int
+}
+
+// textureBinding is a texture target on a texture unit
+type textureBinding struct {
+	unit   uint32
+	target uint32
+}
+
+// StateCache shadows the openGL state we change (bound objects, capabilities and render modes)
+// so calls that wouldn't change anything can be skipped. Everything that binds or sets this state
+// has to go through the cache or it will get out of sync. Call Invalidate after making raw gl calls
+type StateCache struct {
+	program    uint32
+	vao        uint32
+	buffers    map[uint32]uint32
+	activeUnit uint32
+	textures   map[textureBinding]uint32
+
+	framebuffers map[uint32]uint32
+	viewport     [4]int32
+
+	capabilities map[uint32]bool
+	blendSrc     uint32
+	blendDst     uint32
+	depthFunc    uint32
+	depthMask    bool
+	cullFace     uint32
+	polygonMode  uint32
+
+	// Frame counts the calls for the frame being drawn and LastFrame holds the counts for the
+	// frame before it
+	Frame     StateStats
+	LastFrame StateStats
+}
+
+// State is the state cache for the app's openGL context
+var State = NewStateCache()
+
+// NewStateCache creates a cache that assumes openGL's default state
+func NewStateCache() (s *StateCache) {
+	s = &StateCache{}
+	s.Invalidate()
+	return s
+}
+
+// Invalidate resets the cache to openGL's default state. Anything that differs will be
+// set again the next time it is used
+func (s *StateCache) Invalidate() {
+	s.program = 0
+	s.vao = 0
+	s.buffers = map[uint32]uint32{}
+	s.activeUnit = gl.TEXTURE0
+	s.textures = map[textureBinding]uint32{}
+	s.framebuffers = map[uint32]uint32{}
+
+	// the viewport starts out as the size of the window. An empty one is never cached so the
+	// first Viewport always goes through
+	s.viewport = [4]int32{}
+
+	s.capabilities = map[uint32]bool{
+		gl.DITHER:      true,
+		gl.MULTISAMPLE: true,
+	}
+	s.blendSrc = gl.ONE
+	s.blendDst = gl.ZERO
+	s.depthFunc = gl.LESS
+	s.depthMask = true
+	s.cullFace = gl.BACK
+	s.polygonMode = gl.FILL
+}
+
+// EndFrame moves this frame's counts to LastFrame and starts counting again
+func (s *StateCache) EndFrame() {
+	s.LastFrame = s.Frame
+	s.Frame = StateStats{}
+}
+
+// changed counts the call and returns whether it needs to be made
+func (s *StateCache) changed(changed bool) bool {
+	if changed {
+		s.Frame.Calls++
+	} else {
+		s.Frame.Saved++
+	}
+	return changed
+}
+
+// UseProgram makes the program the active one
+func (s *StateCache) UseProgram(program uint32) {
+	if s.changed(s.program != program) {
+		s.program = program
+		Backend.UseProgram(program)
+		CheckError("glUseProgram")
+	}
+}
+
+// BindVertexArray binds the vao
+func (s *StateCache) BindVertexArray(vao uint32) {
+	if s.changed(s.vao != vao) {
+		s.vao = vao
+		Backend.BindVertexArray(vao)
+		CheckError("glBindVertexArray")
+
+		// the element buffer binding belongs to the vao so we don't know what it is anymore
+		delete(s.buffers, gl.ELEMENT_ARRAY_BUFFER)
+	}
+}
+
+// BindBuffer binds the buffer to the target (gl.ARRAY_BUFFER, gl.ELEMENT_ARRAY_BUFFER, ...)
+func (s *StateCache) BindBuffer(target, buffer uint32) {
+	bound, ok := s.buffers[target]
+	if s.changed(!ok || bound != buffer) {
+		s.buffers[target] = buffer
+		Backend.BindBuffer(target, buffer)
+		CheckError("glBindBuffer")
+	}
+}
+
+// BindBufferBase binds the buffer to an indexed binding point of the target (gl.UNIFORM_BUFFER, ...).
+// openGL binds it to the target too so the cache does the same. The indexed bindings aren't cached
+// since they only change when a buffer is created
+func (s *StateCache) BindBufferBase(target, index, buffer uint32) {
+	s.changed(true)
+	s.buffers[target] = buffer
+	Backend.BindBufferBase(target, index, buffer)
+	CheckError("glBindBufferBase")
+}
+
+// ActiveTexture selects the texture unit (gl.TEXTURE0, gl.TEXTURE1, ...) that texture binds go to
+func (s *StateCache) ActiveTexture(unit uint32) {
+	if s.changed(s.activeUnit != unit) {
+		s.activeUnit = unit
+		Backend.ActiveTexture(unit)
+		CheckError("glActiveTexture")
+	}
+}
+
+// BindTexture binds the texture to the target (gl.TEXTURE_2D, ...) on the texture unit
+func (s *StateCache) BindTexture(unit, target, texture uint32) {
+	binding := textureBinding{unit: unit, target: target}
+	if s.changed(s.textures[binding] != texture) {
+		// switching the unit counts as a call of its own, but leaving it alone isn't a saved call
+		// because the bind still goes through
+		if s.activeUnit != unit {
+			s.Frame.Calls++
+			s.activeUnit = unit
+			Backend.ActiveTexture(unit)
+			CheckError("glActiveTexture")
+		}
+		s.textures[binding] = texture
+		Backend.BindTexture(target, texture)
+		CheckError("glBindTexture")
+	}
+}
+
+// BindFramebuffer binds the framebuffer to the target. gl.FRAMEBUFFER binds both the draw and read
+// framebuffers. 0 is the window
+func (s *StateCache) BindFramebuffer(target, framebuffer uint32) {
+	changed := s.framebuffers[target] != framebuffer
+	if target == gl.FRAMEBUFFER {
+		changed = s.framebuffers[gl.DRAW_FRAMEBUFFER] != framebuffer || s.framebuffers[gl.READ_FRAMEBUFFER] != framebuffer
+	}
+	if s.changed(changed) {
+		if target == gl.FRAMEBUFFER {
+			s.framebuffers[gl.DRAW_FRAMEBUFFER] = framebuffer
+			s.framebuffers[gl.READ_FRAMEBUFFER] = framebuffer
+		} else {
+			s.framebuffers[target] = framebuffer
+		}
+		Backend.BindFramebuffer(target, framebuffer)
+		CheckError("glBindFramebuffer")
+	}
+}
+
+// Viewport sets the part of the framebuffer we draw to
+func (s *StateCache) Viewport(x, y, width, height int32) {
+	viewport := [4]int32{x, y, width, height}
+	if s.changed(s.viewport != viewport || width == 0 || height == 0) {
+		s.viewport = viewport
+		Backend.Viewport(x, y, width, height)
+		CheckError("glViewport")
+	}
+}
+
+// ViewportRect returns the viewport last set with Viewport
+func (s *StateCache) ViewportRect() (x, y, width, height int32) {
+	return s.viewport[0], s.viewport[1], s.viewport[2], s.viewport[3]
+}
+
+// Enable turns on an openGL capability (gl.BLEND, gl.DEPTH_TEST, gl.CULL_FACE, ...)
+func (s *StateCache) Enable(capability uint32) {
+	if s.changed(!s.capabilities[capability]) {
+		s.capabilities[capability] = true
+		Backend.Enable(capability)
+		CheckError("glEnable")
+	}
+}
+
+// Disable turns off an openGL capability
+func (s *StateCache) Disable(capability uint32) {
+	if s.changed(s.capabilities[capability]) {
+		s.capabilities[capability] = false
+		Backend.Disable(capability)
+		CheckError("glDisable")
+	}
+}
+
+// BlendFunc sets how source and destination colors are blended
+func (s *StateCache) BlendFunc(src, dst uint32) {
+	if s.changed(s.blendSrc != src || s.blendDst != dst) {
+		s.blendSrc, s.blendDst = src, dst
+		Backend.BlendFunc(src, dst)
+		CheckError("glBlendFunc")
+	}
+}
+
+// DepthFunc sets the depth test comparison
+func (s *StateCache) DepthFunc(function uint32) {
+	if s.changed(s.depthFunc != function) {
+		s.depthFunc = function
+		Backend.DepthFunc(function)
+		CheckError("glDepthFunc")
+	}
+}
+
+// DepthMask sets whether we write to the depth buffer
+func (s *StateCache) DepthMask(write bool) {
+	if s.changed(s.depthMask != write) {
+		s.depthMask = write
+		Backend.DepthMask(write)
+		CheckError("glDepthMask")
+	}
+}
+
+// CullFace sets which faces get culled when gl.CULL_FACE is enabled
+func (s *StateCache) CullFace(mode uint32) {
+	if s.changed(s.cullFace != mode) {
+		s.cullFace = mode
+		Backend.CullFace(mode)
+		CheckError("glCullFace")
+	}
+}
+
+// PolygonMode sets whether we fill triangles or draw them as a wireframe (core profile only
+// supports gl.FRONT_AND_BACK so that is what we set)
+func (s *StateCache) PolygonMode(mode uint32) {
+	if s.changed(s.polygonMode != mode) {
+		s.polygonMode = mode
+		Backend.PolygonMode(gl.FRONT_AND_BACK, mode)
+		CheckError("glPolygonMode")
+	}
+}
+
+// forgetBuffer clears the bindings of a deleted buffer. openGL unbinds it from every target
+func (s *StateCache) forgetBuffer(buffer uint32) {
+	for target, bound := range s.buffers {
+		if bound == buffer {
+			s.buffers[target] = 0
+		}
+	}
+}
+
+// forgetVertexArray clears the binding of a deleted vao. openGL falls back to vao 0
+func (s *StateCache) forgetVertexArray(vao uint32) {
+	if s.vao == vao {
+		s.vao = 0
+		delete(s.buffers, gl.ELEMENT_ARRAY_BUFFER)
+	}
+}
+
+// forgetFramebuffer clears the bindings of a deleted framebuffer. openGL falls back to the window
+func (s *StateCache) forgetFramebuffer(framebuffer uint32) {
+	for target, bound := range s.framebuffers {
+		if bound == framebuffer {
+			s.framebuffers[target] = 0
+		}
+	}
+}
+
+// forgetTexture clears the bindings of a deleted texture. openGL unbinds it from every unit
+func (s *StateCache) forgetTexture(target, texture uint32) {
+	for binding, bound := range s.textures {
+		if binding.target == target && bound == texture {
+			s.textures[binding] = 0
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+func TestStateCacheSkipsRedundantCalls(t *testing.T) {
+	fake := useFakeGL(t)
+
+	State.UseProgram(3)
+	State.UseProgram(3)
+	State.BindBuffer(gl.ARRAY_BUFFER, 1)
+	State.BindBuffer(gl.ARRAY_BUFFER, 1)
+	State.BindBuffer(gl.UNIFORM_BUFFER, 1)
+	State.Enable(gl.DEPTH_TEST)
+	State.Enable(gl.DEPTH_TEST)
+	State.DepthFunc(gl.LESS)
+	State.DepthMask(true)
+	State.BlendFunc(gl.ONE, gl.ZERO)
+	State.CullFace(gl.BACK)
+	State.PolygonMode(gl.FILL)
+	// dithering starts out on
+	State.Enable(gl.DITHER)
+	State.PolygonMode(gl.LINE)
+
+	expectCalls(t, fake, "UseProgram", "BindBuffer", "BindBuffer", "Enable", "PolygonMode")
+	if want := (StateStats{Calls: 5, Saved: 9}); State.Frame != want {
+		t.Errorf("frame stats are %+v, want %+v", State.Frame, want)
+	}
+
+	State.EndFrame()
+	if State.LastFrame.Calls != 5 || State.Frame != (StateStats{}) {
+		t.Errorf("after EndFrame the stats are %+v and %+v", State.LastFrame, State.Frame)
+	}
+}
+
+func TestStateCacheInvalidate(t *testing.T) {
+	fake := useFakeGL(t)
+
+	State.UseProgram(3)
+	State.Disable(gl.DITHER)
+	State.Invalidate()
+	fake.Reset()
+
+	// after raw calls the cache assumes the defaults again so both are set
+	State.UseProgram(3)
+	State.Disable(gl.DITHER)
+	expectCalls(t, fake, "UseProgram", "Disable")
+}
+
+func TestStateCacheVertexArrayOwnsElementBuffer(t *testing.T) {
+	fake := useFakeGL(t)
+
+	State.BindVertexArray(1)
+	State.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, 4)
+	State.BindBuffer(gl.ARRAY_BUFFER, 5)
+	State.BindVertexArray(2)
+	State.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, 4)
+	State.BindBuffer(gl.ARRAY_BUFFER, 5)
+
+	// the new vao has its own element buffer binding but the array buffer is global
+	expectCalls(t, fake, "BindVertexArray", "BindBuffer", "BindBuffer", "BindVertexArray", "BindBuffer")
+	if fake.Buffers[gl.ELEMENT_ARRAY_BUFFER] != 4 || fake.VertexArray != 2 {
+		t.Errorf("bound vao %d with element buffer %d", fake.VertexArray, fake.Buffers[gl.ELEMENT_ARRAY_BUFFER])
+	}
+}
+
+func TestStateCacheTextureUnits(t *testing.T) {
+	fake := useFakeGL(t)
+
+	State.BindTexture(gl.TEXTURE0, gl.TEXTURE_2D, 1)
+	State.BindTexture(gl.TEXTURE1, gl.TEXTURE_2D, 2)
+	State.BindTexture(gl.TEXTURE0, gl.TEXTURE_2D, 1)
+	State.BindTexture(gl.TEXTURE1, gl.TEXTURE_2D, 2)
+	State.BindTexture(gl.TEXTURE0, gl.TEXTURE_2D, 3)
+
+	// texture unit 0 is active to begin with so it is only selected when coming back to it
+	expectCalls(t, fake, "BindTexture", "ActiveTexture", "BindTexture", "ActiveTexture", "BindTexture")
+	if fake.Textures[gl.TEXTURE0] != 3 || fake.Textures[gl.TEXTURE1] != 2 {
+		t.Errorf("bound textures are %v", fake.Textures)
+	}
+	// each gl call counts once and only the two binds that were skipped are saved
+	if want := (StateStats{Calls: 5, Saved: 2}); State.Frame != want {
+		t.Errorf("frame stats are %+v, want %+v", State.Frame, want)
+	}
+}
+
+func TestStateCacheFramebuffers(t *testing.T) {
+	fake := useFakeGL(t)
+
+	State.BindFramebuffer(gl.FRAMEBUFFER, 1)
+	// gl.FRAMEBUFFER set both so these do nothing
+	State.BindFramebuffer(gl.DRAW_FRAMEBUFFER, 1)
+	State.BindFramebuffer(gl.READ_FRAMEBUFFER, 1)
+	State.BindFramebuffer(gl.READ_FRAMEBUFFER, 2)
+	// only one of them is 1 now so both have to be set again
+	State.BindFramebuffer(gl.FRAMEBUFFER, 1)
+	expectCalls(t, fake, "BindFramebuffer", "BindFramebuffer", "BindFramebuffer")
+}
+
+func TestStateCacheViewport(t *testing.T) {
+	fake := useFakeGL(t)
+
+	State.Viewport(0, 0, 0, 0)
+	State.Viewport(0, 0, 0, 0)
+	State.Viewport(0, 0, 800, 600)
+	State.Viewport(0, 0, 800, 600)
+
+	// an empty viewport is never cached
+	expectCalls(t, fake, "Viewport", "Viewport", "Viewport")
+	if x, y, w, h := State.ViewportRect(); x != 0 || y != 0 || w != 800 || h != 600 {
+		t.Errorf("viewport is (%d, %d, %d, %d)", x, y, w, h)
+	}
+}
+
+func TestStateCacheForgetsDeletedObjects(t *testing.T) {
+	fake := useFakeGL(t)
+
+	buffer := GenBuffer()
+	vao := GenVertexArray()
+	State.BindBuffer(gl.ARRAY_BUFFER, buffer)
+	State.BindVertexArray(vao)
+	DeleteBuffer(buffer)
+	DeleteVertexArray(vao)
+	fake.Reset()
+
+	// openGL unbinds deleted objects so binding 0 is already done
+	State.BindBuffer(gl.ARRAY_BUFFER, 0)
+	State.BindVertexArray(0)
+	expectCalls(t, fake)
+}
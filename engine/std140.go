@@ -0,0 +1,147 @@
+package engine
+
+import (
+	"encoding/binary"
+	"math"
+	"reflect"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/pkg/errors"
+)
+
+// the mgl32 types are arrays of floats but GLSL lays them out differently from a float array
+var (
+	vec2Type = reflect.TypeOf(mgl32.Vec2{})
+	vec3Type = reflect.TypeOf(mgl32.Vec3{})
+	vec4Type = reflect.TypeOf(mgl32.Vec4{})
+	mat3Type = reflect.TypeOf(mgl32.Mat3{})
+	mat4Type = reflect.TypeOf(mgl32.Mat4{})
+)
+
+// std140 layout rules (section 7.6.2.2 of the openGL 4.5 spec) in short:
+//   - scalars (float32, int32, uint32 and bool) are 4 bytes and 4 byte aligned
+//   - a vec2 is 8 byte aligned, a vec3 and vec4 are 16 byte aligned (a vec3 is still 12 bytes so a
+//     scalar can sit right after it)
+//   - every array element is padded to 16 bytes, even arrays of floats
+//   - a matrix is an array of its columns, so a mat3 is 3 columns padded to 16 bytes each
+//   - a struct is 16 byte aligned and padded to a multiple of 16 bytes
+
+// std140Layout returns the alignment and size of a go type in std140 layout
+func std140Layout(t reflect.Type) (align, size int, err error) {
+	switch t {
+	case vec2Type:
+		return 8, 8, nil
+	case vec3Type:
+		return 16, 12, nil
+	case vec4Type:
+		return 16, 16, nil
+	case mat3Type:
+		return 16, 3 * 16, nil
+	case mat4Type:
+		return 16, 4 * 16, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Float32, reflect.Int32, reflect.Uint32, reflect.Bool:
+		return 4, 4, nil
+	case reflect.Array:
+		_, elemSize, err := std140Layout(t.Elem())
+		if err != nil {
+			return 0, 0, err
+		}
+		return 16, t.Len() * roundUp(elemSize, 16), nil
+	case reflect.Struct:
+		offset := 0
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			fieldAlign, fieldSize, err := std140Layout(field.Type)
+			if err != nil {
+				return 0, 0, errors.Wrapf(err, "field %s", field.Name)
+			}
+			offset = roundUp(offset, fieldAlign) + fieldSize
+		}
+		return 16, roundUp(offset, 16), nil
+	}
+	return 0, 0, errors.Errorf("%s has no std140 layout (use float32, int32, uint32, bool, mgl32 vectors and matrices, arrays and structs)", t)
+}
+
+// Std140Size returns how many bytes v takes up packed in std140 layout
+func Std140Size(v interface{}) (size int, err error) {
+	_, size, err = std140Layout(reflect.TypeOf(v))
+	return size, err
+}
+
+// PackStd140 packs v (usually a struct matching a uniform block) into bytes laid out the way
+// std140 expects. Fields are packed in order with the padding GLSL adds between them
+func PackStd140(v interface{}) (data []byte, err error) {
+	value := reflect.ValueOf(v)
+	size, err := Std140Size(v)
+	if err != nil {
+		return nil, err
+	}
+
+	data = make([]byte, size)
+	writeStd140(data, 0, value)
+	return data, nil
+}
+
+// writeStd140 writes the value at the offset. The layout has already been checked
+func writeStd140(data []byte, offset int, v reflect.Value) {
+	putFloat := func(offset int, f float32) {
+		binary.LittleEndian.PutUint32(data[offset:], math.Float32bits(f))
+	}
+
+	switch v.Type() {
+	case vec2Type, vec3Type, vec4Type:
+		for i := 0; i < v.Len(); i++ {
+			putFloat(offset+4*i, float32(v.Index(i).Float()))
+		}
+		return
+	case mat3Type, mat4Type:
+		// the matrices are column major like openGL, each column starts on a 16 byte boundary
+		n := 3
+		if v.Type() == mat4Type {
+			n = 4
+		}
+		for col := 0; col < n; col++ {
+			for row := 0; row < n; row++ {
+				putFloat(offset+16*col+4*row, float32(v.Index(col*n+row).Float()))
+			}
+		}
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Float32:
+		putFloat(offset, float32(v.Float()))
+	case reflect.Int32:
+		binary.LittleEndian.PutUint32(data[offset:], uint32(int32(v.Int())))
+	case reflect.Uint32:
+		binary.LittleEndian.PutUint32(data[offset:], uint32(v.Uint()))
+	case reflect.Bool:
+		b := uint32(0)
+		if v.Bool() {
+			b = 1
+		}
+		binary.LittleEndian.PutUint32(data[offset:], b)
+	case reflect.Array:
+		_, elemSize, _ := std140Layout(v.Type().Elem())
+		stride := roundUp(elemSize, 16)
+		for i := 0; i < v.Len(); i++ {
+			writeStd140(data, offset+i*stride, v.Index(i))
+		}
+	case reflect.Struct:
+		fieldOffset := 0
+		for i := 0; i < v.NumField(); i++ {
+			fieldAlign, fieldSize, _ := std140Layout(v.Field(i).Type())
+			fieldOffset = roundUp(fieldOffset, fieldAlign)
+			writeStd140(data, offset+fieldOffset, v.Field(i))
+			fieldOffset += fieldSize
+		}
+	}
+}
+
+// roundUp rounds n up to a multiple of align
+func roundUp(n, align int) int {
+	return (n + align - 1) / align * align
+}
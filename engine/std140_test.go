@@ -0,0 +1,183 @@
+package engine
+
+import (
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// floatAt reads the float packed at the offset
+func floatAt(data []byte, offset int) float32 {
+	return math.Float32frombits(binary.LittleEndian.Uint32(data[offset:]))
+}
+
+// uintAt reads the 4 byte integer packed at the offset
+func uintAt(data []byte, offset int) uint32 {
+	return binary.LittleEndian.Uint32(data[offset:])
+}
+
+// pack packs v and checks its size
+func pack(t *testing.T, v interface{}, size int) []byte {
+	t.Helper()
+	data, err := PackStd140(v)
+	if err != nil {
+		t.Fatalf("unable to pack %T: %v", v, err)
+	}
+	if len(data) != size {
+		t.Fatalf("%T packed to %d bytes, want %d", v, len(data), size)
+	}
+	return data
+}
+
+// expectFloats checks the floats packed at each offset
+func expectFloats(t *testing.T, data []byte, want map[int]float32) {
+	t.Helper()
+	for offset, f := range want {
+		if got := floatAt(data, offset); got != f {
+			t.Errorf("float at %d is %v, want %v", offset, got, f)
+		}
+	}
+}
+
+func TestStd140ScalarAfterVec3(t *testing.T) {
+	// the float fits in the last 4 bytes of the vec3's 16
+	data := pack(t, struct {
+		V mgl32.Vec3
+		F float32
+	}{mgl32.Vec3{1, 2, 3}, 4}, 16)
+	expectFloats(t, data, map[int]float32{0: 1, 4: 2, 8: 3, 12: 4})
+
+	// but a vec3 after a float starts on the next 16 bytes
+	data = pack(t, struct {
+		F float32
+		V mgl32.Vec3
+	}{4, mgl32.Vec3{1, 2, 3}}, 32)
+	expectFloats(t, data, map[int]float32{0: 4, 16: 1, 20: 2, 24: 3})
+
+	// and a vec2 only needs 8
+	data = pack(t, struct {
+		F float32
+		V mgl32.Vec2
+	}{4, mgl32.Vec2{1, 2}}, 16)
+	expectFloats(t, data, map[int]float32{0: 4, 8: 1, 12: 2})
+}
+
+func TestStd140Arrays(t *testing.T) {
+	// every float in an array gets 16 bytes to itself
+	data := pack(t, struct {
+		A [3]float32
+		B float32
+	}{[3]float32{1, 2, 3}, 4}, 64)
+	expectFloats(t, data, map[int]float32{0: 1, 16: 2, 32: 3, 48: 4})
+
+	data = pack(t, struct {
+		A [2]mgl32.Vec3
+		B float32
+	}{[2]mgl32.Vec3{{1, 2, 3}, {4, 5, 6}}, 7}, 48)
+	expectFloats(t, data, map[int]float32{0: 1, 8: 3, 12: 0, 16: 4, 24: 6, 32: 7})
+}
+
+func TestStd140Mat3Columns(t *testing.T) {
+	m := mgl32.Mat3{1, 2, 3, 4, 5, 6, 7, 8, 9}
+	data := pack(t, struct{ M mgl32.Mat3 }{m}, 48)
+
+	// each column is a vec3 padded to 16 bytes
+	expectFloats(t, data, map[int]float32{
+		0: 1, 4: 2, 8: 3, 12: 0,
+		16: 4, 20: 5, 24: 6, 28: 0,
+		32: 7, 36: 8, 40: 9, 44: 0,
+	})
+}
+
+func TestStd140Mat4(t *testing.T) {
+	m := mgl32.Translate3D(1, 2, 3)
+	data := pack(t, struct {
+		F float32
+		M mgl32.Mat4
+	}{5, m}, 80)
+	// the translation is the last column
+	expectFloats(t, data, map[int]float32{0: 5, 16: 1, 64: 1, 68: 2, 72: 3, 76: 1})
+}
+
+func TestStd140NestedStructs(t *testing.T) {
+	type inner struct {
+		V mgl32.Vec2
+		F float32
+	}
+	data := pack(t, struct {
+		A float32
+		S inner
+		B float32
+		T [2]inner
+	}{1, inner{mgl32.Vec2{2, 3}, 4}, 5, [2]inner{{mgl32.Vec2{6, 7}, 8}, {mgl32.Vec2{9, 10}, 11}}}, 80)
+
+	// a struct starts on 16 bytes and is padded out to 16, in and out of arrays
+	expectFloats(t, data, map[int]float32{
+		0:  1,
+		16: 2, 20: 3, 24: 4,
+		32: 5,
+		48: 6, 52: 7, 56: 8,
+		64: 9, 68: 10, 72: 11,
+	})
+}
+
+func TestStd140Integers(t *testing.T) {
+	data := pack(t, struct {
+		I int32
+		U uint32
+		B bool
+		C bool
+	}{-1, 7, true, false}, 16)
+	if uintAt(data, 0) != math.MaxUint32 || uintAt(data, 4) != 7 || uintAt(data, 8) != 1 || uintAt(data, 12) != 0 {
+		t.Errorf("packed %v, want -1, 7, true and false", data)
+	}
+}
+
+func TestStd140LightsBlock(t *testing.T) {
+	// each light is 4 vec3s sharing with a scalar and the shadow index, padded to 80 bytes. Then
+	// the 4 scalars, the matrices and the biases, which are padded out to 16 bytes each
+	const lightsEnd = MaxLights * 80
+	const size = lightsEnd + 16 + MaxShadows*64 + MaxShadows*16
+	if got, err := Std140Size(LightsBlock{}); err != nil || got != size {
+		t.Fatalf("the lights block is %d bytes (%v), want %d", got, err, size)
+	}
+
+	block := NewLightsBlock([]Light{NewPointLight(mgl32.Vec3{1, 2, 3}, mgl32.Vec3{1, 1, 1})}, true)
+	block.EnvironmentLevels = 5
+	block.ShadowBias[1] = 0.5
+	data := pack(t, block, size)
+
+	expectFloats(t, data, map[int]float32{0: 1, 4: 2, 8: 3})
+	if count, blinn := uintAt(data, lightsEnd), uintAt(data, lightsEnd+4); count != 1 || blinn != 1 {
+		t.Errorf("count is %d and blinn is %d, want 1 and 1", count, blinn)
+	}
+	expectFloats(t, data, map[int]float32{
+		lightsEnd + 12:                            5,
+		lightsEnd + 16 + MaxShadows*64 + 1*16:     0.5,
+		lightsEnd + 16 + MaxShadows*64 + 1*16 + 4: 0,
+	})
+}
+
+func TestStd140UnsupportedKinds(t *testing.T) {
+	cases := []struct {
+		v    interface{}
+		want string
+	}{
+		{float64(1), "float64"},
+		{[]float32{1}, "[]float32"},
+		{struct{ S string }{}, "field S: string"},
+		{struct{ A [2]int }{}, "field A: int has no std140 layout"},
+		{struct{ Inner struct{ P *float32 } }{}, "field Inner: field P: *float32"},
+	}
+	for _, c := range cases {
+		if _, err := PackStd140(c.v); err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("packing %T gave %v, want an error about %q", c.v, err, c.want)
+		}
+		if _, err := Std140Size(c.v); err == nil {
+			t.Errorf("%T has a size", c.v)
+		}
+	}
+}
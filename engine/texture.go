@@ -0,0 +1,162 @@
+package engine
+
+import (
+	"image"
+	"image/draw"
+	_ "image/jpeg" // register the jpeg decoder
+	_ "image/png"  // register the png decoder
+	"io/fs"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/pkg/errors"
+)
+
+// Texture manages a 2D texture for OpenGL
+type Texture struct {
+	ID     uint32
+	Width  int
+	Height int
+	// Samples is how many samples each texel has for multisampled textures and 0 for the rest
+	Samples int
+}
+
+// DecodeImage decodes a jpeg or png file into RGBA pixels ready to upload to a texture.
+// It doesn't touch openGL so it is safe to call from any goroutine
+func DecodeImage(fsys fs.FS, file string) (rgba *image.RGBA, err error) {
+	imgFile, err := fsys.Open(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to open texture file")
+	}
+	defer imgFile.Close()
+
+	img, _, err := image.Decode(imgFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to decode image file")
+	}
+
+	rgba = image.NewRGBA(img.Bounds())
+	draw.Draw(rgba, rgba.Bounds(), img, image.Point{0, 0}, draw.Src)
+	return rgba, nil
+}
+
+// NewTexture uploads the pixels to a new 2D texture. It has to be called on the main thread
+func NewTexture(rgba *image.RGBA) (t *Texture) {
+	return newTexture(rgba, gl.RGBA)
+}
+
+// NewSRGBTexture uploads colors stored in sRGB, like most painted textures, to a new 2D texture.
+// Sampling it converts them to linear colors so lighting can add them up correctly. Data that
+// isn't a color (normals, roughness, ...) is already linear and goes in a NewTexture. It has to
+// be called on the main thread
+func NewSRGBTexture(rgba *image.RGBA) (t *Texture) {
+	return newTexture(rgba, gl.SRGB8_ALPHA8)
+}
+
+// newTexture uploads the pixels to a new 2D texture stored in the internal format
+func newTexture(rgba *image.RGBA, internalFormat int32) (t *Texture) {
+	t = &Texture{
+		Width:  rgba.Rect.Size().X,
+		Height: rgba.Rect.Size().Y,
+	}
+
+	Backend.GenTextures(1, &t.ID)
+	Resources.Track(ResourceTexture, t.ID)
+	State.BindTexture(gl.TEXTURE0, gl.TEXTURE_2D, t.ID)
+	Backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	Backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	Backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.REPEAT)
+	Backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.REPEAT)
+	Backend.TexImage2D(
+		gl.TEXTURE_2D,    // What type of texture this is
+		0,                // what level of the mipmap you are creating (default is base 0)
+		internalFormat,   // format to store the texture as
+		int32(t.Width),   // width of the texture
+		int32(t.Height),  // height of the texture
+		gl.RGBA,          // format of the source image
+		gl.UNSIGNED_BYTE, // size of each element of the input
+		rgba.Pix,         // the actual image
+	)
+	CheckError("glTexImage2D")
+
+	return t
+}
+
+// NewRenderTexture creates an empty texture to render into, like the color of a framebuffer.
+// internalFormat is how it is stored (gl.RGBA8, gl.RGBA16F, gl.RG16F, ...) and format the
+// channels it has (gl.RGBA, gl.RG, ...). It stretches to the edges instead of repeating
+func NewRenderTexture(width, height int, internalFormat int32, format uint32) (t *Texture) {
+	t = &Texture{
+		Width:  width,
+		Height: height,
+	}
+
+	Backend.GenTextures(1, &t.ID)
+	Resources.Track(ResourceTexture, t.ID)
+	State.BindTexture(gl.TEXTURE0, gl.TEXTURE_2D, t.ID)
+	Backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	Backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	Backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	Backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	Backend.TexImage2D(gl.TEXTURE_2D, 0, internalFormat, int32(width), int32(height), format, gl.FLOAT, nil)
+	CheckError("NewRenderTexture")
+
+	return t
+}
+
+// NewDepthTexture creates an empty depth texture to render depth into. It is set up for shadow
+// mapping: sampling it with a sampler2DShadow compares against the stored depth and anything
+// outside of it counts as lit
+func NewDepthTexture(width, height int) (t *Texture) {
+	t = &Texture{
+		Width:  width,
+		Height: height,
+	}
+
+	Backend.GenTextures(1, &t.ID)
+	Resources.Track(ResourceTexture, t.ID)
+	State.BindTexture(gl.TEXTURE0, gl.TEXTURE_2D, t.ID)
+	// linear filtering lets the hardware blend 4 comparisons for a little free filtering
+	Backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	Backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	Backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_BORDER)
+	Backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_BORDER)
+	Backend.TexParameterfv(gl.TEXTURE_2D, gl.TEXTURE_BORDER_COLOR, []float32{1, 1, 1, 1})
+	Backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_COMPARE_MODE, gl.COMPARE_REF_TO_TEXTURE)
+	Backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_COMPARE_FUNC, gl.LEQUAL)
+	Backend.TexImage2D(gl.TEXTURE_2D, 0, gl.DEPTH_COMPONENT24, int32(width), int32(height), gl.DEPTH_COMPONENT, gl.FLOAT, nil)
+	CheckError("NewDepthTexture")
+
+	return t
+}
+
+// LoadTexture decodes and uploads a texture in one go. It has to be called on the main thread and
+// blocks while the image decodes, so use a Loader for anything big
+func LoadTexture(fsys fs.FS, file string) (t *Texture, err error) {
+	rgba, err := DecodeImage(fsys, file)
+	if err != nil {
+		return nil, err
+	}
+	t = NewTexture(rgba)
+	Label(gl.TEXTURE, t.ID, file)
+	return t, nil
+}
+
+// Target returns what the texture binds to: gl.TEXTURE_2D or gl.TEXTURE_2D_MULTISAMPLE
+func (t *Texture) Target() uint32 {
+	if t.Samples > 0 {
+		return gl.TEXTURE_2D_MULTISAMPLE
+	}
+	return gl.TEXTURE_2D
+}
+
+// Bind binds the texture to a texture unit (gl.TEXTURE0, gl.TEXTURE1, ...)
+func (t *Texture) Bind(unit uint32) {
+	State.BindTexture(unit, t.Target(), t.ID)
+}
+
+// Delete deletes the texture
+func (t *Texture) Delete() {
+	Backend.DeleteTextures(1, &t.ID)
+	State.forgetTexture(t.Target(), t.ID)
+	Resources.Untrack(ResourceTexture, t.ID)
+}
@@ -0,0 +1,103 @@
+package engine
+
+import (
+	"reflect"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/pkg/errors"
+)
+
+// bindingPoints hands out a uniform buffer binding point to every block name so a block is bound to
+// the same point in every program
+var bindingPoints = map[string]uint32{}
+
+// BindingPoint returns the binding point for a uniform block name, picking the next free one the
+// first time the name is seen
+func BindingPoint(block string) uint32 {
+	point, ok := bindingPoints[block]
+	if !ok {
+		point = uint32(len(bindingPoints))
+		bindingPoints[block] = point
+	}
+	return point
+}
+
+// UniformBuffer is a uniform buffer object holding a uniform block that can be shared between
+// programs. The go struct it is made from is packed with std140 layout so the block in GLSL has to
+// be declared with layout(std140) and the same members in the same order
+type UniformBuffer struct {
+	ID uint32
+	// Block is the name of the uniform block in GLSL
+	Block string
+	// Binding is the binding point the buffer is bound to
+	Binding uint32
+	// Size is the size of the block in bytes
+	Size int
+
+	layout reflect.Type
+}
+
+// NewUniformBuffer creates a uniform buffer for the block from the go struct v and binds it to the
+// block's binding point
+func NewUniformBuffer(block string, v interface{}) (u *UniformBuffer, err error) {
+	data, err := PackStd140(v)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to pack uniform block %s", block)
+	}
+
+	u = &UniformBuffer{
+		ID:      GenBuffer(),
+		Block:   block,
+		Binding: BindingPoint(block),
+		Size:    len(data),
+		layout:  reflect.TypeOf(v),
+	}
+	State.BindBuffer(gl.UNIFORM_BUFFER, u.ID)
+	Backend.BufferData(gl.UNIFORM_BUFFER, u.Size, data, gl.DYNAMIC_DRAW)
+	State.BindBufferBase(gl.UNIFORM_BUFFER, u.Binding, u.ID)
+	Label(gl.BUFFER, u.ID, block)
+	CheckError("NewUniformBuffer")
+	return u, nil
+}
+
+// Update packs v and uploads it to the buffer. v has to be the same type the buffer was made with
+func (u *UniformBuffer) Update(v interface{}) (err error) {
+	if t := reflect.TypeOf(v); t != u.layout {
+		return errors.Errorf("uniform block %s holds a %s not a %s", u.Block, u.layout, t)
+	}
+
+	data, err := PackStd140(v)
+	if err != nil {
+		return errors.Wrapf(err, "unable to pack uniform block %s", u.Block)
+	}
+	State.BindBuffer(gl.UNIFORM_BUFFER, u.ID)
+	Backend.BufferSubData(gl.UNIFORM_BUFFER, 0, len(data), data)
+	CheckError("UniformBuffer.Update")
+	return nil
+}
+
+// Bind points the program's uniform block at the buffer. It checks the size the program expects
+// against the go struct so a missing member or mismatched padding is caught instead of reading
+// garbage. Programs that don't use the block are left alone
+func (u *UniformBuffer) Bind(program uint32) (err error) {
+	index := Backend.GetUniformBlockIndex(program, u.Block)
+	if index == gl.INVALID_INDEX {
+		return nil
+	}
+
+	// drivers can round the size of the block up to 16 bytes, which we always do
+	var size int32
+	Backend.GetActiveUniformBlockiv(program, index, gl.UNIFORM_BLOCK_DATA_SIZE, &size)
+	if int(size) > u.Size || u.Size-int(size) >= 16 {
+		return errors.Errorf("uniform block %s is %d bytes in the shader but %d bytes from %s", u.Block, size, u.Size, u.layout)
+	}
+
+	Backend.UniformBlockBinding(program, index, u.Binding)
+	CheckError("UniformBuffer.Bind")
+	return nil
+}
+
+// Delete deletes the buffer
+func (u *UniformBuffer) Delete() {
+	DeleteBuffer(u.ID)
+}
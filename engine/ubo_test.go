@@ -0,0 +1,100 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// testBlock packs to 32 bytes: the vec3 and float share a 16 byte slot and the int is padded out
+type testBlock struct {
+	Color     mgl32.Vec3
+	Intensity float32
+	Count     int32
+}
+
+func TestNewUniformBuffer(t *testing.T) {
+	fake := useFakeGL(t)
+
+	u, err := NewUniformBuffer("TestBlock", testBlock{Intensity: 2})
+	if err != nil {
+		t.Fatalf("unable to create the uniform buffer: %v", err)
+	}
+
+	expectCalls(t, fake, "GenBuffers", "BindBuffer", "BufferData", "BindBufferBase")
+	if u.Size != 32 || fake.BufferSizes[u.ID] != 32 {
+		t.Errorf("buffer is %d bytes and %d were uploaded, want 32", u.Size, fake.BufferSizes[u.ID])
+	}
+	if u.Binding != BindingPoint("TestBlock") || fake.BufferBases[gl.UNIFORM_BUFFER][u.Binding] != u.ID {
+		t.Errorf("buffer %d isn't bound to binding point %d: %v", u.ID, u.Binding, fake.BufferBases)
+	}
+	if counts := Resources.Counts(); counts[ResourceBuffer] != 1 {
+		t.Errorf("tracked %d buffers, want 1", counts[ResourceBuffer])
+	}
+
+	u.Delete()
+	if counts := Resources.Counts(); counts[ResourceBuffer] != 0 {
+		t.Errorf("deleting the buffer left %d tracked", counts[ResourceBuffer])
+	}
+}
+
+func TestUniformBufferUpdate(t *testing.T) {
+	fake := useFakeGL(t)
+
+	u, err := NewUniformBuffer("TestBlock", testBlock{})
+	if err != nil {
+		t.Fatalf("unable to create the uniform buffer: %v", err)
+	}
+	fake.Reset()
+
+	if err := u.Update(testBlock{Count: 3}); err != nil {
+		t.Fatalf("unable to update: %v", err)
+	}
+	// the buffer is still bound from when it was made
+	expectCalls(t, fake, "BufferSubData")
+	data := fake.Find("BufferSubData")[0].Args[3].([]byte)
+	if len(data) != 32 || data[16] != 3 {
+		t.Errorf("uploaded %v, want the count at byte 16", data)
+	}
+
+	fake.Reset()
+	if err := u.Update(struct{ Count int32 }{3}); err == nil {
+		t.Errorf("updating with a different type should fail")
+	}
+	expectCalls(t, fake)
+}
+
+func TestUniformBufferBind(t *testing.T) {
+	fake := useFakeGL(t)
+
+	u, err := NewUniformBuffer("TestBlock", testBlock{})
+	if err != nil {
+		t.Fatalf("unable to create the uniform buffer: %v", err)
+	}
+
+	// programs that don't declare the block are left alone
+	fake.Reset()
+	if err := u.Bind(7); err != nil {
+		t.Fatalf("binding a program without the block failed: %v", err)
+	}
+	expectCalls(t, fake, "GetUniformBlockIndex")
+
+	// drivers may report the size without the padding at the end
+	fake.Reset()
+	fake.BlockSizes["TestBlock"] = 20
+	if err := u.Bind(7); err != nil {
+		t.Fatalf("unable to bind: %v", err)
+	}
+	expectCalls(t, fake, "GetUniformBlockIndex", "GetActiveUniformBlockiv", "UniformBlockBinding")
+	if binding := fake.Find("UniformBlockBinding")[0]; binding.Args[0] != uint32(7) || binding.Args[2] != u.Binding {
+		t.Errorf("bound %v, want program 7 to binding point %d", binding, u.Binding)
+	}
+
+	for _, size := range []int32{16, 48} {
+		fake.BlockSizes["TestBlock"] = size
+		if err := u.Bind(7); err == nil {
+			t.Errorf("a %d byte block in the shader should not match the 32 byte struct", size)
+		}
+	}
+}